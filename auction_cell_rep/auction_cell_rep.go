@@ -4,62 +4,163 @@ import (
 	"errors"
 	"net/url"
 	"strconv"
+	"sync"
 
 	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/clock"
 	"code.cloudfoundry.org/executor"
 	"code.cloudfoundry.org/lager"
 	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/crash_loop"
 	"code.cloudfoundry.org/rep/evacuation/evacuation_context"
+	"code.cloudfoundry.org/rep/image_cache"
+	"code.cloudfoundry.org/rep/quarantine"
 )
 
 var ErrPreloadedRootFSNotFound = errors.New("preloaded rootfs path not found")
-var ErrCellUnhealthy = errors.New("internal cell healthcheck failed")
+var ErrCellUnhealthy = rep.ErrCellUnhealthy
 
 type AuctionCellRep struct {
 	cellID               string
-	stackPathMap         rep.StackPathMap
-	rootFSProviders      rep.RootFSProviders
 	stack                string
 	zone                 string
 	generateInstanceGuid func() (string, error)
 	client               executor.Client
 	evacuationReporter   evacuation_context.EvacuationReporter
+	quarantine           quarantine.Tracker
+	imageCache           image_cache.Cache
+	crashLoop            crash_loop.Tracker
+	clock                clock.Clock
 	logger               lager.Logger
+
+	configLock sync.RWMutex
+	config     placementConfig
+
+	holdsLock       sync.Mutex
+	holds           map[string]rep.Hold
+	committingHolds map[string]bool
+
+	// allocationLock serializes performWork, so that the containers counted
+	// against the instance limit include those allocated by concurrent work.
+	allocationLock sync.Mutex
+
+	healthLock sync.Mutex
+	health     rep.CellHealth
+}
+
+// Config holds the options with which the cell takes part in auctions.
+type Config struct {
+	CellID                 string
+	Zone                   string
+	PreloadedStackPathMap  rep.StackPathMap
+	RootFSProviderSpecs    []string
+	MaxInstancesPerProcess int
+	Overcommit             rep.OvercommitFactors
+}
+
+// AdmissionConfig holds the collaborators that decide whether the cell takes
+// on new work.
+type AdmissionConfig struct {
+	EvacuationReporter evacuation_context.EvacuationReporter
+	Quarantine         quarantine.Tracker
+	CrashLoop          crash_loop.Tracker
 }
 
 func New(
-	cellID string,
-	preloadedStackPathMap rep.StackPathMap,
-	arbitraryRootFSes []string,
-	zone string,
+	config Config,
+	admission AdmissionConfig,
 	generateInstanceGuid func() (string, error),
 	client executor.Client,
-	evacuationReporter evacuation_context.EvacuationReporter,
+	imageCache image_cache.Cache,
+	clock clock.Clock,
 	logger lager.Logger,
 ) *AuctionCellRep {
 	return &AuctionCellRep{
-		cellID:               cellID,
-		stackPathMap:         preloadedStackPathMap,
-		rootFSProviders:      rootFSProviders(preloadedStackPathMap, arbitraryRootFSes),
-		zone:                 zone,
+		cellID:               config.CellID,
+		zone:                 config.Zone,
 		generateInstanceGuid: generateInstanceGuid,
 		client:               client,
-		evacuationReporter:   evacuationReporter,
+		evacuationReporter:   admission.EvacuationReporter,
+		quarantine:           admission.Quarantine,
+		imageCache:           imageCache,
+		crashLoop:            admission.CrashLoop,
+		clock:                clock,
 		logger:               logger.Session("auction-delegate"),
+		config: placementConfig{
+			stackPathMap:           config.PreloadedStackPathMap,
+			rootFSProviderSpecs:    config.RootFSProviderSpecs,
+			rootFSProviders:        RootFSProviders(config.PreloadedStackPathMap, config.RootFSProviderSpecs),
+			maxInstancesPerProcess: config.MaxInstancesPerProcess,
+			overcommit:             config.Overcommit,
+		},
+		holds:           map[string]rep.Hold{},
+		committingHolds: map[string]bool{},
 	}
 }
 
-func rootFSProviders(preloaded rep.StackPathMap, arbitrary []string) rep.RootFSProviders {
-	rootFSProviders := rep.RootFSProviders{}
-	for _, scheme := range arbitrary {
-		rootFSProviders[scheme] = rep.ArbitraryRootFSProvider{}
+// placementConfig holds the settings that may be changed while the rep is
+// running.
+type placementConfig struct {
+	stackPathMap           rep.StackPathMap
+	rootFSProviderSpecs    []string
+	rootFSProviders        rep.RootFSProviders
+	maxInstancesPerProcess int
+	overcommit             rep.OvercommitFactors
+}
+
+// Reconfigure replaces the rootfs providers, instance limit and
+// overcommit factors used by subsequent auctions. Work already on the cell is
+// left alone.
+func (a *AuctionCellRep) Reconfigure(rootFSProviderSpecs []string, maxInstancesPerProcess int, overcommit rep.OvercommitFactors) {
+	a.configLock.Lock()
+	defer a.configLock.Unlock()
+
+	a.config = placementConfig{
+		stackPathMap:           a.config.stackPathMap,
+		rootFSProviderSpecs:    rootFSProviderSpecs,
+		rootFSProviders:        RootFSProviders(a.config.stackPathMap, rootFSProviderSpecs),
+		maxInstancesPerProcess: maxInstancesPerProcess,
+		overcommit:             overcommit,
 	}
 
-	stacks := make([]string, 0, len(preloaded))
-	for stack, _ := range preloaded {
-		stacks = append(stacks, stack)
+	a.logger.Info("reconfigured", lager.Data{
+		"rootfs-providers":          rootFSProviderSpecs,
+		"max-instances-per-process": maxInstancesPerProcess,
+		"overcommit":                overcommit,
+	})
+}
+
+// SetPreloadedStacks replaces the preloaded stacks offered by the cell.
+// Containers already running on a stack that is no longer offered are left
+// alone.
+func (a *AuctionCellRep) SetPreloadedStacks(stackPathMap rep.StackPathMap) {
+	a.configLock.Lock()
+	defer a.configLock.Unlock()
+
+	a.config.stackPathMap = stackPathMap
+	a.config.rootFSProviders = RootFSProviders(stackPathMap, a.config.rootFSProviderSpecs)
+
+	a.logger.Info("set-preloaded-stacks", lager.Data{"stacks": stackPathMap.PreloadedRootFSes()})
+}
+
+func (a *AuctionCellRep) currentConfig() placementConfig {
+	a.configLock.RLock()
+	defer a.configLock.RUnlock()
+	return a.config
+}
+
+// RootFSProviders builds the providers for a cell with the given preloaded
+// stacks that also accepts the rootfses described by the given provider
+// specifications. The specifications must already have been validated with
+// rep.ParseRootFSProviderSpecs; when they are invalid only the preloaded
+// stacks are accepted.
+func RootFSProviders(preloaded rep.StackPathMap, specs []string) rep.RootFSProviders {
+	rootFSProviders, err := rep.ParseRootFSProviderSpecs(specs)
+	if err != nil {
+		rootFSProviders = rep.RootFSProviders{}
 	}
-	rootFSProviders["preloaded"] = rep.NewFixedSetRootFSProvider(stacks...)
+
+	rootFSProviders["preloaded"] = preloaded.RootFSProvider()
 
 	return rootFSProviders
 }
@@ -75,7 +176,7 @@ func PathForRootFS(rootFS string, stackPathMap rep.StackPathMap) (string, error)
 	}
 
 	if url.Scheme == models.PreloadedRootFSScheme {
-		path, ok := stackPathMap[url.Opaque]
+		path, ok := stackPathMap.Path(url.Opaque, url.Query().Get(rep.StackVersionParam))
 		if !ok {
 			return "", ErrPreloadedRootFSNotFound
 		}
@@ -95,13 +196,22 @@ func (a *AuctionCellRep) State() (rep.CellState, error) {
 	healthy := a.client.Healthy(logger)
 	if !healthy {
 		logger.Error("failed-garden-health-check", nil)
-		return rep.CellState{}, ErrCellUnhealthy
+		health := a.recordHealth(logger, rep.CellUnhealthy, []string{healthReasonGardenUnhealthy})
+		return rep.CellState{
+			Zone:        a.zone,
+			Evacuating:  a.evacuationReporter.Evacuating(),
+			Health:      health,
+			Quarantined: a.quarantine.Quarantined(),
+		}, ErrCellUnhealthy
 	}
 
+	var degradedReasons []string
+
 	containers, err := a.client.ListContainers(logger)
+	containersListed := err == nil
 	if err != nil {
 		logger.Error("failed-to-fetch-containers", err)
-		return rep.CellState{}, err
+		degradedReasons = append(degradedReasons, healthReasonListContainersFailed)
 	}
 
 	totalResources, err := a.client.TotalResources(logger)
@@ -119,7 +229,7 @@ func (a *AuctionCellRep) State() (rep.CellState, error) {
 	volumeDrivers, err := a.client.VolumeDrivers(logger)
 	if err != nil {
 		logger.Error("failed-to-get-volume-drivers", err)
-		return rep.CellState{}, err
+		degradedReasons = append(degradedReasons, healthReasonVolumeDriversFailed)
 	}
 
 	var key *models.ActualLRPKey
@@ -131,6 +241,7 @@ func (a *AuctionCellRep) State() (rep.CellState, error) {
 	for i := range containers {
 		container := &containers[i]
 		resource := rep.Resource{MemoryMB: int32(container.MemoryMB), DiskMB: int32(container.DiskMB)}
+		a.imageCache.Record(container.RootFSPath)
 
 		if containerIsStarting(container) {
 			startingContainerCount++
@@ -151,14 +262,24 @@ func (a *AuctionCellRep) State() (rep.CellState, error) {
 			lrps = append(lrps, rep.NewLRP(*key, resource))
 		case rep.TaskLifecycle:
 			domain := container.Tags[rep.DomainTag]
-			tasks = append(tasks, rep.NewTask(container.Guid, domain, resource))
+			tasks = append(tasks, rep.NewTask(rep.TaskGuidFromContainer(*container), domain, resource))
 		}
 	}
 
+	realAvailableResources := a.convertResources(availableResources)
+	realTotalResources := a.convertResources(totalResources)
+
+	config := a.currentConfig()
+	holds := a.outstandingHolds(logger)
+	unheldResources := config.overcommit.ScaleAvailable(realAvailableResources, realTotalResources)
+	for i := range holds {
+		unheldResources.SubtractHold(&holds[i])
+	}
+
 	state := rep.NewCellState(
-		a.rootFSProviders,
-		a.convertResources(availableResources),
-		a.convertResources(totalResources),
+		config.rootFSProviders.Copy(),
+		unheldResources,
+		config.overcommit.ScaleTotal(realTotalResources),
 		lrps,
 		tasks,
 		a.zone,
@@ -166,13 +287,34 @@ func (a *AuctionCellRep) State() (rep.CellState, error) {
 		a.evacuationReporter.Evacuating(),
 		volumeDrivers,
 	)
+	state.Holds = holds
+	if !containersListed {
+		// Without its containers the cell cannot tell what it is running, so it
+		// offers no capacity and reports no instance counts rather than made-up
+		// ones.
+		state.AvailableResources = rep.Resources{}
+		state.ProcessInstanceCounts = nil
+	}
+	state.RealAvailableResources = realAvailableResources
+	state.RealTotalResources = realTotalResources
+	state.Health = a.recordHealth(logger, healthState(state.Evacuating, degradedReasons), degradedReasons)
+	state.Quarantined = a.quarantine.Quarantined()
+	state.CachedImages = a.imageCache.Images()
+	state.CrashLoopingProcesses = a.crashLoop.CrashLooping()
 
 	a.logger.Info("provided", lager.Data{
-		"available-resources": state.AvailableResources,
-		"total-resources":     state.TotalResources,
-		"num-lrps":            len(state.LRPs),
-		"zone":                state.Zone,
-		"evacuating":          state.Evacuating,
+		"available-resources":      state.AvailableResources,
+		"total-resources":          state.TotalResources,
+		"real-available-resources": state.RealAvailableResources,
+		"real-total-resources":     state.RealTotalResources,
+		"num-lrps":                 len(state.LRPs),
+		"num-holds":                len(state.Holds),
+		"zone":                     state.Zone,
+		"evacuating":               state.Evacuating,
+		"health":                   state.Health.State,
+		"quarantined":              state.Quarantined,
+		"num-cached-images":        len(state.CachedImages),
+		"crash-looping-processes":  state.CrashLoopingProcesses,
 	})
 
 	return state, nil
@@ -185,28 +327,113 @@ func containerIsStarting(container *executor.Container) bool {
 }
 
 func (a *AuctionCellRep) Perform(work rep.Work) (rep.Work, error) {
-	var failedWork = rep.Work{}
-
 	logger := a.logger.Session("auction-work", lager.Data{
 		"lrp-starts": len(work.LRPs),
 		"tasks":      len(work.Tasks),
 	})
 
-	if a.evacuationReporter.Evacuating() {
+	if !a.acceptingWork(logger) {
 		return work, nil
 	}
 
+	work, refusedWork := a.rejectInadmissibleWork(logger, work)
+	work, rejectedWork := a.rejectWorkOverUnheldCapacity(logger, work)
+	rejectedWork.LRPs = append(rejectedWork.LRPs, refusedWork.LRPs...)
+	rejectedWork.Tasks = append(rejectedWork.Tasks, refusedWork.Tasks...)
+
+	failedWork := a.performWork(logger, work)
+	failedWork.LRPs = append(failedWork.LRPs, rejectedWork.LRPs...)
+	failedWork.Tasks = append(failedWork.Tasks, rejectedWork.Tasks...)
+
+	return failedWork, nil
+}
+
+// rejectInadmissibleWork applies the checks that all new work goes through,
+// whether it is performed directly or against a hold. The quarantine is
+// consulted later, in performWork, once the work that is actually going to be
+// allocated is known.
+func (a *AuctionCellRep) rejectInadmissibleWork(logger lager.Logger, work rep.Work) (rep.Work, rep.Work) {
+	return a.rejectCrashLoopingLRPs(logger, work)
+}
+
+func (a *AuctionCellRep) performWork(logger lager.Logger, work rep.Work) rep.Work {
+	a.allocationLock.Lock()
+	defer a.allocationLock.Unlock()
+
+	var failedWork = rep.Work{}
+	if len(work.LRPs) == 0 && len(work.Tasks) == 0 {
+		return failedWork
+	}
+
+	// The same listing backs the instance limit and the guid collision
+	// checks. Without it neither can be enforced, so no work is accepted.
+	containers, err := a.client.ListContainers(logger)
+	if err != nil {
+		logger.Error("failed-to-fetch-containers", err)
+		return work
+	}
+	guidsInUse := containerGuids(containers)
+
+	lrpLogger := logger.Session("lrp-allocate-instances")
+	taskLogger := logger.Session("task-allocate-instances")
+
+	var lrpRequests, taskRequests []executor.AllocationRequest
+	var lrpMap map[string]*rep.LRP
+	var taskMap map[string]*rep.Task
+
 	if len(work.LRPs) > 0 {
-		lrpLogger := logger.Session("lrp-allocate-instances")
+		lrps, rejectedLRPs := a.rejectLRPsOverInstanceLimit(lrpLogger, work.LRPs, containers)
+		if len(rejectedLRPs) > 0 {
+			lrpLogger.Info("rejected-lrps-over-instance-limit", lager.Data{"num-rejected": len(rejectedLRPs)})
+			failedWork.LRPs = rejectedLRPs
+		}
 
-		requests, lrpMap, untranslatedLRPs := a.lrpsToAllocationRequest(work.LRPs)
+		var untranslatedLRPs []rep.LRP
+		lrpRequests, lrpMap, untranslatedLRPs = a.lrpsToAllocationRequest(lrps)
 		if len(untranslatedLRPs) > 0 {
 			lrpLogger.Info("failed-to-translate-lrps-to-containers", lager.Data{"num-failed-to-translate": len(untranslatedLRPs)})
-			failedWork.LRPs = untranslatedLRPs
+			failedWork.LRPs = append(failedWork.LRPs, untranslatedLRPs...)
 		}
 
-		lrpLogger.Info("requesting-container-allocation", lager.Data{"num-requesting-allocation": len(requests)})
-		failures, err := a.client.AllocateContainers(logger, requests)
+		var collisions []string
+		lrpRequests, collisions = rejectContainerGuidCollisions(lrpLogger, lrpRequests, guidsInUse, func(tags executor.Tags) []string {
+			return rep.LRPContainerGuids(tags[rep.ProcessGuidTag], tags[rep.InstanceGuidTag])
+		})
+		for _, guid := range collisions {
+			failedWork.LRPs = append(failedWork.LRPs, *lrpMap[guid])
+		}
+	}
+
+	if len(work.Tasks) > 0 {
+		var failedTasks []rep.Task
+		taskRequests, taskMap, failedTasks = a.tasksToAllocationRequests(work.Tasks)
+		if len(failedTasks) > 0 {
+			taskLogger.Info("failed-to-translate-tasks-to-containers", lager.Data{"num-failed-to-translate": len(failedTasks)})
+			failedWork.Tasks = failedTasks
+		}
+
+		var collisions []string
+		taskRequests, collisions = rejectContainerGuidCollisions(taskLogger, taskRequests, guidsInUse, func(tags executor.Tags) []string {
+			return rep.TaskContainerGuids(tags[rep.TaskGuidTag])
+		})
+		for _, guid := range collisions {
+			failedWork.Tasks = append(failedWork.Tasks, *taskMap[guid])
+		}
+	}
+
+	lrpRequests, taskRequests, quarantined := a.rejectRequestsOverQuarantine(logger, lrpRequests, taskRequests)
+	for _, guid := range quarantined {
+		if lrp, found := lrpMap[guid]; found {
+			failedWork.LRPs = append(failedWork.LRPs, *lrp)
+			continue
+		}
+		failedWork.Tasks = append(failedWork.Tasks, *taskMap[guid])
+	}
+
+	if len(lrpRequests) > 0 {
+		lrpLogger.Info("requesting-container-allocation", lager.Data{"num-requesting-allocation": len(lrpRequests)})
+		failures, err := a.client.AllocateContainers(logger, lrpRequests)
+		a.recordAllocationOutcome(lrpLogger, len(lrpRequests), failures, err)
 		if err != nil {
 			lrpLogger.Error("failed-requesting-container-allocation", err)
 			failedWork.LRPs = work.LRPs
@@ -222,17 +449,10 @@ func (a *AuctionCellRep) Perform(work rep.Work) (rep.Work, error) {
 		}
 	}
 
-	if len(work.Tasks) > 0 {
-		taskLogger := logger.Session("task-allocate-instances")
-
-		requests, taskMap, failedTasks := a.tasksToAllocationRequests(work.Tasks)
-		if len(failedTasks) > 0 {
-			taskLogger.Info("failed-to-translate-tasks-to-containers", lager.Data{"num-failed-to-translate": len(failedTasks)})
-			failedWork.Tasks = failedTasks
-		}
-
-		taskLogger.Info("requesting-container-allocation", lager.Data{"num-requesting-allocation": len(requests)})
-		failures, err := a.client.AllocateContainers(logger, requests)
+	if len(taskRequests) > 0 {
+		taskLogger.Info("requesting-container-allocation", lager.Data{"num-requesting-allocation": len(taskRequests)})
+		failures, err := a.client.AllocateContainers(logger, taskRequests)
+		a.recordAllocationOutcome(taskLogger, len(taskRequests), failures, err)
 		if err != nil {
 			taskLogger.Error("failed-requesting-container-allocation", err)
 			failedWork.Tasks = work.Tasks
@@ -248,13 +468,92 @@ func (a *AuctionCellRep) Perform(work rep.Work) (rep.Work, error) {
 		}
 	}
 
-	return failedWork, nil
+	return failedWork
+}
+
+// rejectLRPsOverInstanceLimit splits the given LRPs into those that fit
+// within the per-process instance limit, counting the instances already on
+// the cell, and those that would exceed it.
+func (a *AuctionCellRep) rejectLRPsOverInstanceLimit(logger lager.Logger, lrps []rep.LRP, containers []executor.Container) ([]rep.LRP, []rep.LRP) {
+	maxInstancesPerProcess := a.currentConfig().maxInstancesPerProcess
+	if maxInstancesPerProcess <= 0 {
+		return lrps, nil
+	}
+
+	instanceCounts := map[string]int{}
+	for i := range containers {
+		tags := containers[i].Tags
+		if tags[rep.LifecycleTag] != rep.LRPLifecycle {
+			continue
+		}
+		instanceCounts[tags[rep.ProcessGuidTag]]++
+	}
+
+	var accepted, rejected []rep.LRP
+	for i := range lrps {
+		lrp := &lrps[i]
+		if instanceCounts[lrp.ProcessGuid] >= maxInstancesPerProcess {
+			logger.Info("instance-limit-reached", lager.Data{"process-guid": lrp.ProcessGuid, "index": lrp.Index})
+			rejected = append(rejected, *lrp)
+			continue
+		}
+		instanceCounts[lrp.ProcessGuid]++
+		accepted = append(accepted, *lrp)
+	}
+
+	return accepted, rejected
+}
+
+// containerGuids returns the guids of the given containers.
+func containerGuids(containers []executor.Container) map[string]bool {
+	guids := make(map[string]bool, len(containers))
+	for i := range containers {
+		guids[containers[i].Guid] = true
+	}
+	return guids
+}
+
+// rejectContainerGuidCollisions drops the requests any of whose candidate
+// guids, legacy ones included, is held by an existing container or by an
+// earlier request in the batch. The guids of the dropped requests are
+// returned alongside the accepted requests.
+func rejectContainerGuidCollisions(
+	logger lager.Logger,
+	requests []executor.AllocationRequest,
+	guidsInUse map[string]bool,
+	candidateGuids func(executor.Tags) []string,
+) ([]executor.AllocationRequest, []string) {
+	accepted := make([]executor.AllocationRequest, 0, len(requests))
+	rejected := []string{}
+
+	for i := range requests {
+		request := &requests[i]
+
+		collided := false
+		for _, guid := range candidateGuids(request.Tags) {
+			if guidsInUse[guid] {
+				collided = true
+				break
+			}
+		}
+		if collided {
+			logger.Error("container-guid-collision", rep.ErrContainerGuidCollision, lager.Data{"container-guid": request.Guid})
+			rejected = append(rejected, request.Guid)
+			continue
+		}
+
+		guidsInUse[request.Guid] = true
+		accepted = append(accepted, *request)
+	}
+
+	return accepted, rejected
 }
 
 func (a *AuctionCellRep) lrpsToAllocationRequest(lrps []rep.LRP) ([]executor.AllocationRequest, map[string]*rep.LRP, []rep.LRP) {
 	requests := make([]executor.AllocationRequest, 0, len(lrps))
 	untranslatedLRPs := make([]rep.LRP, 0)
 	lrpMap := make(map[string]*rep.LRP, len(lrps))
+	stackPathMap := a.currentConfig().stackPathMap
 	for i := range lrps {
 		lrp := &lrps[i]
 		tags := executor.Tags{}
@@ -271,7 +570,7 @@ func (a *AuctionCellRep) lrpsToAllocationRequest(lrps []rep.LRP) ([]executor.All
 		tags[rep.LifecycleTag] = rep.LRPLifecycle
 		tags[rep.InstanceGuidTag] = instanceGuid
 
-		rootFSPath, err := PathForRootFS(lrp.RootFs, a.stackPathMap)
+		rootFSPath, err := PathForRootFS(lrp.RootFs, stackPathMap)
 		if err != nil {
 			untranslatedLRPs = append(untranslatedLRPs, *lrp)
 			continue
@@ -291,11 +590,13 @@ func (a *AuctionCellRep) tasksToAllocationRequests(tasks []rep.Task) ([]executor
 	failedTasks := make([]rep.Task, 0)
 	taskMap := make(map[string]*rep.Task, len(tasks))
 	requests := make([]executor.AllocationRequest, 0, len(tasks))
+	stackPathMap := a.currentConfig().stackPathMap
 
 	for i := range tasks {
 		task := &tasks[i]
-		taskMap[task.TaskGuid] = task
-		rootFSPath, err := PathForRootFS(task.RootFs, a.stackPathMap)
+		containerGuid := rep.TaskContainerGuid(task.TaskGuid)
+		taskMap[containerGuid] = task
+		rootFSPath, err := PathForRootFS(task.RootFs, stackPathMap)
 		if err != nil {
 			failedTasks = append(failedTasks, *task)
 			continue
@@ -303,9 +604,10 @@ func (a *AuctionCellRep) tasksToAllocationRequests(tasks []rep.Task) ([]executor
 		tags := executor.Tags{}
 		tags[rep.LifecycleTag] = rep.TaskLifecycle
 		tags[rep.DomainTag] = task.Domain
+		tags[rep.TaskGuidTag] = task.TaskGuid
 
 		resource := executor.NewResource(int(task.MemoryMB), int(task.DiskMB), rootFSPath)
-		requests = append(requests, executor.NewAllocationRequest(task.TaskGuid, &resource, tags))
+		requests = append(requests, executor.NewAllocationRequest(containerGuid, &resource, tags))
 	}
 
 	return requests, taskMap, failedTasks
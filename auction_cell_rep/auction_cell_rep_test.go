@@ -2,14 +2,20 @@ package auction_cell_rep_test
 
 import (
 	"errors"
+	"time"
 
 	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/clock/fakeclock"
 	"code.cloudfoundry.org/executor"
 	fake_client "code.cloudfoundry.org/executor/fakes"
+	"code.cloudfoundry.org/lager"
 	"code.cloudfoundry.org/lager/lagertest"
 	"code.cloudfoundry.org/rep"
 	"code.cloudfoundry.org/rep/auction_cell_rep"
+	"code.cloudfoundry.org/rep/crash_loop/fake_crash_loop"
 	"code.cloudfoundry.org/rep/evacuation/evacuation_context/fake_evacuation_context"
+	"code.cloudfoundry.org/rep/image_cache"
+	"code.cloudfoundry.org/rep/quarantine/fake_quarantine"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -21,11 +27,17 @@ var _ = Describe("AuctionCellRep", func() {
 	var commonErr error
 	var logger *lagertest.TestLogger
 	var evacuationReporter *fake_evacuation_context.FakeEvacuationReporter
+	var quarantineTracker *fake_quarantine.FakeTracker
+	var imageCache image_cache.Cache
+	var crashLoopTracker *fake_crash_loop.FakeTracker
+	var fakeClock *fakeclock.FakeClock
 
 	const expectedCellID = "some-cell-id"
 	var expectedGuid string
 	var expectedGuidError error
 	var fakeGenerateContainerGuid func() (string, error)
+	var maxInstancesPerProcess int
+	var overcommit rep.OvercommitFactors
 
 	const linuxStack = "linux"
 	const linuxPath = "/data/rootfs/linux"
@@ -35,6 +47,13 @@ var _ = Describe("AuctionCellRep", func() {
 		client = new(fake_client.FakeClient)
 		logger = lagertest.NewTestLogger("test")
 		evacuationReporter = &fake_evacuation_context.FakeEvacuationReporter{}
+		quarantineTracker = &fake_quarantine.FakeTracker{}
+		quarantineTracker.AdmitStub = func(_ lager.Logger, requested int) int {
+			return requested
+		}
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		imageCache = image_cache.NewCache(10, fakeClock)
+		crashLoopTracker = new(fake_crash_loop.FakeTracker)
 
 		expectedGuid = "container-guid"
 		expectedGuidError = nil
@@ -42,13 +61,28 @@ var _ = Describe("AuctionCellRep", func() {
 			return expectedGuid, expectedGuidError
 		}
 		linuxRootFSURL = models.PreloadedRootFS(linuxStack)
+		maxInstancesPerProcess = 0
+		overcommit = rep.OvercommitFactors{}
 
 		commonErr = errors.New("Failed to fetch")
 		client.HealthyReturns(true)
 	})
 
 	JustBeforeEach(func() {
-		cellRep = auction_cell_rep.New(expectedCellID, rep.StackPathMap{linuxStack: linuxPath}, []string{"docker"}, "the-zone", fakeGenerateContainerGuid, client, evacuationReporter, logger)
+		config := auction_cell_rep.Config{
+			CellID:                 expectedCellID,
+			Zone:                   "the-zone",
+			PreloadedStackPathMap:  rep.StackPathMap{linuxStack: linuxPath},
+			RootFSProviderSpecs:    []string{"docker"},
+			MaxInstancesPerProcess: maxInstancesPerProcess,
+			Overcommit:             overcommit,
+		}
+		admission := auction_cell_rep.AdmissionConfig{
+			EvacuationReporter: evacuationReporter,
+			Quarantine:         quarantineTracker,
+			CrashLoop:          crashLoopTracker,
+		}
+		cellRep = auction_cell_rep.New(config, admission, fakeGenerateContainerGuid, client, imageCache, fakeClock, logger)
 	})
 
 	Describe("State", func() {
@@ -104,6 +138,16 @@ var _ = Describe("AuctionCellRep", func() {
 					},
 					State: executor.StateCreated,
 				},
+				{
+					Guid:     rep.TaskContainerGuid("namespaced-task"),
+					Resource: executor.NewResource(40, 30, "rootfs"),
+					Tags: executor.Tags{
+						rep.LifecycleTag: rep.TaskLifecycle,
+						rep.DomainTag:    "domain",
+						rep.TaskGuidTag:  "namespaced-task",
+					},
+					State: executor.StateRunning,
+				},
 				{
 					Guid:     "other-task",
 					Resource: executor.NewResource(40, 30, "rootfs"),
@@ -149,11 +193,149 @@ var _ = Describe("AuctionCellRep", func() {
 
 			Expect(state.Tasks).To(ConsistOf([]rep.Task{
 				rep.NewTask("da-task", "domain", rep.NewResource(40, 30, "", nil)),
+				rep.NewTask("namespaced-task", "domain", rep.NewResource(40, 30, "", nil)),
 			}))
 
 			Expect(state.StartingContainerCount).To(Equal(3))
 
+			Expect(state.ProcessInstanceCounts).To(Equal(map[string]int{
+				"the-first-app-guid":  1,
+				"the-second-app-guid": 1,
+			}))
+
 			Expect(state.VolumeDrivers).To(ConsistOf(volumeDrivers))
+			Expect(state.Holds).To(BeEmpty())
+
+			Expect(state.RealAvailableResources).To(Equal(state.AvailableResources))
+			Expect(state.RealTotalResources).To(Equal(state.TotalResources))
+
+			Expect(state.Health.State).To(Equal(rep.CellDraining))
+			Expect(state.Health.Reasons).To(ConsistOf("cell is evacuating"))
+			Expect(state.Health.CheckedAt).To(Equal(fakeClock.Now()))
+		})
+
+		Context("when all checks pass and the cell is not evacuating", func() {
+			BeforeEach(func() {
+				evacuationReporter.EvacuatingReturns(false)
+			})
+
+			It("reports the cell as healthy", func() {
+				state, err := cellRep.State()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(state.Health.State).To(Equal(rep.CellHealthy))
+				Expect(state.Health.Reasons).To(BeEmpty())
+				Expect(state.Health.Since).To(Equal(fakeClock.Now()))
+			})
+
+			It("keeps the time at which the cell became healthy", func() {
+				since := fakeClock.Now()
+				_, err := cellRep.State()
+				Expect(err).NotTo(HaveOccurred())
+
+				fakeClock.Increment(time.Minute)
+
+				state, err := cellRep.State()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(state.Health.Since).To(Equal(since))
+				Expect(state.Health.CheckedAt).To(Equal(fakeClock.Now()))
+			})
+		})
+
+		Context("when containers run docker images", func() {
+			BeforeEach(func() {
+				imageCache.Record("docker:///cloudfoundry/prepulled#v1")
+				containers[0].RootFSPath = "docker:///cloudfoundry/cflinuxfs2"
+				containers[1].RootFSPath = linuxPath
+			})
+
+			It("reports the docker images held by the cell", func() {
+				state, err := cellRep.State()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(state.CachedImages).To(Equal([]string{
+					"docker.io/cloudfoundry/cflinuxfs2:latest",
+					"docker.io/cloudfoundry/prepulled:v1",
+				}))
+			})
+		})
+
+		Context("when the cell is quarantined", func() {
+			BeforeEach(func() {
+				quarantineTracker.QuarantinedReturns(true)
+			})
+
+			It("reports the cell as quarantined", func() {
+				state, err := cellRep.State()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(state.Quarantined).To(BeTrue())
+			})
+		})
+
+		Context("when processes are crash looping", func() {
+			BeforeEach(func() {
+				crashLoopTracker.CrashLoopingReturns([]string{"process-a", "process-b"})
+			})
+
+			It("reports them", func() {
+				state, err := cellRep.State()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(state.CrashLoopingProcesses).To(Equal([]string{"process-a", "process-b"}))
+			})
+		})
+
+		Context("when overcommit factors are configured", func() {
+			BeforeEach(func() {
+				overcommit = rep.NewOvercommitFactors(1.5, 2.0)
+			})
+
+			It("scales the reported memory and disk", func() {
+				state, err := cellRep.State()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(state.TotalResources).To(Equal(rep.NewResources(1536, 4096, 4)))
+				Expect(state.AvailableResources).To(Equal(rep.NewResources(1024, 2304, 2)))
+			})
+
+			It("reports the real resources of the executor", func() {
+				state, err := cellRep.State()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(state.RealTotalResources).To(Equal(rep.NewResources(1024, 2048, 4)))
+				Expect(state.RealAvailableResources).To(Equal(rep.NewResources(512, 256, 2)))
+			})
+		})
+
+		Context("when the rep is reconfigured", func() {
+			JustBeforeEach(func() {
+				cellRep.(*auction_cell_rep.AuctionCellRep).Reconfigure([]string{"docker", "http"}, 0, rep.NewOvercommitFactors(2.0, 1.0))
+			})
+
+			It("reports the new rootfs providers and overcommitted resources", func() {
+				state, err := cellRep.State()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(state.RootFSProviders).To(Equal(rep.RootFSProviders{
+					models.PreloadedRootFSScheme: rep.NewFixedSetRootFSProvider("linux"),
+					"docker":                     rep.ArbitraryRootFSProvider{},
+					"http":                       rep.ArbitraryRootFSProvider{},
+				}))
+				Expect(state.TotalResources).To(Equal(rep.NewResources(2048, 2048, 4)))
+			})
+		})
+
+		Context("when the preloaded stacks change", func() {
+			JustBeforeEach(func() {
+				cellRep.(*auction_cell_rep.AuctionCellRep).SetPreloadedStacks(rep.StackPathMap{"windows": "/data/rootfs/windows"})
+			})
+
+			It("reports the new stacks alongside the configured providers", func() {
+				state, err := cellRep.State()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(state.RootFSProviders).To(Equal(rep.RootFSProviders{
+					models.PreloadedRootFSScheme: rep.NewFixedSetRootFSProvider("windows"),
+					"docker":                     rep.ArbitraryRootFSProvider{},
+				}))
+			})
 		})
 
 		Context("when the cell is not healthy", func() {
@@ -165,6 +347,12 @@ var _ = Describe("AuctionCellRep", func() {
 				_, err := cellRep.State()
 				Expect(err).To(MatchError(auction_cell_rep.ErrCellUnhealthy))
 			})
+
+			It("reports the cell as unhealthy", func() {
+				state, _ := cellRep.State()
+				Expect(state.Health.State).To(Equal(rep.CellUnhealthy))
+				Expect(state.Health.Reasons).To(ConsistOf("garden health check failed"))
+			})
 		})
 
 		Context("when the client fails to fetch total resources", func() {
@@ -193,13 +381,40 @@ var _ = Describe("AuctionCellRep", func() {
 
 		Context("when the client fails to list containers", func() {
 			BeforeEach(func() {
+				evacuationReporter.EvacuatingReturns(false)
 				client.ListContainersReturns(nil, commonErr)
 			})
 
-			It("should return an error and no state", func() {
+			It("reports a degraded state without containers", func() {
 				state, err := cellRep.State()
-				Expect(state).To(BeZero())
-				Expect(err).To(MatchError(commonErr))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(state.LRPs).To(BeEmpty())
+				Expect(state.Tasks).To(BeEmpty())
+				Expect(state.ProcessInstanceCounts).To(BeNil())
+				Expect(state.Health.State).To(Equal(rep.CellDegraded))
+				Expect(state.Health.Reasons).To(ConsistOf("failed to list containers"))
+			})
+
+			It("offers no capacity", func() {
+				state, err := cellRep.State()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(state.AvailableResources).To(BeZero())
+				Expect(state.TotalResources).NotTo(BeZero())
+			})
+		})
+
+		Context("when the client fails to list volume drivers", func() {
+			BeforeEach(func() {
+				evacuationReporter.EvacuatingReturns(false)
+				client.VolumeDriversReturns(nil, commonErr)
+			})
+
+			It("reports a degraded state without volume drivers", func() {
+				state, err := cellRep.State()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(state.VolumeDrivers).To(BeEmpty())
+				Expect(state.Health.State).To(Equal(rep.CellDegraded))
+				Expect(state.Health.Reasons).To(ConsistOf("failed to list volume drivers"))
 			})
 		})
 	})
@@ -233,6 +448,194 @@ var _ = Describe("AuctionCellRep", func() {
 			})
 		})
 
+		Context("when the cell is not healthy", func() {
+			BeforeEach(func() {
+				task := rep.NewTask("the-task-guid", "tests", rep.NewResource(2048, 1024, linuxRootFSURL, []string{}))
+				work = rep.Work{Tasks: []rep.Task{task}}
+			})
+
+			Context("because the garden health check fails", func() {
+				BeforeEach(func() {
+					client.HealthyReturns(false)
+				})
+
+				It("returns all work it was given", func() {
+					Expect(cellRep.Perform(work)).To(Equal(work))
+					Expect(client.AllocateContainersCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("because the last state was degraded", func() {
+				BeforeEach(func() {
+					client.ListContainersReturns(nil, commonErr)
+				})
+
+				It("returns all work it was given", func() {
+					state, err := cellRep.State()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(state.Health.State).To(Equal(rep.CellDegraded))
+
+					Expect(cellRep.Perform(work)).To(Equal(work))
+					Expect(client.AllocateContainersCallCount()).To(Equal(0))
+				})
+
+				It("accepts work again once the cell recovers, without the state being fetched", func() {
+					_, err := cellRep.State()
+					Expect(err).NotTo(HaveOccurred())
+
+					client.ListContainersReturns(nil, nil)
+					failedWork, err := cellRep.Perform(work)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(failedWork.Tasks).To(BeEmpty())
+					Expect(client.AllocateContainersCallCount()).To(Equal(1))
+				})
+			})
+		})
+
+		Context("when the cell is quarantined", func() {
+			var lrp rep.LRP
+
+			BeforeEach(func() {
+				lrp = rep.NewLRP(
+					models.NewActualLRPKey("process-guid", int32(expectedIndex), "tests"),
+					rep.NewResource(2048, 1024, linuxRootFSURL, []string{}),
+				)
+				task := rep.NewTask("the-task-guid", "tests", rep.NewResource(2048, 1024, linuxRootFSURL, []string{}))
+				work = rep.Work{LRPs: []rep.LRP{lrp}, Tasks: []rep.Task{task}}
+			})
+
+			Context("and no work is admitted", func() {
+				BeforeEach(func() {
+					quarantineTracker.AdmitReturns(0)
+				})
+
+				It("returns all work it was given", func() {
+					Expect(cellRep.Perform(work)).To(Equal(work))
+					Expect(client.AllocateContainersCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("and a probe is admitted", func() {
+				BeforeEach(func() {
+					quarantineTracker.AdmitReturns(1)
+					client.AllocateContainersReturns([]executor.AllocationFailure{}, nil)
+				})
+
+				It("performs only the probe and returns the rest", func() {
+					failedWork, err := cellRep.Perform(work)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(failedWork.LRPs).To(BeEmpty())
+					Expect(failedWork.Tasks).To(Equal(work.Tasks))
+
+					Expect(client.AllocateContainersCallCount()).To(Equal(1))
+					Expect(quarantineTracker.RecordSuccessCallCount()).To(Equal(1))
+				})
+			})
+		})
+
+		Context("when the containers on the cell are listed", func() {
+			BeforeEach(func() {
+				lrp := rep.NewLRP(
+					models.NewActualLRPKey("process-guid", int32(expectedIndex), "tests"),
+					rep.NewResource(2048, 1024, linuxRootFSURL, []string{}),
+				)
+				task := rep.NewTask("the-task-guid", "tests", rep.NewResource(2048, 1024, linuxRootFSURL, []string{}))
+				work = rep.Work{LRPs: []rep.LRP{lrp}, Tasks: []rep.Task{task}}
+			})
+
+			It("lists them once for all of the work", func() {
+				_, err := cellRep.Perform(work)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(client.ListContainersCallCount()).To(Equal(1))
+			})
+
+			Context("when listing them fails", func() {
+				BeforeEach(func() {
+					client.ListContainersReturns(nil, commonErr)
+				})
+
+				It("returns all work it was given without allocating it", func() {
+					Expect(cellRep.Perform(work)).To(Equal(work))
+					Expect(client.AllocateContainersCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Context("when a process is crash looping", func() {
+			var loopingLRP, healthyLRP rep.LRP
+
+			BeforeEach(func() {
+				loopingLRP = rep.NewLRP(
+					models.NewActualLRPKey("looping-process-guid", int32(expectedIndex), "tests"),
+					rep.NewResource(2048, 1024, linuxRootFSURL, []string{}),
+				)
+				healthyLRP = rep.NewLRP(
+					models.NewActualLRPKey("process-guid", int32(expectedIndex), "tests"),
+					rep.NewResource(2048, 1024, linuxRootFSURL, []string{}),
+				)
+				work = rep.Work{LRPs: []rep.LRP{loopingLRP, healthyLRP}}
+
+				crashLoopTracker.RejectsStub = func(processGuid string) bool {
+					return processGuid == "looping-process-guid"
+				}
+				client.AllocateContainersReturns([]executor.AllocationFailure{}, nil)
+			})
+
+			It("rejects its new instances and performs the rest", func() {
+				failedWork, err := cellRep.Perform(work)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(failedWork.LRPs).To(Equal([]rep.LRP{loopingLRP}))
+
+				Expect(client.AllocateContainersCallCount()).To(Equal(1))
+				_, requests := client.AllocateContainersArgsForCall(0)
+				Expect(requests).To(HaveLen(1))
+				Expect(requests[0].Tags[rep.ProcessGuidTag]).To(Equal("process-guid"))
+			})
+		})
+
+		Context("when allocations fail", func() {
+			BeforeEach(func() {
+				task := rep.NewTask("the-task-guid", "tests", rep.NewResource(2048, 1024, linuxRootFSURL, []string{}))
+				work = rep.Work{Tasks: []rep.Task{task}}
+			})
+
+			It("records a failure for each failed allocation", func() {
+				resource := executor.NewResource(2048, 1024, linuxPath)
+				request := executor.NewAllocationRequest("the-task-guid", &resource, executor.Tags{})
+				client.AllocateContainersReturns([]executor.AllocationFailure{executor.NewAllocationFailure(&request, commonErr.Error())}, nil)
+
+				_, err := cellRep.Perform(work)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(quarantineTracker.RecordFailureCallCount()).To(Equal(1))
+				_, reason := quarantineTracker.RecordFailureArgsForCall(0)
+				Expect(reason).To(Equal("container allocation failed"))
+				Expect(quarantineTracker.RecordSuccessCallCount()).To(Equal(0))
+			})
+
+			It("does not record a failure when the cell runs out of resources", func() {
+				resource := executor.NewResource(2048, 1024, linuxPath)
+				request := executor.NewAllocationRequest("the-task-guid", &resource, executor.Tags{})
+				client.AllocateContainersReturns([]executor.AllocationFailure{
+					executor.NewAllocationFailure(&request, executor.ErrInsufficientResourcesAvailable.Error()),
+				}, nil)
+
+				_, err := cellRep.Perform(work)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(quarantineTracker.RecordFailureCallCount()).To(Equal(0))
+				Expect(quarantineTracker.RecordSuccessCallCount()).To(Equal(0))
+			})
+
+			It("records a failure when the allocation request fails", func() {
+				client.AllocateContainersReturns(nil, commonErr)
+
+				_, err := cellRep.Perform(work)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(quarantineTracker.RecordFailureCallCount()).To(Equal(1))
+				_, reason := quarantineTracker.RecordFailureArgsForCall(0)
+				Expect(reason).To(Equal("container allocation request failed"))
+			})
+		})
+
 		Describe("performing starts", func() {
 			var lrpAuctionOne, lrpAuctionTwo rep.LRP
 			var expectedGuidOne = "instance-guid-1"
@@ -335,6 +738,104 @@ var _ = Describe("AuctionCellRep", func() {
 				})
 			})
 
+			Context("when a maximum number of instances per process is configured", func() {
+				var lrpAuctionThree rep.LRP
+
+				BeforeEach(func() {
+					maxInstancesPerProcess = 2
+
+					guidChan := make(chan string, 3)
+					guidChan <- expectedGuidOne
+					guidChan <- expectedGuidTwo
+					guidChan <- "instance-guid-3"
+					fakeGenerateContainerGuid = func() (string, error) {
+						return <-guidChan, nil
+					}
+
+					lrpAuctionOne.RootFs = linuxRootFSURL
+					lrpAuctionTwo.RootFs = linuxRootFSURL
+					lrpAuctionThree = rep.NewLRP(
+						models.NewActualLRPKey("other-process-guid", 0, "tests"),
+						rep.NewResource(2048, 1024, linuxRootFSURL, []string{}),
+					)
+
+					client.ListContainersReturns([]executor.Container{
+						{
+							Guid: "existing-instance-guid",
+							Tags: executor.Tags{
+								rep.LifecycleTag:    rep.LRPLifecycle,
+								rep.ProcessGuidTag:  "process-guid",
+								rep.ProcessIndexTag: "0",
+								rep.DomainTag:       "tests",
+							},
+						},
+					}, nil)
+				})
+
+				It("rejects the instances over the limit", func() {
+					failedWork, err := cellRep.Perform(rep.Work{LRPs: []rep.LRP{lrpAuctionOne, lrpAuctionTwo, lrpAuctionThree}})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(failedWork.LRPs).To(ConsistOf(lrpAuctionTwo))
+
+					Expect(client.AllocateContainersCallCount()).To(Equal(1))
+					_, arg := client.AllocateContainersArgsForCall(0)
+					Expect(arg).To(HaveLen(2))
+					Expect(arg[0].Tags[rep.ProcessGuidTag]).To(Equal("process-guid"))
+					Expect(arg[1].Tags[rep.ProcessGuidTag]).To(Equal("other-process-guid"))
+				})
+
+				It("counts the instances allocated by concurrent work", func() {
+					release := make(chan struct{})
+					client.AllocateContainersStub = func(lager.Logger, []executor.AllocationRequest) ([]executor.AllocationFailure, error) {
+						<-release
+						return nil, nil
+					}
+
+					firstDone := make(chan struct{})
+					go func() {
+						defer GinkgoRecover()
+						defer close(firstDone)
+						cellRep.Perform(rep.Work{LRPs: []rep.LRP{lrpAuctionOne}})
+					}()
+					Eventually(client.AllocateContainersCallCount).Should(Equal(1))
+					listed := client.ListContainersCallCount()
+
+					secondDone := make(chan struct{})
+					go func() {
+						defer GinkgoRecover()
+						defer close(secondDone)
+						cellRep.Perform(rep.Work{LRPs: []rep.LRP{lrpAuctionTwo}})
+					}()
+					Consistently(client.ListContainersCallCount).Should(Equal(listed))
+
+					close(release)
+					Eventually(firstDone).Should(BeClosed())
+					Eventually(secondDone).Should(BeClosed())
+					Expect(client.ListContainersCallCount()).To(BeNumerically(">", listed))
+				})
+
+				It("only asks the quarantine to admit the instances within the limit", func() {
+					_, err := cellRep.Perform(rep.Work{LRPs: []rep.LRP{lrpAuctionOne, lrpAuctionTwo, lrpAuctionThree}})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(quarantineTracker.AdmitCallCount()).To(Equal(1))
+					_, requested := quarantineTracker.AdmitArgsForCall(0)
+					Expect(requested).To(Equal(2))
+				})
+
+				Context("when listing the containers fails", func() {
+					BeforeEach(func() {
+						client.ListContainersReturns(nil, commonErr)
+					})
+
+					It("rejects all of the LRPs", func() {
+						failedWork, err := cellRep.Perform(rep.Work{LRPs: []rep.LRP{lrpAuctionOne, lrpAuctionTwo, lrpAuctionThree}})
+						Expect(err).NotTo(HaveOccurred())
+						Expect(failedWork.LRPs).To(ConsistOf(lrpAuctionOne, lrpAuctionTwo, lrpAuctionThree))
+					})
+				})
+			})
+
 			Context("when an LRP Auction specifies a preloaded RootFSes for which it cannot determine a RootFS path", func() {
 				BeforeEach(func() {
 					lrpAuctionOne.RootFs = linuxRootFSURL
@@ -545,7 +1046,7 @@ var _ = Describe("AuctionCellRep", func() {
 						resource := executor.NewResource(int(task1.MemoryMB), int(task1.DiskMB), "linux")
 						tags := executor.Tags{}
 						allocationRequest := executor.NewAllocationRequest(
-							task1.TaskGuid,
+							rep.TaskContainerGuid(task1.TaskGuid),
 							&resource,
 							tags,
 						)
@@ -561,6 +1062,46 @@ var _ = Describe("AuctionCellRep", func() {
 				})
 			})
 
+			Context("when a Task's container guid is already in use", func() {
+				BeforeEach(func() {
+					task1.RootFs = linuxRootFSURL
+					task2.RootFs = linuxRootFSURL
+
+					client.ListContainersReturns([]executor.Container{
+						{Guid: task1.TaskGuid},
+					}, nil)
+				})
+
+				It("rejects the Task without requesting its allocation", func() {
+					failedWork, err := cellRep.Perform(rep.Work{Tasks: []rep.Task{task1, task2}})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(failedWork.Tasks).To(ConsistOf(task1))
+
+					Expect(client.AllocateContainersCallCount()).To(Equal(1))
+					_, arg := client.AllocateContainersArgsForCall(0)
+					Expect(arg).To(ConsistOf(
+						allocationRequestFromTask(task2, linuxPath),
+					))
+				})
+			})
+
+			Context("when the same Task appears twice in the work", func() {
+				BeforeEach(func() {
+					task1.RootFs = linuxRootFSURL
+				})
+
+				It("rejects the duplicate", func() {
+					failedWork, err := cellRep.Perform(rep.Work{Tasks: []rep.Task{task1, task1}})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(failedWork.Tasks).To(ConsistOf(task1))
+
+					_, arg := client.AllocateContainersArgsForCall(0)
+					Expect(arg).To(ConsistOf(
+						allocationRequestFromTask(task1, linuxPath),
+					))
+				})
+			})
+
 			Context("when a Task specifies a preloaded RootFSes for which it cannot determine a RootFS path", func() {
 				BeforeEach(func() {
 					task1.RootFs = linuxRootFSURL
@@ -684,11 +1225,12 @@ var _ = Describe("AuctionCellRep", func() {
 func allocationRequestFromTask(task rep.Task, rootFSPath string) executor.AllocationRequest {
 	resource := executor.NewResource(int(task.MemoryMB), int(task.DiskMB), rootFSPath)
 	return executor.NewAllocationRequest(
-		task.TaskGuid,
+		rep.TaskContainerGuid(task.TaskGuid),
 		&resource,
 		executor.Tags{
 			rep.LifecycleTag: rep.TaskLifecycle,
 			rep.DomainTag:    task.Domain,
+			rep.TaskGuidTag:  task.TaskGuid,
 		},
 	)
 }
@@ -0,0 +1,28 @@
+package auction_cell_rep
+
+import (
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep"
+)
+
+// rejectCrashLoopingLRPs refuses new instances of the processes that keep
+// crashing on this cell, so that the auctioneer places them on other cells
+// until their cool-down elapses.
+func (a *AuctionCellRep) rejectCrashLoopingLRPs(logger lager.Logger, work rep.Work) (rep.Work, rep.Work) {
+	var rejected rep.Work
+	lrps := make([]rep.LRP, 0, len(work.LRPs))
+	for i := range work.LRPs {
+		if a.crashLoop.Rejects(work.LRPs[i].ProcessGuid) {
+			rejected.LRPs = append(rejected.LRPs, work.LRPs[i])
+			continue
+		}
+		lrps = append(lrps, work.LRPs[i])
+	}
+
+	if len(rejected.LRPs) > 0 {
+		logger.Info("rejected-crash-looping-lrps", lager.Data{"num-rejected": len(rejected.LRPs)})
+	}
+
+	work.LRPs = lrps
+	return work, rejected
+}
@@ -0,0 +1,96 @@
+package auction_cell_rep
+
+import (
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep"
+)
+
+const (
+	healthReasonGardenUnhealthy      = "garden health check failed"
+	healthReasonListContainersFailed = "failed to list containers"
+	healthReasonVolumeDriversFailed  = "failed to list volume drivers"
+	healthReasonEvacuating           = "cell is evacuating"
+)
+
+func healthState(evacuating bool, degradedReasons []string) rep.CellHealthState {
+	switch {
+	case evacuating:
+		return rep.CellDraining
+	case len(degradedReasons) > 0:
+		return rep.CellDegraded
+	default:
+		return rep.CellHealthy
+	}
+}
+
+// recordHealth records the outcome of a health check, keeping the time at
+// which the cell entered its current state.
+func (a *AuctionCellRep) recordHealth(logger lager.Logger, state rep.CellHealthState, reasons []string) rep.CellHealth {
+	if state == rep.CellDraining {
+		reasons = append([]string{healthReasonEvacuating}, reasons...)
+	}
+
+	now := a.clock.Now()
+
+	a.healthLock.Lock()
+	defer a.healthLock.Unlock()
+
+	if a.health.State != state {
+		logger.Info("cell-health-changed", lager.Data{"from": a.health.State, "to": state, "reasons": reasons})
+		a.health.Since = now
+	}
+
+	a.health = rep.NewCellHealth(state, reasons, a.health.Since, now)
+	return a.health
+}
+
+// acceptingWork reports whether the cell should take on new work. The garden
+// health check is cached by the executor and cheap to consult. The remaining
+// checks run when the state is fetched, and are repeated here while the last
+// state was degraded or unhealthy, so that a cell whose state is not fetched
+// again does not refuse work forever.
+func (a *AuctionCellRep) acceptingWork(logger lager.Logger) bool {
+	if a.evacuationReporter.Evacuating() {
+		return false
+	}
+
+	if !a.client.Healthy(logger) {
+		logger.Info("refusing-work", lager.Data{"health": rep.CellUnhealthy})
+		return false
+	}
+
+	a.healthLock.Lock()
+	state := a.health.State
+	a.healthLock.Unlock()
+
+	if state == rep.CellDegraded || state == rep.CellUnhealthy {
+		state = a.recheckHealth(logger)
+	}
+
+	if state == rep.CellDegraded || state == rep.CellUnhealthy {
+		logger.Info("refusing-work", lager.Data{"health": state})
+		return false
+	}
+
+	return true
+}
+
+// recheckHealth repeats the checks that degrade a cell whose garden health
+// check passes and which is not evacuating, and records the outcome.
+func (a *AuctionCellRep) recheckHealth(logger lager.Logger) rep.CellHealthState {
+	var degradedReasons []string
+
+	_, err := a.client.ListContainers(logger)
+	if err != nil {
+		logger.Error("failed-to-fetch-containers", err)
+		degradedReasons = append(degradedReasons, healthReasonListContainersFailed)
+	}
+
+	_, err = a.client.VolumeDrivers(logger)
+	if err != nil {
+		logger.Error("failed-to-get-volume-drivers", err)
+		degradedReasons = append(degradedReasons, healthReasonVolumeDriversFailed)
+	}
+
+	return a.recordHealth(logger, healthState(false, degradedReasons), degradedReasons).State
+}
@@ -0,0 +1,237 @@
+package auction_cell_rep
+
+import (
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep"
+)
+
+// Reserve sets aside the requested resources under a new hold, provided they
+// fit within the resources that are neither in use nor already held.
+func (a *AuctionCellRep) Reserve(request rep.HoldRequest) (rep.Hold, error) {
+	logger := a.logger.Session("auction-reserve", lager.Data{"resources": request.Resources, "ttl": request.TTL})
+	logger.Info("reserving")
+
+	err := request.Validate()
+	if err != nil {
+		logger.Error("invalid-hold-request", err)
+		return rep.Hold{}, err
+	}
+
+	if !a.acceptingWork(logger) {
+		return rep.Hold{}, rep.ErrorInsufficientResources
+	}
+
+	if a.quarantine.Quarantined() {
+		logger.Info("refusing-hold-while-quarantined")
+		return rep.Hold{}, rep.ErrorInsufficientResources
+	}
+
+	available, err := a.availableResources(logger)
+	if err != nil {
+		return rep.Hold{}, err
+	}
+
+	holdID, err := a.generateInstanceGuid()
+	if err != nil {
+		logger.Error("failed-to-generate-hold-id", err)
+		return rep.Hold{}, err
+	}
+
+	a.holdsLock.Lock()
+	defer a.holdsLock.Unlock()
+
+	a.pruneExpiredHolds(logger)
+
+	for _, hold := range a.holds {
+		available.SubtractHold(&hold)
+	}
+
+	if request.Resources.MemoryMB > available.MemoryMB ||
+		request.Resources.DiskMB > available.DiskMB ||
+		request.Resources.Containers > available.Containers {
+		logger.Info("insufficient-resources", lager.Data{"available-resources": available})
+		return rep.Hold{}, rep.ErrorInsufficientResources
+	}
+
+	hold := rep.NewHold(holdID, request.Resources, a.clock.Now().Add(request.TTL))
+	a.holds[holdID] = hold
+
+	logger.Info("reserved", lager.Data{"hold-id": holdID, "expires-at": hold.ExpiresAt})
+	return hold, nil
+}
+
+// CommitHold performs the given work against the resources set aside by the
+// hold, releasing the hold once the containers are allocated. Work that does
+// not fit within the hold, or that the cell is not accepting, is refused and
+// the hold is kept. The work goes through the same checks as work that is
+// performed directly.
+func (a *AuctionCellRep) CommitHold(holdID string, work rep.Work) (rep.Work, error) {
+	logger := a.logger.Session("auction-commit-hold", lager.Data{
+		"hold-id":    holdID,
+		"lrp-starts": len(work.LRPs),
+		"tasks":      len(work.Tasks),
+	})
+	logger.Info("committing")
+
+	a.holdsLock.Lock()
+	a.pruneExpiredHolds(logger)
+	hold, ok := a.holds[holdID]
+	if !ok || a.committingHolds[holdID] {
+		a.holdsLock.Unlock()
+		logger.Info("hold-not-found")
+		return rep.Work{}, rep.ErrorHoldNotFound
+	}
+
+	if !hold.Covers(&work) {
+		a.holdsLock.Unlock()
+		logger.Info("work-exceeds-hold", lager.Data{"held-resources": hold.Resources, "required-resources": work.Resources()})
+		return rep.Work{}, rep.ErrorWorkExceedsHold
+	}
+
+	// the hold keeps its resources set aside until the containers have been
+	// allocated, so that other work cannot claim them in the meantime
+	a.committingHolds[holdID] = true
+	a.holdsLock.Unlock()
+
+	if !a.acceptingWork(logger) {
+		a.holdsLock.Lock()
+		delete(a.committingHolds, holdID)
+		a.holdsLock.Unlock()
+		logger.Info("refused-work-keeping-hold")
+		return work, nil
+	}
+
+	work, rejectedWork := a.rejectInadmissibleWork(logger, work)
+
+	failedWork := a.performWork(logger, work)
+
+	a.holdsLock.Lock()
+	delete(a.holds, holdID)
+	delete(a.committingHolds, holdID)
+	a.holdsLock.Unlock()
+	failedWork.LRPs = append(failedWork.LRPs, rejectedWork.LRPs...)
+	failedWork.Tasks = append(failedWork.Tasks, rejectedWork.Tasks...)
+
+	logger.Info("committed", lager.Data{
+		"failed-lrp-starts": len(failedWork.LRPs),
+		"failed-tasks":      len(failedWork.Tasks),
+	})
+	return failedWork, nil
+}
+
+func (a *AuctionCellRep) ReleaseHold(holdID string) error {
+	logger := a.logger.Session("auction-release-hold", lager.Data{"hold-id": holdID})
+	logger.Info("releasing")
+
+	a.holdsLock.Lock()
+	defer a.holdsLock.Unlock()
+
+	a.pruneExpiredHolds(logger)
+	if _, ok := a.holds[holdID]; !ok || a.committingHolds[holdID] {
+		logger.Info("hold-not-found")
+		return rep.ErrorHoldNotFound
+	}
+
+	delete(a.holds, holdID)
+
+	logger.Info("released")
+	return nil
+}
+
+func (a *AuctionCellRep) outstandingHolds(logger lager.Logger) []rep.Hold {
+	a.holdsLock.Lock()
+	defer a.holdsLock.Unlock()
+
+	a.pruneExpiredHolds(logger)
+
+	holds := make([]rep.Hold, 0, len(a.holds))
+	for _, hold := range a.holds {
+		holds = append(holds, hold)
+	}
+	return holds
+}
+
+// rejectWorkOverUnheldCapacity keeps work submitted outside of a hold from
+// consuming resources that have been set aside by outstanding holds. Work that
+// does not fit in the unheld capacity is rejected.
+func (a *AuctionCellRep) rejectWorkOverUnheldCapacity(logger lager.Logger, work rep.Work) (rep.Work, rep.Work) {
+	holds := a.outstandingHolds(logger)
+	if len(holds) == 0 {
+		return work, rep.Work{}
+	}
+
+	available, err := a.availableResources(logger)
+	if err != nil {
+		return rep.Work{}, work
+	}
+
+	for i := range holds {
+		available.SubtractHold(&holds[i])
+	}
+
+	var accepted, rejected rep.Work
+	for i := range work.LRPs {
+		lrp := &work.LRPs[i]
+		if !fits(&available, &lrp.Resource) {
+			rejected.LRPs = append(rejected.LRPs, *lrp)
+			continue
+		}
+		available.Subtract(&lrp.Resource)
+		accepted.LRPs = append(accepted.LRPs, *lrp)
+	}
+	for i := range work.Tasks {
+		task := &work.Tasks[i]
+		if !fits(&available, &task.Resource) {
+			rejected.Tasks = append(rejected.Tasks, *task)
+			continue
+		}
+		available.Subtract(&task.Resource)
+		accepted.Tasks = append(accepted.Tasks, *task)
+	}
+
+	if len(rejected.LRPs) > 0 || len(rejected.Tasks) > 0 {
+		logger.Info("rejected-work-over-unheld-capacity", lager.Data{
+			"num-rejected-lrps":  len(rejected.LRPs),
+			"num-rejected-tasks": len(rejected.Tasks),
+			"num-holds":          len(holds),
+		})
+	}
+
+	return accepted, rejected
+}
+
+// availableResources returns the overcommitted resources not in use by any
+// container, without accounting for holds.
+func (a *AuctionCellRep) availableResources(logger lager.Logger) (rep.Resources, error) {
+	remainingResources, err := a.client.RemainingResources(logger)
+	if err != nil {
+		logger.Error("failed-to-get-remaining-resource", err)
+		return rep.Resources{}, err
+	}
+
+	totalResources, err := a.client.TotalResources(logger)
+	if err != nil {
+		logger.Error("failed-to-get-total-resources", err)
+		return rep.Resources{}, err
+	}
+
+	return a.currentConfig().overcommit.ScaleAvailable(a.convertResources(remainingResources), a.convertResources(totalResources)), nil
+}
+
+// pruneExpiredHolds releases every hold whose TTL has elapsed, except those
+// being committed. Callers must hold holdsLock.
+func (a *AuctionCellRep) pruneExpiredHolds(logger lager.Logger) {
+	now := a.clock.Now()
+	for id, hold := range a.holds {
+		if hold.Expired(now) && !a.committingHolds[id] {
+			logger.Info("released-expired-hold", lager.Data{"hold-id": id, "expired-at": hold.ExpiresAt})
+			delete(a.holds, id)
+		}
+	}
+}
+
+func fits(available *rep.Resources, res *rep.Resource) bool {
+	return available.MemoryMB >= res.MemoryMB &&
+		available.DiskMB >= res.DiskMB &&
+		available.Containers >= 1
+}
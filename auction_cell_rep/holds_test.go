@@ -0,0 +1,316 @@
+package auction_cell_rep_test
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/executor"
+	fake_client "code.cloudfoundry.org/executor/fakes"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/auction_cell_rep"
+	"code.cloudfoundry.org/rep/crash_loop"
+	"code.cloudfoundry.org/rep/evacuation/evacuation_context/fake_evacuation_context"
+	"code.cloudfoundry.org/rep/image_cache"
+	"code.cloudfoundry.org/rep/quarantine"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Holds", func() {
+	var (
+		cellRep            *auction_cell_rep.AuctionCellRep
+		client             *fake_client.FakeClient
+		evacuationReporter *fake_evacuation_context.FakeEvacuationReporter
+		fakeClock          *fakeclock.FakeClock
+		guidCount          int
+		ttl                time.Duration
+		crashLoopTracker   crash_loop.Tracker
+	)
+
+	const linuxStack = "linux"
+	const linuxPath = "/data/rootfs/linux"
+
+	BeforeEach(func() {
+		client = new(fake_client.FakeClient)
+		evacuationReporter = &fake_evacuation_context.FakeEvacuationReporter{}
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		ttl = 30 * time.Second
+		guidCount = 0
+
+		generateGuid := func() (string, error) {
+			guidCount++
+			return fmt.Sprintf("guid-%d", guidCount), nil
+		}
+
+		client.HealthyReturns(true)
+		client.RemainingResourcesReturns(executor.ExecutorResources{MemoryMB: 1024, DiskMB: 2048, Containers: 4}, nil)
+		client.TotalResourcesReturns(executor.ExecutorResources{MemoryMB: 1024, DiskMB: 2048, Containers: 4}, nil)
+
+		crashLoopTracker = crash_loop.NewTracker(crash_loop.Config{CrashThreshold: 1, Window: time.Minute, CoolDown: time.Minute, RejectInstances: true}, fakeClock)
+
+		config := auction_cell_rep.Config{
+			CellID:                "some-cell-id",
+			Zone:                  "the-zone",
+			PreloadedStackPathMap: rep.StackPathMap{linuxStack: linuxPath},
+			RootFSProviderSpecs:   []string{"docker"},
+		}
+		admission := auction_cell_rep.AdmissionConfig{
+			EvacuationReporter: evacuationReporter,
+			Quarantine:         quarantine.NewTracker(quarantine.Config{}, fakeClock),
+			CrashLoop:          crashLoopTracker,
+		}
+		cellRep = auction_cell_rep.New(config, admission, generateGuid, client, image_cache.NewCache(0, fakeClock), fakeClock, lagertest.NewTestLogger("test"))
+	})
+
+	Describe("Reserve", func() {
+		It("returns a hold that expires after the TTL", func() {
+			hold, err := cellRep.Reserve(rep.NewHoldRequest(512, 1024, 2, ttl))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hold).To(Equal(rep.NewHold("guid-1", rep.NewResources(512, 1024, 2), fakeClock.Now().Add(ttl))))
+		})
+
+		It("reports outstanding holds in the state and subtracts them from the available resources", func() {
+			hold, err := cellRep.Reserve(rep.NewHoldRequest(512, 1024, 2, ttl))
+			Expect(err).NotTo(HaveOccurred())
+
+			state, err := cellRep.State()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.Holds).To(ConsistOf(hold))
+			Expect(state.AvailableResources).To(Equal(rep.NewResources(512, 1024, 2)))
+		})
+
+		It("refuses to hold more than the unheld capacity", func() {
+			_, err := cellRep.Reserve(rep.NewHoldRequest(768, 1024, 2, ttl))
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = cellRep.Reserve(rep.NewHoldRequest(512, 512, 1, ttl))
+			Expect(err).To(Equal(rep.ErrorInsufficientResources))
+		})
+
+		Context("when the request is invalid", func() {
+			It("returns an invalid hold request error", func() {
+				_, err := cellRep.Reserve(rep.NewHoldRequest(512, 1024, 2, 0))
+				Expect(err).To(Equal(rep.ErrorInvalidHoldRequest))
+			})
+		})
+
+		Context("when evacuating", func() {
+			BeforeEach(func() {
+				evacuationReporter.EvacuatingReturns(true)
+			})
+
+			It("refuses to hold resources", func() {
+				_, err := cellRep.Reserve(rep.NewHoldRequest(512, 1024, 2, ttl))
+				Expect(err).To(Equal(rep.ErrorInsufficientResources))
+			})
+		})
+
+		Context("when the cell is not healthy", func() {
+			BeforeEach(func() {
+				client.HealthyReturns(false)
+			})
+
+			It("refuses to hold resources", func() {
+				_, err := cellRep.Reserve(rep.NewHoldRequest(512, 1024, 2, ttl))
+				Expect(err).To(Equal(rep.ErrorInsufficientResources))
+			})
+		})
+
+		Context("when fetching the remaining resources fails", func() {
+			BeforeEach(func() {
+				client.RemainingResourcesReturns(executor.ExecutorResources{}, errors.New("boom"))
+			})
+
+			It("returns the error", func() {
+				_, err := cellRep.Reserve(rep.NewHoldRequest(512, 1024, 2, ttl))
+				Expect(err).To(MatchError("boom"))
+			})
+		})
+
+		Context("when a hold expires", func() {
+			It("is released automatically", func() {
+				_, err := cellRep.Reserve(rep.NewHoldRequest(1024, 2048, 4, ttl))
+				Expect(err).NotTo(HaveOccurred())
+
+				fakeClock.Increment(ttl)
+
+				state, err := cellRep.State()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(state.Holds).To(BeEmpty())
+				Expect(state.AvailableResources).To(Equal(rep.NewResources(1024, 2048, 4)))
+
+				_, err = cellRep.Reserve(rep.NewHoldRequest(1024, 2048, 4, ttl))
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("CommitHold", func() {
+		var (
+			hold rep.Hold
+			lrp  rep.LRP
+			task rep.Task
+		)
+
+		BeforeEach(func() {
+			var err error
+			hold, err = cellRep.Reserve(rep.NewHoldRequest(512, 1024, 2, ttl))
+			Expect(err).NotTo(HaveOccurred())
+
+			lrp = rep.NewLRP(models.NewActualLRPKey("process-guid", 0, "domain"), rep.NewResource(256, 512, models.PreloadedRootFS(linuxStack), nil))
+			task = rep.NewTask("task-guid", "domain", rep.NewResource(256, 512, models.PreloadedRootFS(linuxStack), nil))
+		})
+
+		It("allocates containers for the work and releases the hold", func() {
+			failedWork, err := cellRep.CommitHold(hold.ID, rep.Work{LRPs: []rep.LRP{lrp}, Tasks: []rep.Task{task}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(failedWork).To(Equal(rep.Work{}))
+
+			Expect(client.AllocateContainersCallCount()).To(Equal(2))
+			_, lrpRequests := client.AllocateContainersArgsForCall(0)
+			Expect(lrpRequests).To(HaveLen(1))
+			_, taskRequests := client.AllocateContainersArgsForCall(1)
+			Expect(taskRequests).To(ConsistOf(allocationRequestFromTask(task, linuxPath)))
+
+			Expect(cellRep.ReleaseHold(hold.ID)).To(Equal(rep.ErrorHoldNotFound))
+		})
+
+		It("keeps the resources held until the containers are allocated", func() {
+			var heldDuringAllocation []rep.Hold
+			client.AllocateContainersStub = func(lager.Logger, []executor.AllocationRequest) ([]executor.AllocationFailure, error) {
+				state, err := cellRep.State()
+				Expect(err).NotTo(HaveOccurred())
+				heldDuringAllocation = state.Holds
+				return nil, nil
+			}
+
+			_, err := cellRep.CommitHold(hold.ID, rep.Work{Tasks: []rep.Task{task}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(heldDuringAllocation).To(ConsistOf(hold))
+
+			state, err := cellRep.State()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.Holds).To(BeEmpty())
+		})
+
+		Context("when the cell is not healthy", func() {
+			BeforeEach(func() {
+				client.HealthyReturns(false)
+			})
+
+			It("returns all the work and keeps the hold", func() {
+				work := rep.Work{LRPs: []rep.LRP{lrp}, Tasks: []rep.Task{task}}
+				failedWork, err := cellRep.CommitHold(hold.ID, work)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(failedWork).To(Equal(work))
+				Expect(client.AllocateContainersCallCount()).To(Equal(0))
+
+				Expect(cellRep.ReleaseHold(hold.ID)).To(Succeed())
+			})
+
+			It("lets the hold be committed once the cell recovers", func() {
+				work := rep.Work{Tasks: []rep.Task{task}}
+				_, err := cellRep.CommitHold(hold.ID, work)
+				Expect(err).NotTo(HaveOccurred())
+
+				client.HealthyReturns(true)
+				_, err = cellRep.CommitHold(hold.ID, work)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(client.AllocateContainersCallCount()).To(Equal(1))
+			})
+		})
+
+		Context("when the process is crash looping", func() {
+			BeforeEach(func() {
+				logger := lagertest.NewTestLogger("test")
+				crashLoopTracker.RecordCrash(logger, "process-guid", "container-guid-1")
+				crashLoopTracker.RecordCrash(logger, "process-guid", "container-guid-2")
+			})
+
+			It("rejects its instances", func() {
+				failedWork, err := cellRep.CommitHold(hold.ID, rep.Work{LRPs: []rep.LRP{lrp}, Tasks: []rep.Task{task}})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(failedWork.LRPs).To(ConsistOf(lrp))
+				Expect(failedWork.Tasks).To(BeEmpty())
+
+				Expect(client.AllocateContainersCallCount()).To(Equal(1))
+				_, requests := client.AllocateContainersArgsForCall(0)
+				Expect(requests).To(ConsistOf(allocationRequestFromTask(task, linuxPath)))
+			})
+		})
+
+		Context("when the hold does not exist", func() {
+			It("returns a hold not found error", func() {
+				_, err := cellRep.CommitHold("unknown", rep.Work{Tasks: []rep.Task{task}})
+				Expect(err).To(Equal(rep.ErrorHoldNotFound))
+				Expect(client.AllocateContainersCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the hold has expired", func() {
+			It("returns a hold not found error", func() {
+				fakeClock.Increment(ttl)
+
+				_, err := cellRep.CommitHold(hold.ID, rep.Work{Tasks: []rep.Task{task}})
+				Expect(err).To(Equal(rep.ErrorHoldNotFound))
+				Expect(client.AllocateContainersCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the work exceeds the hold", func() {
+			It("refuses the work and keeps the hold", func() {
+				bigTask := rep.NewTask("big-task-guid", "domain", rep.NewResource(1024, 512, "", nil))
+
+				_, err := cellRep.CommitHold(hold.ID, rep.Work{Tasks: []rep.Task{bigTask}})
+				Expect(err).To(Equal(rep.ErrorWorkExceedsHold))
+				Expect(client.AllocateContainersCallCount()).To(Equal(0))
+
+				Expect(cellRep.ReleaseHold(hold.ID)).To(Succeed())
+			})
+		})
+	})
+
+	Describe("ReleaseHold", func() {
+		It("returns the held resources to the available resources", func() {
+			hold, err := cellRep.Reserve(rep.NewHoldRequest(512, 1024, 2, ttl))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cellRep.ReleaseHold(hold.ID)).To(Succeed())
+
+			state, err := cellRep.State()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.Holds).To(BeEmpty())
+			Expect(state.AvailableResources).To(Equal(rep.NewResources(1024, 2048, 4)))
+		})
+
+		Context("when the hold does not exist", func() {
+			It("returns a hold not found error", func() {
+				Expect(cellRep.ReleaseHold("unknown")).To(Equal(rep.ErrorHoldNotFound))
+			})
+		})
+	})
+
+	Describe("Perform with outstanding holds", func() {
+		It("rejects work that does not fit in the unheld capacity", func() {
+			_, err := cellRep.Reserve(rep.NewHoldRequest(768, 1024, 2, ttl))
+			Expect(err).NotTo(HaveOccurred())
+
+			fits := rep.NewTask("fits", "domain", rep.NewResource(256, 512, "", nil))
+			tooBig := rep.NewTask("too-big", "domain", rep.NewResource(512, 512, "", nil))
+
+			failedWork, err := cellRep.Perform(rep.Work{Tasks: []rep.Task{fits, tooBig}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(failedWork.Tasks).To(ConsistOf(tooBig))
+
+			Expect(client.AllocateContainersCallCount()).To(Equal(1))
+			_, requests := client.AllocateContainersArgsForCall(0)
+			Expect(requests).To(ConsistOf(allocationRequestFromTask(fits, "")))
+		})
+	})
+})
@@ -0,0 +1,85 @@
+package auction_cell_rep
+
+import (
+	"code.cloudfoundry.org/executor"
+	"code.cloudfoundry.org/lager"
+)
+
+const (
+	quarantineReasonAllocationFailed        = "container allocation failed"
+	quarantineReasonAllocationRequestFailed = "container allocation request failed"
+)
+
+// rejectRequestsOverQuarantine splits the allocation requests into those the
+// quarantine tracker admits and those it refuses, returning the guids of the
+// refused ones. While the cell is quarantined nothing is admitted; once the
+// cool-down elapses only the probe allocations are. It is consulted only for
+// the requests that are about to be allocated, so that a probe is never spent
+// on work that is rejected for another reason and whose outcome is never
+// recorded.
+func (a *AuctionCellRep) rejectRequestsOverQuarantine(
+	logger lager.Logger,
+	lrpRequests []executor.AllocationRequest,
+	taskRequests []executor.AllocationRequest,
+) ([]executor.AllocationRequest, []executor.AllocationRequest, []string) {
+	requested := len(lrpRequests) + len(taskRequests)
+	if requested == 0 {
+		return lrpRequests, taskRequests, nil
+	}
+
+	admitted := a.quarantine.Admit(logger, requested)
+	if admitted >= requested {
+		return lrpRequests, taskRequests, nil
+	}
+
+	logger.Info("refusing-work-while-quarantined", lager.Data{"requested": requested, "admitted": admitted})
+
+	var refused []string
+	admit := func(requests []executor.AllocationRequest) []executor.AllocationRequest {
+		accepted := make([]executor.AllocationRequest, 0, len(requests))
+		for i := range requests {
+			if admitted > 0 {
+				accepted = append(accepted, requests[i])
+				admitted--
+				continue
+			}
+			refused = append(refused, requests[i].Guid)
+		}
+		return accepted
+	}
+
+	lrpRequests = admit(lrpRequests)
+	taskRequests = admit(taskRequests)
+	return lrpRequests, taskRequests, refused
+}
+
+func (a *AuctionCellRep) recordAllocationOutcome(logger lager.Logger, requested int, failures []executor.AllocationFailure, err error) {
+	if err != nil {
+		a.quarantine.RecordFailure(logger, quarantineReasonAllocationRequestFailed)
+		return
+	}
+
+	for i := range failures {
+		if isBenignAllocationFailure(failures[i]) {
+			continue
+		}
+		a.quarantine.RecordFailure(logger, quarantineReasonAllocationFailed)
+	}
+
+	if requested > len(failures) {
+		a.quarantine.RecordSuccess(logger)
+	}
+}
+
+// isBenignAllocationFailure reports whether the executor refused the
+// allocation for a reason that says nothing about the health of the cell, such
+// as losing a race for the remaining resources.
+func isBenignAllocationFailure(failure executor.AllocationFailure) bool {
+	switch failure.ErrorMsg {
+	case executor.ErrInsufficientResourcesAvailable.Error(),
+		executor.ErrContainerGuidNotAvailable.Error():
+		return true
+	default:
+		return false
+	}
+}
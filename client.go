@@ -34,6 +34,9 @@ func (factory *clientFactory) CreateClient(address string) Client {
 type AuctionCellClient interface {
 	State() (CellState, error)
 	Perform(work Work) (Work, error)
+	Reserve(request HoldRequest) (Hold, error)
+	CommitHold(holdID string, work Work) (Work, error)
+	ReleaseHold(holdID string) error
 }
 
 //go:generate counterfeiter -o repfakes/fake_client.go . Client
@@ -42,6 +45,8 @@ type Client interface {
 	AuctionCellClient
 	StopLRPInstance(key models.ActualLRPKey, instanceKey models.ActualLRPInstanceKey) error
 	CancelTask(taskGuid string) error
+	EvacuationStatus() (EvacuationStatus, error)
+	CancelEvacuation() error
 	SetStateClient(stateClient *http.Client)
 	StateClientTimeout() time.Duration
 }
@@ -89,6 +94,15 @@ func (c *client) State() (CellState, error) {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusServiceUnavailable {
+		var state CellState
+		err = json.NewDecoder(resp.Body).Decode(&state)
+		if err != nil {
+			return CellState{}, ErrCellUnhealthy
+		}
+		return state, ErrCellUnhealthy
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		return CellState{}, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
@@ -132,6 +146,100 @@ func (c *client) Perform(work Work) (Work, error) {
 	return failedWork, nil
 }
 
+func (c *client) Reserve(request HoldRequest) (Hold, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return Hold{}, err
+	}
+
+	req, err := c.requestGenerator.CreateRequest(ReserveRoute, nil, bytes.NewReader(body))
+	if err != nil {
+		return Hold{}, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return Hold{}, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusConflict:
+		return Hold{}, ErrorInsufficientResources
+	case http.StatusBadRequest:
+		return Hold{}, ErrorInvalidHoldRequest
+	default:
+		return Hold{}, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var hold Hold
+	err = json.NewDecoder(resp.Body).Decode(&hold)
+	if err != nil {
+		return Hold{}, err
+	}
+
+	return hold, nil
+}
+
+func (c *client) CommitHold(holdID string, work Work) (Work, error) {
+	body, err := json.Marshal(work)
+	if err != nil {
+		return Work{}, err
+	}
+
+	req, err := c.requestGenerator.CreateRequest(CommitHoldRoute, rata.Params{"hold_id": holdID}, bytes.NewReader(body))
+	if err != nil {
+		return Work{}, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return Work{}, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return Work{}, ErrorHoldNotFound
+	case http.StatusBadRequest:
+		return Work{}, ErrorWorkExceedsHold
+	default:
+		return Work{}, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var failedWork Work
+	err = json.NewDecoder(resp.Body).Decode(&failedWork)
+	if err != nil {
+		return Work{}, err
+	}
+
+	return failedWork, nil
+}
+
+func (c *client) ReleaseHold(holdID string) error {
+	req, err := c.requestGenerator.CreateRequest(ReleaseHoldRoute, rata.Params{"hold_id": holdID}, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return nil
+	case http.StatusNotFound:
+		return ErrorHoldNotFound
+	default:
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+}
+
 func (c *client) Reset() error {
 	req, err := c.requestGenerator.CreateRequest(Sim_ResetRoute, nil, nil)
 	if err != nil {
@@ -195,6 +303,53 @@ func (c *client) CancelTask(taskGuid string) error {
 	return nil
 }
 
+func (c *client) EvacuationStatus() (EvacuationStatus, error) {
+	req, err := c.requestGenerator.CreateRequest(EvacuationStatusRoute, nil, nil)
+	if err != nil {
+		return EvacuationStatus{}, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return EvacuationStatus{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return EvacuationStatus{}, fmt.Errorf("http error: status code %d (%s)", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	var status EvacuationStatus
+	err = json.NewDecoder(resp.Body).Decode(&status)
+	if err != nil {
+		return EvacuationStatus{}, err
+	}
+
+	return status, nil
+}
+
+func (c *client) CancelEvacuation() error {
+	req, err := c.requestGenerator.CreateRequest(CancelEvacuationRoute, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return nil
+	case http.StatusConflict:
+		return ErrNoEvacuationInProgress
+	default:
+		return fmt.Errorf("http error: status code %d (%s)", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+}
+
 func stopParamsFromLRP(
 	key models.ActualLRPKey,
 	instanceKey models.ActualLRPInstanceKey,
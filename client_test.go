@@ -110,6 +110,37 @@ var _ = Describe("Client", func() {
 		})
 	})
 
+	Describe("State", func() {
+		Context("when the cell is unhealthy", func() {
+			var unhealthyState rep.CellState
+
+			BeforeEach(func() {
+				unhealthyState = rep.CellState{
+					Zone: "some-zone",
+					Health: rep.CellHealth{
+						State:   rep.CellUnhealthy,
+						Reasons: []string{"garden health check failed"},
+					},
+				}
+
+				fakeServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/state"),
+						ghttp.RespondWithJSONEncoded(http.StatusServiceUnavailable, unhealthyState),
+					),
+				)
+			})
+
+			It("returns the state along with an unhealthy error", func() {
+				state, err := client.State()
+				Expect(err).To(Equal(rep.ErrCellUnhealthy))
+				Expect(state.Zone).To(Equal("some-zone"))
+				Expect(state.Health.State).To(Equal(rep.CellUnhealthy))
+				Expect(state.Health.Reasons).To(ConsistOf("garden health check failed"))
+			})
+		})
+	})
+
 	Describe("CancelTask", func() {
 		const cellAddr = "cell.example.com"
 		var cancelErr error
@@ -190,4 +221,99 @@ var _ = Describe("Client", func() {
 			})
 		})
 	})
+
+	Describe("EvacuationStatus", func() {
+		var status rep.EvacuationStatus
+		var statusErr error
+
+		JustBeforeEach(func() {
+			status, statusErr = client.EvacuationStatus()
+		})
+
+		Context("when the request is successful", func() {
+			var expectedStatus rep.EvacuationStatus
+
+			BeforeEach(func() {
+				expectedStatus = rep.EvacuationStatus{
+					Evacuating: true,
+					StartedAt:  time.Unix(1000, 0).UTC(),
+					Timeout:    10 * time.Minute,
+					Elapsed:    time.Minute,
+					RemainingContainers: map[string]map[string]int{
+						rep.LRPLifecycle: {"running": 2},
+					},
+					FailedContainers: []rep.EvacuationFailure{
+						{ContainerGuid: "container-guid", Operation: "evacuate-running-actual-lrp", Error: "boom", FailedAt: time.Unix(1030, 0).UTC()},
+					},
+				}
+
+				fakeServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/evacuate"),
+						ghttp.RespondWithJSONEncoded(http.StatusOK, expectedStatus),
+					),
+				)
+			})
+
+			It("returns the evacuation status", func() {
+				Expect(statusErr).NotTo(HaveOccurred())
+				Expect(status).To(Equal(expectedStatus))
+			})
+		})
+
+		Context("when the request returns 500", func() {
+			BeforeEach(func() {
+				fakeServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/evacuate"),
+						ghttp.RespondWith(http.StatusInternalServerError, ""),
+					),
+				)
+			})
+
+			It("returns an error", func() {
+				Expect(statusErr).To(HaveOccurred())
+				Expect(statusErr.Error()).To(ContainSubstring("http error: status code 500"))
+			})
+		})
+	})
+
+	Describe("CancelEvacuation", func() {
+		var cancelErr error
+
+		JustBeforeEach(func() {
+			cancelErr = client.CancelEvacuation()
+		})
+
+		Context("when the evacuation is cancelled", func() {
+			BeforeEach(func() {
+				fakeServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("DELETE", "/evacuate"),
+						ghttp.RespondWith(http.StatusNoContent, ""),
+					),
+				)
+			})
+
+			It("does not return an error", func() {
+				Expect(cancelErr).NotTo(HaveOccurred())
+				Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+			})
+		})
+
+		Context("when the cell is not evacuating", func() {
+			BeforeEach(func() {
+				fakeServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("DELETE", "/evacuate"),
+						ghttp.RespondWith(http.StatusConflict, ""),
+					),
+				)
+			})
+
+			It("returns ErrNoEvacuationInProgress", func() {
+				Expect(cancelErr).To(Equal(rep.ErrNoEvacuationInProgress))
+			})
+		})
+	})
 })
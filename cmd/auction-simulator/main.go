@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"code.cloudfoundry.org/rep/simulation"
+)
+
+var scenarioPath = flag.String(
+	"scenario",
+	"",
+	"path to a JSON or YAML scenario describing the cell fleet and the auctions to replay",
+)
+
+var startingContainerWeight = flag.Float64(
+	"startingContainerWeight",
+	-1,
+	"overrides the scenario's starting container weight when not negative",
+)
+
+var jsonOutput = flag.Bool(
+	"json",
+	false,
+	"print the placement report as JSON",
+)
+
+func main() {
+	flag.Parse()
+
+	if *scenarioPath == "" {
+		fmt.Fprintln(os.Stderr, "-scenario must be specified")
+		os.Exit(2)
+	}
+
+	file, err := os.Open(*scenarioPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to open scenario: %s\n", err)
+		os.Exit(1)
+	}
+
+	scenario, err := simulation.LoadScenario(file)
+	file.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid scenario: %s\n", err)
+		os.Exit(1)
+	}
+
+	weight := scenario.StartingContainerWeight
+	if *startingContainerWeight >= 0 {
+		weight = *startingContainerWeight
+	}
+
+	report := simulation.Replay(scenario, weight)
+
+	if *jsonOutput {
+		err = json.NewEncoder(os.Stdout).Encode(report)
+	} else {
+		err = printReport(os.Stdout, report)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to print report: %s\n", err)
+		os.Exit(1)
+	}
+
+	if len(report.Failed) > 0 {
+		os.Exit(3)
+	}
+}
+
+func printReport(out io.Writer, report simulation.Report) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(w, "starting container weight:\t%.3f\n", report.StartingContainerWeight)
+	fmt.Fprintf(w, "placed:\t%d\n", report.Placed)
+	fmt.Fprintf(w, "failed:\t%d\n", len(report.Failed))
+	fmt.Fprintf(w, "fragmentation:\t%.3f\n", report.Fragmentation)
+	fmt.Fprintf(w, "max zone spread:\t%d\n", report.MaxZoneSpread)
+	fmt.Fprintf(w, "unbalanced processes:\t%d\n", report.UnbalancedProcesses)
+
+	fmt.Fprintln(w, "\nCELL\tZONE\tLRPS\tTASKS\tMEMORY\tDISK\tCONTAINERS")
+	for _, cell := range report.Cells {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%.1f%%\t%.1f%%\t%.1f%%\n",
+			cell.ID, cell.Zone, cell.LRPs, cell.Tasks,
+			100*cell.MemoryUtilization, 100*cell.DiskUtilization, 100*cell.ContainerUtilization)
+	}
+
+	fmt.Fprintln(w, "\nZONE\tCELLS\tLRPS\tTASKS\tMEMORY")
+	for _, zone := range report.Zones {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%.1f%%\n", zone.Zone, zone.Cells, zone.LRPs, zone.Tasks, 100*zone.MemoryUtilization)
+	}
+
+	if len(report.Failed) > 0 {
+		fmt.Fprintln(w, "\nAUCTION\tWORK\tREASON")
+		for _, failure := range report.Failed {
+			work := failure.TaskGuid
+			if work == "" {
+				work = fmt.Sprintf("%s/%d", failure.ProcessGuid, failure.Index)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", failure.Auction, work, failure.Reason)
+		}
+	}
+
+	return w.Flush()
+}
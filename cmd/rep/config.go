@@ -0,0 +1,464 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"code.cloudfoundry.org/debugserver"
+	"code.cloudfoundry.org/lager"
+	"github.com/ghodss/yaml"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/http_server"
+)
+
+var configPath = flag.String(
+	"config",
+	"",
+	"path to a JSON or YAML file of option values keyed by flag name; flags given on the command line take precedence",
+)
+
+const redacted = "[REDACTED]"
+
+// Every flag is classified by how its value is shown in the effective
+// configuration. A flag in none of the sets below is redacted as a whole, so a
+// new flag must be added to one of them before its value is shown.
+
+// secretFlags hold values that may carry credentials as a whole.
+var secretFlags = map[string]bool{
+	"gardenHealthcheckProcessArgs": true,
+	"gardenHealthcheckProcessEnv":  true,
+	"postSetupHook":                true,
+}
+
+// urlFlags hold comma-separated URLs whose credentials and query strings are
+// redacted.
+var urlFlags = map[string]bool{
+	"bbsAddress":    true,
+	"consulCluster": true,
+	"locketAddress": true,
+	"gardenAddr":    true,
+}
+
+// hookFlags hold repeated 'domain=url' values whose URLs are redacted as for
+// urlFlags.
+var hookFlags = map[string]bool{
+	"taskCompletionHook": true,
+}
+
+// plainFlags hold values that are shown as they are.
+var plainFlags = map[string]bool{
+	"bbsCACert":                          true,
+	"bbsClientCert":                      true,
+	"bbsClientKey":                       true,
+	"bbsClientSessionCacheSize":          true,
+	"bbsMaxIdleConnsPerHost":             true,
+	"caCertsForDownloads":                true,
+	"cachePath":                          true,
+	"cellID":                             true,
+	"cellPresenceBackend":                true,
+	"communicationTimeout":               true,
+	"config":                             true,
+	"containerInodeLimit":                true,
+	"containerMaxCpuShares":              true,
+	"containerOwnerName":                 true,
+	"containerReapInterval":              true,
+	"crashArchiveDir":                    true,
+	"crashArchiveMaxAge":                 true,
+	"crashArchiveMaxFileSizeMB":          true,
+	"crashArchiveMaxSizeMB":              true,
+	"crashCapturePaths":                  true,
+	"crashLoopCoolDown":                  true,
+	"crashLoopThreshold":                 true,
+	"crashLoopWindow":                    true,
+	"createWorkPoolSize":                 true,
+	"debugAddr":                          true,
+	"defaultTaskEvacuationPolicy":        true,
+	"deleteWorkPoolSize":                 true,
+	"diskMB":                             true,
+	"diskOvercommitFactor":               true,
+	"dropsondePort":                      true,
+	"evacuationLRPDomains":               true,
+	"evacuationMaxConcurrentPerProcess":  true,
+	"evacuationMaxTaskWait":              true,
+	"evacuationPollingInterval":          true,
+	"evacuationReportDir":                true,
+	"evacuationTaskDomains":              true,
+	"evacuationTimeout":                  true,
+	"exportNetworkEnvVars":               true,
+	"gardenHealthcheckCommandRetryPause": true,
+	"gardenHealthcheckEmissionInterval":  true,
+	"gardenHealthcheckInterval":          true,
+	"gardenHealthcheckProcessDir":        true,
+	"gardenHealthcheckProcessPath":       true,
+	"gardenHealthcheckProcessUser":       true,
+	"gardenHealthcheckTimeout":           true,
+	"gardenNetwork":                      true,
+	"healthCheckWorkPoolSize":            true,
+	"healthyMonitoringInterval":          true,
+	"imageCacheSize":                     true,
+	"listenAddr":                         true,
+	"lockRetryInterval":                  true,
+	"lockTTL":                            true,
+	"locketCACertFile":                   true,
+	"locketClientCertFile":               true,
+	"locketClientKeyFile":                true,
+	"logLevel":                           true,
+	"maxCacheSizeInBytes":                true,
+	"maxConcurrentDownloads":             true,
+	"maxInstancesPerProcess":             true,
+	"memoryMB":                           true,
+	"memoryOvercommitFactor":             true,
+	"metricsWorkPoolSize":                true,
+	"pollingInterval":                    true,
+	"postSetupUser":                      true,
+	"preloadedRootFS":                    true,
+	"preloadedRootFSDir":                 true,
+	"preloadedRootFSScanInterval":        true,
+	"prepullTimeout":                     true,
+	"quarantineCoolDown":                 true,
+	"quarantineFailureThreshold":         true,
+	"quarantineFailureWindow":            true,
+	"quarantineProbes":                   true,
+	"readWorkPoolSize":                   true,
+	"rejectCrashLoopingInstances":        true,
+	"reservedExpirationTime":             true,
+	"rootFSProvider":                     true,
+	"sessionName":                        true,
+	"simulationCellCount":                true,
+	"simulationContainers":               true,
+	"simulationDiskMB":                   true,
+	"simulationMemoryMB":                 true,
+	"simulationMode":                     true,
+	"skipCertVerify":                     true,
+	"strictEvacuationHandOff":            true,
+	"taskCompletionHookConcurrency":      true,
+	"taskCompletionHookQueueSize":        true,
+	"taskCompletionHookTimeout":          true,
+	"taskEvacuationPolicy":               true,
+	"tempDir":                            true,
+	"trustedSystemCertificatesPath":      true,
+	"unhealthyMonitoringInterval":        true,
+	"volmanDriverPaths":                  true,
+	"zone":                               true,
+}
+
+type configErrors []error
+
+func (e configErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// commandLineFlags returns the names of the flags given on the command line.
+// It must be called before the config file is loaded.
+func commandLineFlags(flags *flag.FlagSet) map[string]bool {
+	set := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	return set
+}
+
+func readConfigFile(path string) (map[string]interface{}, error) {
+	payload, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]interface{}{}
+	err = yaml.Unmarshal(payload, &values)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %s", path, err)
+	}
+
+	return values, nil
+}
+
+// loadConfig applies the values in the file at path to every flag in flags
+// that was not given on the command line. Every invalid entry in the file is
+// reported in the returned error.
+func loadConfig(path string, flags *flag.FlagSet, commandLine map[string]bool) error {
+	values, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs configErrors
+	for _, name := range names {
+		f := flags.Lookup(name)
+		if f == nil || name == "config" {
+			errs = append(errs, fmt.Errorf("%s: unknown option", name))
+			continue
+		}
+
+		if commandLine[name] {
+			continue
+		}
+
+		settings, err := configSettings(f, values[name])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", name, err))
+			continue
+		}
+
+		for _, setting := range settings {
+			err := flags.Set(name, setting)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %s", name, err))
+				break
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// configSettings converts a value from the config file into the arguments
+// that would have been given for the flag on the command line.
+func configSettings(f *flag.Flag, value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case []interface{}:
+		elements := make([]string, 0, len(v))
+		for _, element := range v {
+			scalar, err := configScalar(element)
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, scalar)
+		}
+
+		switch f.Value.(type) {
+		case *providers, *stackPathMap, *taskEvacuationPolicies:
+			return elements, nil
+		case *argList:
+			return []string{strings.Join(elements, ",")}, nil
+		}
+		if f.Name == "volmanDriverPaths" {
+			return []string{strings.Join(elements, string(filepath.ListSeparator))}, nil
+		}
+		return nil, fmt.Errorf("does not accept a list")
+
+	case map[string]interface{}:
+		var separator string
+		switch f.Value.(type) {
+		case *stackPathMap:
+			separator = ":"
+		case *taskEvacuationPolicies:
+			separator = "="
+		default:
+			return nil, fmt.Errorf("does not accept a map")
+		}
+
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		settings := make([]string, 0, len(v))
+		for _, key := range keys {
+			scalar, err := configScalar(v[key])
+			if err != nil {
+				return nil, err
+			}
+			settings = append(settings, key+separator+scalar)
+		}
+		return settings, nil
+
+	default:
+		scalar, err := configScalar(value)
+		if err != nil {
+			return nil, err
+		}
+		return []string{scalar}, nil
+	}
+}
+
+func configScalar(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case nil:
+		return "", nil
+	default:
+		return "", fmt.Errorf("unsupported value %v", value)
+	}
+}
+
+// configSnapshot is the configuration of the running rep. The flags are only
+// set while the configuration is loaded at startup; after that the reloader
+// replaces the snapshot under the lock, so that the /config endpoint and the
+// reloaded members never observe a reload half applied.
+type configSnapshot struct {
+	lock       sync.RWMutex
+	values     map[string]string
+	reloadable reloadableConfig
+}
+
+func newConfigSnapshot(flags *flag.FlagSet) (*configSnapshot, error) {
+	values := map[string]string{}
+	flags.VisitAll(func(f *flag.Flag) {
+		values[f.Name] = f.Value.String()
+	})
+
+	rootFSProviders := *flags.Lookup("rootFSProvider").Value.(*providers)
+	reloadable, errs := parseReloadable(func(name string) string { return values[name] }, rootFSProviders)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return &configSnapshot{values: values, reloadable: reloadable}, nil
+}
+
+// Values returns the value of every flag.
+func (c *configSnapshot) Values() map[string]string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	values := make(map[string]string, len(c.values))
+	for name, value := range c.values {
+		values[name] = value
+	}
+	return values
+}
+
+func (c *configSnapshot) Reloadable() reloadableConfig {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.reloadable
+}
+
+func (c *configSnapshot) reload(changes map[string]configChange, reloadable reloadableConfig) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	values := make(map[string]string, len(c.values))
+	for name, value := range c.values {
+		values[name] = value
+	}
+	for name, change := range changes {
+		values[name] = change.Current
+	}
+
+	c.values = values
+	c.reloadable = reloadable
+}
+
+// effectiveConfig returns the value of every flag, with credentials redacted.
+func effectiveConfig(values map[string]string) map[string]string {
+	config := make(map[string]string, len(values))
+	for name, value := range values {
+		switch {
+		case secretFlags[name]:
+			if value != "" && value != "[]" {
+				value = redacted
+			}
+		case urlFlags[name]:
+			value = redactURLs(value)
+		case hookFlags[name]:
+			value = redactHooks(value)
+		case !plainFlags[name]:
+			value = redacted
+		}
+		config[name] = value
+	}
+	return config
+}
+
+func redactURLs(value string) string {
+	addresses := strings.Split(value, ",")
+	for i, address := range addresses {
+		addresses[i] = redactURL(address)
+	}
+	return strings.Join(addresses, ",")
+}
+
+// redactHooks redacts the URLs of a repeated flag shown as
+// '[domain=url domain=url]'.
+func redactHooks(value string) string {
+	hooks := strings.Fields(strings.TrimSuffix(strings.TrimPrefix(value, "["), "]"))
+	for i, hook := range hooks {
+		parts := strings.SplitN(hook, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		hooks[i] = parts[0] + "=" + redactURL(parts[1])
+	}
+	return "[" + strings.Join(hooks, " ") + "]"
+}
+
+// redactURL redacts the user info and the query string values of a URL,
+// either of which may carry credentials.
+func redactURL(address string) string {
+	u, err := url.Parse(address)
+	if err != nil || (u.User == nil && u.RawQuery == "") {
+		return address
+	}
+
+	if u.User != nil {
+		if _, hasPassword := u.User.Password(); hasPassword {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+		} else {
+			u.User = url.User(redacted)
+		}
+	}
+
+	if u.RawQuery != "" {
+		query := u.Query()
+		keys := make([]string, 0, len(query))
+		for key := range query {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		params := make([]string, 0, len(keys))
+		for _, key := range keys {
+			params = append(params, url.QueryEscape(key)+"="+redacted)
+		}
+		u.RawQuery = strings.Join(params, "&")
+	}
+
+	return u.String()
+}
+
+// debugServerRunner serves the standard debug endpoints along with the
+// effective configuration at /config.
+func debugServerRunner(address string, sink *lager.ReconfigurableSink, config *configSnapshot, logger lager.Logger) ifrit.Runner {
+	mux := http.NewServeMux()
+	mux.Handle("/", debugserver.Handler(sink))
+	mux.HandleFunc("/config", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		err := json.NewEncoder(w).Encode(effectiveConfig(config.Values()))
+		if err != nil {
+			logger.Error("failed-to-encode-config", err)
+		}
+	})
+
+	return http_server.New(address, mux)
+}
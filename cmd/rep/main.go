@@ -22,15 +22,24 @@ import (
 	"code.cloudfoundry.org/lager"
 	"code.cloudfoundry.org/localip"
 	"code.cloudfoundry.org/locket"
+	locketmodels "code.cloudfoundry.org/locket/models"
 	"code.cloudfoundry.org/operationq"
 	"code.cloudfoundry.org/rep"
 	"code.cloudfoundry.org/rep/auction_cell_rep"
+	"code.cloudfoundry.org/rep/crash_archive"
+	"code.cloudfoundry.org/rep/crash_loop"
 	"code.cloudfoundry.org/rep/evacuation"
 	"code.cloudfoundry.org/rep/evacuation/evacuation_context"
+	"code.cloudfoundry.org/rep/evacuation/evacuation_order"
+	"code.cloudfoundry.org/rep/evacuation/task_evacuation"
 	"code.cloudfoundry.org/rep/generator"
 	"code.cloudfoundry.org/rep/handlers"
 	"code.cloudfoundry.org/rep/harmonizer"
+	"code.cloudfoundry.org/rep/image_cache"
 	"code.cloudfoundry.org/rep/maintain"
+	"code.cloudfoundry.org/rep/preloaded_rootfs"
+	"code.cloudfoundry.org/rep/quarantine"
+	"code.cloudfoundry.org/rep/task_hooks"
 	"github.com/cloudfoundry/dropsonde"
 	"github.com/nu7hatch/gouuid"
 	"github.com/tedsuo/ifrit"
@@ -38,6 +47,8 @@ import (
 	"github.com/tedsuo/ifrit/http_server"
 	"github.com/tedsuo/ifrit/sigmon"
 	"github.com/tedsuo/rata"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 var sessionName = flag.String(
@@ -64,6 +75,36 @@ var lockRetryInterval = flag.Duration(
 	"interval to wait before retrying a failed lock acquisition",
 )
 
+var cellPresenceBackend = flag.String(
+	"cellPresenceBackend",
+	"consul",
+	"lock service used to maintain the cell presence: consul or locket",
+)
+
+var locketAddress = flag.String(
+	"locketAddress",
+	"",
+	"address of the locket server, required when the cell presence backend is locket",
+)
+
+var locketCACertFile = flag.String(
+	"locketCACertFile",
+	"",
+	"path to certificate authority cert used for mutually authenticated TLS locket communication",
+)
+
+var locketClientCertFile = flag.String(
+	"locketClientCertFile",
+	"",
+	"path to client cert used for mutually authenticated TLS locket communication",
+)
+
+var locketClientKeyFile = flag.String(
+	"locketClientKeyFile",
+	"",
+	"path to client key used for mutually authenticated TLS locket communication",
+)
+
 var listenAddr = flag.String(
 	"listenAddr",
 	"0.0.0.0:1800",
@@ -82,12 +123,144 @@ var zone = flag.String(
 	"the availability zone associated with the rep",
 )
 
+var maxInstancesPerProcess = flag.Int(
+	"maxInstancesPerProcess",
+	0,
+	"the maximum number of instances of a single process guid the rep will accept (0 means unlimited)",
+)
+
+var memoryOvercommitFactor = flag.Float64(
+	"memoryOvercommitFactor",
+	1.0,
+	"factor by which the memory reported by the executor is scaled when advertising the cell's capacity (must be at least 1.0)",
+)
+
+var diskOvercommitFactor = flag.Float64(
+	"diskOvercommitFactor",
+	1.0,
+	"factor by which the disk reported by the executor is scaled when advertising the cell's capacity (must be at least 1.0)",
+)
+
+var quarantineFailureThreshold = flag.Int(
+	"quarantineFailureThreshold",
+	0,
+	"number of container allocation or run failures within quarantineFailureWindow after which the cell stops accepting work (0 disables quarantine)",
+)
+
+var quarantineFailureWindow = flag.Duration(
+	"quarantineFailureWindow",
+	5*time.Minute,
+	"the window over which container allocation and run failures are counted towards quarantine",
+)
+
+var quarantineCoolDown = flag.Duration(
+	"quarantineCoolDown",
+	5*time.Minute,
+	"how long the cell stays quarantined before admitting probe work",
+)
+
+var quarantineProbes = flag.Int(
+	"quarantineProbes",
+	1,
+	"number of work items admitted after the quarantine cool-down to test whether the cell has recovered",
+)
+
 var pollingInterval = flag.Duration(
 	"pollingInterval",
 	30*time.Second,
 	"the interval on which to scan the executor",
 )
 
+var preloadedRootFSDir = flag.String(
+	"preloadedRootFSDir",
+	"",
+	"directory whose subdirectories hold preloaded rootfses described by a metadata.json file, in addition to those given with -preloadedRootFS",
+)
+
+var preloadedRootFSScanInterval = flag.Duration(
+	"preloadedRootFSScanInterval",
+	time.Minute,
+	"the interval on which to rescan -preloadedRootFSDir",
+)
+
+var imageCacheSize = flag.Int(
+	"imageCacheSize",
+	100,
+	"number of recently used docker images the cell advertises as cached (0 to disable)",
+)
+
+var prepullTimeout = flag.Duration(
+	"prepullTimeout",
+	15*time.Minute,
+	"time allowed to download each docker image requested with the prepull route",
+)
+
+var taskCompletionHookTimeout = flag.Duration(
+	"taskCompletionHookTimeout",
+	30*time.Second,
+	"time allowed for each task completion hook to run",
+)
+
+var taskCompletionHookConcurrency = flag.Int(
+	"taskCompletionHookConcurrency",
+	4,
+	"maximum number of task completion hooks running at once",
+)
+
+var taskCompletionHookQueueSize = flag.Int(
+	"taskCompletionHookQueueSize",
+	1000,
+	"number of task completion hook runs that may wait for a worker before new ones are dropped",
+)
+
+var crashArchiveDir = flag.String(
+	"crashArchiveDir",
+	"",
+	"directory keeping files captured from crashed LRP containers (empty to disable capture)",
+)
+
+var crashArchiveMaxFileSizeMB = flag.Int(
+	"crashArchiveMaxFileSizeMB",
+	50,
+	"size in MB at which each captured path is truncated",
+)
+
+var crashArchiveMaxSizeMB = flag.Int(
+	"crashArchiveMaxSizeMB",
+	1024,
+	"size in MB of the crash archive above which the oldest crashes are removed",
+)
+
+var crashArchiveMaxAge = flag.Duration(
+	"crashArchiveMaxAge",
+	7*24*time.Hour,
+	"age after which captured crashes are removed",
+)
+
+var crashLoopThreshold = flag.Int(
+	"crashLoopThreshold",
+	0,
+	"number of crashes of a process within crashLoopWindow after which the cell reports it as crash looping (0 disables detection)",
+)
+
+var crashLoopWindow = flag.Duration(
+	"crashLoopWindow",
+	5*time.Minute,
+	"the window over which the crashes of a process are counted",
+)
+
+var crashLoopCoolDown = flag.Duration(
+	"crashLoopCoolDown",
+	10*time.Minute,
+	"how long a process stays crash looping after its last crash",
+)
+
+var rejectCrashLoopingInstances = flag.Bool(
+	"rejectCrashLoopingInstances",
+	false,
+	"refuse new instances of crash looping processes so that they are placed on other cells",
+)
+
 var dropsondePort = flag.Int(
 	"dropsondePort",
 	3457,
@@ -112,6 +285,36 @@ var evacuationPollingInterval = flag.Duration(
 	"the interval on which to scan the executor during evacuation",
 )
 
+var evacuationMaxConcurrentPerProcess = flag.Int(
+	"evacuationMaxConcurrentPerProcess",
+	0,
+	"the maximum number of instances of a single process guid handed off at once during evacuation (0 means unlimited)",
+)
+
+var evacuationMaxTaskWait = flag.Duration(
+	"evacuationMaxTaskWait",
+	evacuation_order.DefaultMaxTaskWait,
+	"the maximum time LRPs are held back during evacuation for the tasks of evacuationTaskDomains",
+)
+
+var strictEvacuationHandOff = flag.Bool(
+	"strictEvacuationHandOff",
+	false,
+	"keep an evacuating LRP serving until its replacement is observed running on another cell or the evacuation TTL passes",
+)
+
+var evacuationReportDir = flag.String(
+	"evacuationReportDir",
+	"",
+	"directory in which to write a JSON report at the end of each evacuation (reports are only logged when empty)",
+)
+
+var defaultTaskEvacuationPolicy = flag.String(
+	"defaultTaskEvacuationPolicy",
+	task_evacuation.PolicyWait,
+	"what to do with running tasks during evacuation when their domain has no policy: 'wait', 'fail-fast' or 'drain-after:<grace period>'",
+)
+
 var bbsAddress = flag.String(
 	"bbsAddress",
 	"",
@@ -157,13 +360,18 @@ func (s *stackPathMap) String() string {
 func (s *stackPathMap) Set(value string) error {
 	parts := strings.SplitN(value, ":", 2)
 	if len(parts) != 2 {
-		return errors.New("Invalid preloaded RootFS value: not of the form 'stack-name:path'")
+		return errors.New("Invalid preloaded RootFS value: not of the form 'stack-name:path' or 'stack-name@version:path'")
 	}
 
-	if parts[0] == "" {
+	name, version := rep.SplitStackKey(parts[0])
+	if name == "" {
 		return errors.New("Invalid preloaded RootFS value: blank stack")
 	}
 
+	if strings.Contains(parts[0], rep.StackVersionSeparator) && version == "" {
+		return errors.New("Invalid preloaded RootFS value: blank version")
+	}
+
 	if parts[1] == "" {
 		return errors.New("Invalid preloaded RootFS value: blank path")
 	}
@@ -172,6 +380,53 @@ func (s *stackPathMap) Set(value string) error {
 	return nil
 }
 
+type taskEvacuationPolicies map[string]task_evacuation.Policy
+
+func (t *taskEvacuationPolicies) String() string {
+	return fmt.Sprintf("%v", *t)
+}
+
+func (t *taskEvacuationPolicies) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return errors.New("Invalid task evacuation policy: not of the form 'domain=policy'")
+	}
+
+	policy, err := task_evacuation.ParsePolicy(parts[1])
+	if err != nil {
+		return err
+	}
+
+	(*t)[parts[0]] = policy
+	return nil
+}
+
+type taskCompletionHooks []string
+
+func (t *taskCompletionHooks) String() string {
+	return fmt.Sprintf("%v", *t)
+}
+
+func (t *taskCompletionHooks) Set(value string) error {
+	_, _, err := task_hooks.ParseHook(value, 0, nil)
+	if err != nil {
+		return err
+	}
+
+	*t = append(*t, value)
+	return nil
+}
+
+// Hooks returns the hooks of each domain.
+func (t taskCompletionHooks) Hooks(timeout time.Duration, clock clock.Clock) map[string][]task_hooks.Hook {
+	hooks := map[string][]task_hooks.Hook{}
+	for _, value := range t {
+		domain, hook, _ := task_hooks.ParseHook(value, timeout, clock)
+		hooks[domain] = append(hooks[domain], hook)
+	}
+	return hooks
+}
+
 type providers []string
 
 func (p *providers) String() string {
@@ -183,10 +438,30 @@ func (p *providers) Set(value string) error {
 		return errors.New("Cannot set blank value for RootFS provider")
 	}
 
+	_, _, err := rep.ParseRootFSProviderSpec(value)
+	if err != nil {
+		return err
+	}
+
 	*p = append(*p, value)
 	return nil
 }
 
+// Schemes returns the distinct schemes of the providers, in the order in which
+// they were given.
+func (p providers) Schemes() []string {
+	seen := map[string]bool{}
+	schemes := []string{}
+	for _, spec := range p {
+		scheme := strings.SplitN(spec, "=", 2)[0]
+		if !seen[scheme] {
+			seen[scheme] = true
+			schemes = append(schemes, scheme)
+		}
+	}
+	return schemes
+}
+
 type argList []string
 
 func (a *argList) String() string {
@@ -202,6 +477,11 @@ const (
 	dropsondeOrigin = "rep"
 
 	bbsPingTimeout = 5 * time.Minute
+
+	crashArchiveRotateInterval = 10 * time.Minute
+
+	consulPresenceBackend = "consul"
+	locketPresenceBackend = "locket"
 )
 
 func main() {
@@ -212,15 +492,27 @@ func main() {
 	supportedProviders := providers{}
 	gardenHealthcheckEnv := argList{}
 	gardenHealthcheckArgs := argList{}
-	flag.Var(&stackMap, "preloadedRootFS", "List of preloaded RootFSes")
-	flag.Var(&supportedProviders, "rootFSProvider", "List of RootFS providers")
+	evacuationTaskDomains := argList{}
+	evacuationLRPDomains := argList{}
+	taskEvacuationPolicyMap := taskEvacuationPolicies{}
+	completionHooks := taskCompletionHooks{}
+	crashCapturePaths := argList{}
+	flag.Var(&stackMap, "preloadedRootFS", "Preloaded RootFS of the form 'stack-name:path', or 'stack-name@version:path' for a specific version of the stack (can be repeated)")
+	flag.Var(&supportedProviders, "rootFSProvider", "RootFS provider of the form 'scheme', accepting any rootfs with the scheme, or 'scheme=glob:pattern', 'scheme=regex:pattern' or 'scheme=registry:host' matching the host and path (can be repeated)")
 	flag.Var(&gardenHealthcheckArgs, "gardenHealthcheckProcessArgs", "List of command line args to pass to the garden health check process")
 	flag.Var(&gardenHealthcheckEnv, "gardenHealthcheckProcessEnv", "Environment variables to use when running the garden health check")
+	flag.Var(&evacuationTaskDomains, "evacuationTaskDomains", "Comma-separated domains whose tasks must finish before any LRP is evacuated")
+	flag.Var(&evacuationLRPDomains, "evacuationLRPDomains", "Comma-separated domains in the order in which their LRPs are evacuated")
+	flag.Var(&taskEvacuationPolicyMap, "taskEvacuationPolicy", "Evacuation policy for the running tasks of a domain, of the form 'domain=policy'")
+	flag.Var(&completionHooks, "taskCompletionHook", "Hook run when a task of a domain completes, of the form 'domain=exec:/path/to/executable' or 'domain=http://localhost:port/path' (can be repeated)")
+	flag.Var(&crashCapturePaths, "crashCapturePaths", "Comma-separated paths fetched from crashed LRP containers into the crash archive, such as app logs and core dumps")
 	flag.Parse()
 
-	preloadedRootFSes := []string{}
-	for k := range stackMap {
-		preloadedRootFSes = append(preloadedRootFSes, k)
+	commandLine := commandLineFlags(flag.CommandLine)
+
+	var configErr error
+	if *configPath != "" {
+		configErr = loadConfig(*configPath, flag.CommandLine, commandLine)
 	}
 
 	cfhttp.Initialize(*communicationTimeout)
@@ -228,17 +520,58 @@ func main() {
 	clock := clock.NewClock()
 	logger, reconfigurableSink := cflager.New(*sessionName)
 
+	if configErr != nil {
+		logger.Error("invalid-config-file", configErr, lager.Data{"path": *configPath})
+		os.Exit(1)
+	}
+
+	if _, err := rep.ParseRootFSProviderSpecs(supportedProviders); err != nil {
+		logger.Error("invalid-rootfs-providers", err, lager.Data{"rootfs-providers": supportedProviders})
+		os.Exit(1)
+	}
+
+	config, err := newConfigSnapshot(flag.CommandLine)
+	if err != nil {
+		logger.Error("invalid-config", err)
+		os.Exit(1)
+	}
+
+	preloadedStacks := rep.StackPathMap(stackMap)
+	if *preloadedRootFSDir != "" {
+		scanned, err := preloaded_rootfs.Scan(logger, *preloadedRootFSDir)
+		if err != nil {
+			logger.Error("failed-to-scan-preloaded-rootfs-dir", err, lager.Data{"dir": *preloadedRootFSDir})
+			os.Exit(1)
+		}
+		preloadedStacks = preloaded_rootfs.Merge(rep.StackPathMap(stackMap), scanned)
+	}
+
+	if *simulationMode {
+		if *cellID == "" {
+			logger.Error("invalid-cell-id", errors.New("-cellID must be specified"))
+			os.Exit(1)
+		}
+
+		err := runSimulation(logger, reconfigurableSink, config, preloadedStacks, supportedProviders)
+		if err != nil {
+			logger.Error("exited-with-failure", err)
+			os.Exit(1)
+		}
+
+		logger.Info("exited")
+		return
+	}
+
 	var (
 		executorConfiguration   executorinit.Configuration
 		gardenHealthcheckRootFS string
 		certBytes               []byte
-		err                     error
 	)
 
-	if len(preloadedRootFSes) == 0 {
+	if stacks := preloadedStacks.Stacks(); len(stacks) == 0 {
 		gardenHealthcheckRootFS = ""
 	} else {
-		gardenHealthcheckRootFS = stackMap[preloadedRootFSes[0]]
+		gardenHealthcheckRootFS, _ = preloadedStacks.Path(stacks[0], "")
 	}
 
 	if *pathToCACertsForDownloads != "" {
@@ -263,6 +596,12 @@ func main() {
 		os.Exit(1)
 	}
 
+	overcommit := rep.NewOvercommitFactors(*memoryOvercommitFactor, *diskOvercommitFactor)
+	if err := overcommit.Validate(); err != nil {
+		logger.Error("invalid-overcommit-factors", err, lager.Data{"memory": *memoryOvercommitFactor, "disk": *diskOvercommitFactor})
+		os.Exit(1)
+	}
+
 	executorClient, executorMembers, err := executorinit.Initialize(logger, executorConfiguration, clock)
 	if err != nil {
 		logger.Error("failed-to-initialize-executor", err)
@@ -275,42 +614,137 @@ func main() {
 		os.Exit(1)
 	}
 
-	serviceClient := initializeServiceClient(logger)
+	if err := validateCellPresenceBackend(); err != nil {
+		logger.Error("invalid-cell-presence-backend", err)
+		os.Exit(1)
+	}
+
+	presenceBackend := initializePresenceBackend(logger)
+
+	quarantineConfig := quarantine.Config{
+		FailureThreshold: *quarantineFailureThreshold,
+		FailureWindow:    *quarantineFailureWindow,
+		CoolDown:         *quarantineCoolDown,
+		Probes:           *quarantineProbes,
+	}
+	if err := quarantineConfig.Validate(); err != nil {
+		logger.Error("invalid-quarantine-config", err, lager.Data{"threshold": *quarantineFailureThreshold, "probes": *quarantineProbes})
+		os.Exit(1)
+	}
+	quarantineTracker := quarantine.NewTracker(quarantineConfig, clock)
+
+	crashLoopConfig := crash_loop.Config{
+		CrashThreshold:  *crashLoopThreshold,
+		Window:          *crashLoopWindow,
+		CoolDown:        *crashLoopCoolDown,
+		RejectInstances: *rejectCrashLoopingInstances,
+	}
+	if err := crashLoopConfig.Validate(); err != nil {
+		logger.Error("invalid-crash-loop-config", err, lager.Data{"threshold": *crashLoopThreshold, "window": crashLoopWindow.String()})
+		os.Exit(1)
+	}
+	crashLoopTracker := crash_loop.NewTracker(crashLoopConfig, clock)
 
 	evacuatable, evacuationReporter, evacuationNotifier := evacuation_context.New()
 
 	// only one outstanding operation per container is necessary
 	queue := operationq.NewSlidingQueue(1)
 
+	evacuationOrderer := evacuation_order.New(evacuation_order.Config{
+		TaskDomains:             evacuationTaskDomains,
+		MaxTaskWait:             *evacuationMaxTaskWait,
+		LRPDomains:              evacuationLRPDomains,
+		MaxConcurrentPerProcess: *evacuationMaxConcurrentPerProcess,
+	}, clock)
+
+	defaultPolicy, err := task_evacuation.ParsePolicy(*defaultTaskEvacuationPolicy)
+	if err != nil {
+		logger.Fatal("invalid-default-task-evacuation-policy", err)
+	}
+	taskPolicies := task_evacuation.NewPolicies(defaultPolicy, taskEvacuationPolicyMap)
+	taskHooks := task_hooks.NewDispatcher(logger, completionHooks.Hooks(*taskCompletionHookTimeout, clock), *taskCompletionHookConcurrency, *taskCompletionHookQueueSize)
+
 	evacuator := evacuation.NewEvacuator(
 		logger,
 		clock,
 		executorClient,
 		evacuationNotifier,
+		evacuationOrderer,
 		*cellID,
 		*evacuationTimeout,
 		*evacuationPollingInterval,
+		*evacuationReportDir,
 	)
 
 	bbsClient := initializeBBSClient(logger)
-	httpServer, address := initializeServer(bbsClient, executorClient, evacuatable, evacuationReporter, logger, rep.StackPathMap(stackMap), supportedProviders)
-	opGenerator := generator.New(*cellID, bbsClient, executorClient, evacuationReporter, uint64(evacuationTimeout.Seconds()))
+	imageCache := image_cache.NewCache(*imageCacheSize, clock)
+	prepuller := image_cache.NewPrepuller(executorClient, imageCache, generateGuid, clock, time.Second, *prepullTimeout)
+	crashArchive := crash_archive.New(logger, crash_archive.Config{
+		Dir:            *crashArchiveDir,
+		Paths:          crashCapturePaths,
+		MaxFileSize:    int64(*crashArchiveMaxFileSizeMB) * 1024 * 1024,
+		MaxSize:        int64(*crashArchiveMaxSizeMB) * 1024 * 1024,
+		MaxAge:         *crashArchiveMaxAge,
+		RotateInterval: crashArchiveRotateInterval,
+	}, executorClient, clock)
+	httpServer, address, auctionCellRep := initializeServer(bbsClient, executorClient, evacuatable, evacuationReporter, evacuator, quarantineTracker, imageCache, crashLoopTracker, prepuller, crashArchive, logger, preloadedStacks, supportedProviders, overcommit)
+	evacuationConfig := generator.EvacuationConfig{
+		Reporter:            evacuationReporter,
+		StatusReporter:      evacuator,
+		FailureRecorder:     evacuator,
+		DispositionRecorder: evacuator,
+		Orderer:             evacuationOrderer,
+		TaskPolicies:        taskPolicies,
+		TTLInSeconds:        uint64(evacuationTimeout.Seconds()),
+		StrictHandOff:       *strictEvacuationHandOff,
+	}
+	crashConfig := generator.CrashConfig{
+		Capturer:    crashArchive,
+		LoopTracker: crashLoopTracker,
+	}
+	opGenerator := generator.New(*cellID, bbsClient, executorClient, evacuationConfig, crashConfig, quarantineTracker, taskHooks, prepuller, clock)
 	cleanup := evacuation.NewEvacuationCleanup(logger, *cellID, bbsClient)
 
+	maintainer := initializeCellPresence(address, presenceBackend, executorClient, logger, supportedProviders.Schemes(), preloadedStacks.PreloadedRootFSes(), overcommit)
+	bulker := harmonizer.NewBulker(logger, *pollingInterval, *evacuationPollingInterval, evacuationNotifier, clock, opGenerator, queue)
+	reloader := newReloader(*configPath, flag.CommandLine, commandLine, config, reconfigurableSink, bulker, evacuator, auctionCellRep, maintainer, logger)
+
 	members := grouper.Members{
-		{"presence", initializeCellPresence(address, serviceClient, executorClient, logger, supportedProviders, preloadedRootFSes)},
+		{"presence", maintainer},
 		{"http_server", httpServer},
 		{"evacuation-cleanup", cleanup},
-		{"bulker", harmonizer.NewBulker(logger, *pollingInterval, *evacuationPollingInterval, evacuationNotifier, clock, opGenerator, queue)},
+		{"bulker", bulker},
 		{"event-consumer", harmonizer.NewEventConsumer(logger, opGenerator, queue)},
 		{"evacuator", evacuator},
+		{"task-completion-hooks", taskHooks},
+		{"config-reloader", reloader},
+	}
+
+	if *crashArchiveDir != "" {
+		members = append(members, grouper.Member{"crash-archive", crashArchive})
+	}
+
+	if *preloadedRootFSDir != "" {
+		scanner := preloaded_rootfs.NewScanner(
+			logger,
+			*preloadedRootFSDir,
+			*preloadedRootFSScanInterval,
+			clock,
+			rep.StackPathMap(stackMap),
+			preloadedStacks,
+			auctionCellRep,
+			preloaded_rootfs.StackListenerFunc(func(stacks rep.StackPathMap) {
+				maintainer.SetPreloadedRootFSes(stacks.PreloadedRootFSes())
+			}),
+		)
+		members = append(members, grouper.Member{"preloaded-rootfs-scanner", scanner})
 	}
 
 	members = append(executorMembers, members...)
 
 	if dbgAddr := debugserver.DebugAddress(flag.CommandLine); dbgAddr != "" {
 		members = append(grouper.Members{
-			{"debug-server", debugserver.Runner(dbgAddr, reconfigurableSink)},
+			{"debug-server", debugServerRunner(dbgAddr, reconfigurableSink, config, logger)},
 		}, members...)
 	}
 
@@ -337,7 +771,14 @@ func initializeDropsonde(logger lager.Logger) {
 	}
 }
 
-func initializeCellPresence(address string, serviceClient bbs.ServiceClient, executorClient executor.Client, logger lager.Logger, rootFSProviders, preloadedRootFSes []string) ifrit.Runner {
+func initializeCellPresence(
+	address string,
+	presenceBackend maintain.PresenceBackend,
+	executorClient executor.Client,
+	logger lager.Logger,
+	rootFSProviders, preloadedRootFSes []string,
+	overcommit rep.OvercommitFactors,
+) *maintain.Maintainer {
 	config := maintain.Config{
 		CellID:            *cellID,
 		RepAddress:        address,
@@ -345,17 +786,50 @@ func initializeCellPresence(address string, serviceClient bbs.ServiceClient, exe
 		RetryInterval:     *lockRetryInterval,
 		RootFSProviders:   rootFSProviders,
 		PreloadedRootFSes: preloadedRootFSes,
+		Overcommit:        overcommit,
+	}
+	return maintain.New(logger, config, executorClient, presenceBackend, *lockTTL, clock.NewClock())
+}
+
+func initializePresenceBackend(logger lager.Logger) maintain.PresenceBackend {
+	switch *cellPresenceBackend {
+	case locketPresenceBackend:
+		return initializeLocketPresenceBackend(logger)
+	default:
+		return initializeConsulPresenceBackend(logger)
 	}
-	return maintain.New(logger, config, executorClient, serviceClient, *lockTTL, clock.NewClock())
 }
 
-func initializeServiceClient(logger lager.Logger) bbs.ServiceClient {
+func initializeConsulPresenceBackend(logger lager.Logger) maintain.PresenceBackend {
 	consulClient, err := consuladapter.NewClientFromUrl(*consulCluster)
 	if err != nil {
 		logger.Fatal("new-client-failed", err)
 	}
 
-	return bbs.NewServiceClient(consulClient, clock.NewClock())
+	return maintain.NewConsulPresenceBackend(consulClient, clock.NewClock())
+}
+
+func initializeLocketPresenceBackend(logger lager.Logger) maintain.PresenceBackend {
+	dialOption := grpc.WithInsecure()
+	if *locketCACertFile != "" {
+		tlsConfig, err := cfhttp.NewTLSConfig(*locketClientCertFile, *locketClientKeyFile, *locketCACertFile)
+		if err != nil {
+			logger.Fatal("failed-to-configure-locket-tls", err)
+		}
+		dialOption = grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))
+	}
+
+	conn, err := grpc.Dial(*locketAddress, dialOption)
+	if err != nil {
+		logger.Fatal("failed-to-connect-to-locket", err)
+	}
+
+	owner, err := generateGuid()
+	if err != nil {
+		logger.Fatal("failed-to-generate-locket-owner", err)
+	}
+
+	return maintain.NewLocketPresenceBackend(locketmodels.NewLocketClient(conn), owner, clock.NewClock())
 }
 
 func initializeServer(
@@ -363,13 +837,33 @@ func initializeServer(
 	executorClient executor.Client,
 	evacuatable evacuation_context.Evacuatable,
 	evacuationReporter evacuation_context.EvacuationReporter,
+	evacuationStatusReporter evacuation_context.EvacuationStatusReporter,
+	quarantineTracker quarantine.Tracker,
+	imageCache image_cache.Cache,
+	crashLoopTracker crash_loop.Tracker,
+	prepuller image_cache.Prepuller,
+	crashArchive crash_archive.Archive,
 	logger lager.Logger,
 	stackMap rep.StackPathMap,
 	supportedProviders []string,
-) (ifrit.Runner, string) {
-
-	auctionCellRep := auction_cell_rep.New(*cellID, stackMap, supportedProviders, *zone, generateGuid, executorClient, evacuationReporter, logger)
-	handlers := handlers.New(auctionCellRep, executorClient, evacuatable, logger)
+	overcommit rep.OvercommitFactors,
+) (ifrit.Runner, string, *auction_cell_rep.AuctionCellRep) {
+
+	config := auction_cell_rep.Config{
+		CellID:                 *cellID,
+		Zone:                   *zone,
+		PreloadedStackPathMap:  stackMap,
+		RootFSProviderSpecs:    supportedProviders,
+		MaxInstancesPerProcess: *maxInstancesPerProcess,
+		Overcommit:             overcommit,
+	}
+	admission := auction_cell_rep.AdmissionConfig{
+		EvacuationReporter: evacuationReporter,
+		Quarantine:         quarantineTracker,
+		CrashLoop:          crashLoopTracker,
+	}
+	auctionCellRep := auction_cell_rep.New(config, admission, generateGuid, executorClient, imageCache, clock.NewClock(), logger)
+	handlers := handlers.New(auctionCellRep, executorClient, evacuatable, evacuationStatusReporter, prepuller, crashArchive, logger)
 
 	router, err := rata.NewRouter(rep.Routes, handlers)
 	if err != nil {
@@ -384,7 +878,21 @@ func initializeServer(
 	port := strings.Split(*listenAddr, ":")[1]
 	address := fmt.Sprintf("http://%s:%s", ip, port)
 
-	return http_server.New(*listenAddr, router), address
+	return http_server.New(*listenAddr, router), address, auctionCellRep
+}
+
+func validateCellPresenceBackend() error {
+	switch *cellPresenceBackend {
+	case consulPresenceBackend:
+		return nil
+	case locketPresenceBackend:
+		if *locketAddress == "" {
+			return errors.New("locketAddress is required when the cell presence backend is locket")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown cell presence backend %q: must be %s or %s", *cellPresenceBackend, consulPresenceBackend, locketPresenceBackend)
+	}
 }
 
 func validateBBSAddress() error {
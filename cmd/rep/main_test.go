@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"os"
 	"regexp"
@@ -14,13 +15,18 @@ import (
 	"code.cloudfoundry.org/bbs/models"
 	"code.cloudfoundry.org/bbs/models/test/model_helpers"
 	"code.cloudfoundry.org/cfhttp"
+	"code.cloudfoundry.org/clock"
 	"code.cloudfoundry.org/executor/gardenhealth"
 	"code.cloudfoundry.org/lager"
 	"code.cloudfoundry.org/lager/lagertest"
+	locketmodels "code.cloudfoundry.org/locket/models"
 	"code.cloudfoundry.org/rep"
 	"code.cloudfoundry.org/rep/cmd/rep/testrunner"
+	"code.cloudfoundry.org/rep/maintain/local_locket"
 	"github.com/cloudfoundry-incubator/garden"
 	"github.com/cloudfoundry-incubator/garden/transport"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -280,6 +286,52 @@ Se6AbGXgSlq+ZCEVo0qIwSgeBqmsJxUu7NCSOwVJLYNEBO2DtIxoYVk+MA==
 			})
 		})
 
+		Context("when a config file is provided", func() {
+			var configFile *os.File
+
+			BeforeEach(func() {
+				var err error
+				configFile, err = ioutil.TempFile("", "rep-config")
+				Expect(err).NotTo(HaveOccurred())
+
+				config.ConfigPath = configFile.Name()
+			})
+
+			AfterEach(func() {
+				os.Remove(configFile.Name())
+			})
+
+			Context("when the file is valid", func() {
+				BeforeEach(func() {
+					_, err := configFile.WriteString("zone: z1\nrootFSProvider: [docker, preloaded]\n")
+					Expect(err).NotTo(HaveOccurred())
+
+					runner = testrunner.New(representativePath, config)
+				})
+
+				It("should start", func() {
+					Consistently(runner.Session).ShouldNot(Exit())
+				})
+			})
+
+			Context("when the file has invalid entries", func() {
+				BeforeEach(func() {
+					_, err := configFile.WriteString("notAFlag: true\npollingInterval: soon\n")
+					Expect(err).NotTo(HaveOccurred())
+
+					runner = testrunner.New(representativePath, config)
+					runner.StartCheck = ""
+				})
+
+				It("reports every error and does not start", func() {
+					Eventually(runner.Session.Buffer()).Should(gbytes.Say("invalid-config-file"))
+					Expect(runner.Session.Buffer()).To(gbytes.Say("notAFlag: unknown option"))
+					Expect(runner.Session.Buffer()).To(gbytes.Say("pollingInterval: "))
+					Eventually(runner.Session.ExitCode).Should(Equal(1))
+				})
+			})
+		})
+
 		Describe("when an interrupt signal is sent to the representative", func() {
 			JustBeforeEach(func() {
 				if runtime.GOOS == "windows" {
@@ -352,6 +404,49 @@ Se6AbGXgSlq+ZCEVo0qIwSgeBqmsJxUu7NCSOwVJLYNEBO2DtIxoYVk+MA==
 				cellPresence := cellSet[cellID]
 				Expect(cellPresence.CellId).To(Equal(cellID))
 			})
+
+			Context("when the cell presence backend is locket", func() {
+				var (
+					locketServer *local_locket.Server
+					locketClient locketmodels.LocketClient
+					conn         *grpc.ClientConn
+				)
+
+				BeforeEach(func() {
+					listener, err := net.Listen("tcp", "127.0.0.1:0")
+					Expect(err).NotTo(HaveOccurred())
+
+					locketServer = local_locket.NewServer(clock.NewClock())
+					go locketServer.Serve(listener)
+
+					conn, err = grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+					Expect(err).NotTo(HaveOccurred())
+					locketClient = locketmodels.NewLocketClient(conn)
+
+					config.LocketAddress = listener.Addr().String()
+					runner = testrunner.New(representativePath, config)
+				})
+
+				AfterEach(func() {
+					conn.Close()
+					locketServer.Stop()
+				})
+
+				It("should maintain presence in locket", func() {
+					Eventually(func() error {
+						_, err := locketClient.Fetch(context.Background(), &locketmodels.FetchRequest{Key: cellID})
+						return err
+					}).Should(Succeed())
+
+					response, err := locketClient.Fetch(context.Background(), &locketmodels.FetchRequest{Key: cellID})
+					Expect(err).NotTo(HaveOccurred())
+
+					var cellPresence models.CellPresence
+					Expect(json.Unmarshal([]byte(response.Resource.Value), &cellPresence)).To(Succeed())
+					Expect(cellPresence.CellId).To(Equal(cellID))
+					Expect(response.Resource.Type).To(Equal(locketmodels.PresenceType))
+				})
+			})
 		})
 
 		Context("acting as an auction representative", func() {
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sort"
+	"strconv"
+	"syscall"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/auction_cell_rep"
+	"code.cloudfoundry.org/rep/evacuation"
+	"code.cloudfoundry.org/rep/harmonizer"
+	"code.cloudfoundry.org/rep/maintain"
+)
+
+// reloadableFlags may be changed in the config file and picked up on SIGHUP.
+// Changing any other option requires restarting the rep.
+var reloadableFlags = map[string]bool{
+	"logLevel":                  true,
+	"pollingInterval":           true,
+	"evacuationPollingInterval": true,
+	"maxInstancesPerProcess":    true,
+	"memoryOvercommitFactor":    true,
+	"diskOvercommitFactor":      true,
+	"rootFSProvider":            true,
+}
+
+var logLevels = map[string]lager.LogLevel{
+	"debug": lager.DEBUG,
+	"info":  lager.INFO,
+	"error": lager.ERROR,
+	"fatal": lager.FATAL,
+}
+
+var errNoConfigFile = errors.New("the rep was not started with -config")
+
+type configChange struct {
+	Previous string `json:"previous"`
+	Current  string `json:"current"`
+
+	value flag.Value
+}
+
+// reloadableConfig holds the values of the reloadable options.
+type reloadableConfig struct {
+	logLevel                  lager.LogLevel
+	pollingInterval           time.Duration
+	evacuationPollingInterval time.Duration
+	maxInstancesPerProcess    int
+	overcommit                rep.OvercommitFactors
+	rootFSProviders           providers
+}
+
+// reloader re-reads the config file on SIGHUP and applies changes to the
+// reloadable options to the running rep, without restarting any member or
+// releasing the cell presence lock. It never sets the flags, which are only
+// read for their defaults and types; the values live in the config snapshot.
+type reloader struct {
+	configPath  string
+	flags       *flag.FlagSet
+	commandLine map[string]bool
+	config      *configSnapshot
+
+	sink       *lager.ReconfigurableSink
+	bulker     *harmonizer.Bulker
+	evacuator  *evacuation.Evacuator
+	cellRep    *auction_cell_rep.AuctionCellRep
+	maintainer *maintain.Maintainer
+	logger     lager.Logger
+}
+
+func newReloader(
+	configPath string,
+	flags *flag.FlagSet,
+	commandLine map[string]bool,
+	config *configSnapshot,
+	sink *lager.ReconfigurableSink,
+	bulker *harmonizer.Bulker,
+	evacuator *evacuation.Evacuator,
+	cellRep *auction_cell_rep.AuctionCellRep,
+	maintainer *maintain.Maintainer,
+	logger lager.Logger,
+) *reloader {
+	return &reloader{
+		configPath:  configPath,
+		flags:       flags,
+		commandLine: commandLine,
+		config:      config,
+		sink:        sink,
+		bulker:      bulker,
+		evacuator:   evacuator,
+		cellRep:     cellRep,
+		maintainer:  maintainer,
+		logger:      logger.Session("config-reloader"),
+	}
+}
+
+func (r *reloader) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	defer signal.Stop(hangups)
+
+	close(ready)
+
+	for {
+		select {
+		case <-hangups:
+			r.reload()
+		case <-signals:
+			return nil
+		}
+	}
+}
+
+func (r *reloader) reload() {
+	logger := r.logger.Session("reload")
+	logger.Info("starting")
+	defer logger.Info("finished")
+
+	if r.configPath == "" {
+		logger.Error("failed-to-reload-configuration", errNoConfigFile)
+		return
+	}
+
+	changes, reloadable, err := r.pendingChanges()
+	if err != nil {
+		logger.Error("rejected-configuration", err, lager.Data{"path": r.configPath})
+		return
+	}
+
+	if len(changes) == 0 {
+		logger.Info("configuration-unchanged")
+		return
+	}
+
+	r.config.reload(changes, reloadable)
+	r.apply()
+	logger.Info("reloaded-configuration", lager.Data{"changes": changes})
+}
+
+// pendingChanges returns the options whose values would change if the config
+// file were loaded again, along with the resulting reloadable values. It fails
+// when the file is invalid or changes an option that cannot be reloaded.
+func (r *reloader) pendingChanges() (map[string]configChange, reloadableConfig, error) {
+	values, err := readConfigFile(r.configPath)
+	if err != nil {
+		return nil, reloadableConfig{}, err
+	}
+
+	current := r.config.Values()
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs configErrors
+	for _, name := range names {
+		if r.flags.Lookup(name) == nil || name == "config" {
+			errs = append(errs, fmt.Errorf("%s: unknown option", name))
+		}
+	}
+
+	changes := map[string]configChange{}
+	unsafe := []string{}
+
+	r.flags.VisitAll(func(f *flag.Flag) {
+		if r.commandLine[f.Name] || f.Name == "config" {
+			return
+		}
+
+		next := freshValue(f)
+		value, inFile := values[f.Name]
+		if inFile {
+			settings, err := configSettings(f, value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %s", f.Name, err))
+				return
+			}
+			for _, setting := range settings {
+				err := next.Set(setting)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %s", f.Name, err))
+					return
+				}
+			}
+		} else if !isListFlag(f) {
+			next.Set(f.DefValue)
+		}
+
+		if next.String() == current[f.Name] {
+			return
+		}
+
+		if !reloadableFlags[f.Name] {
+			unsafe = append(unsafe, f.Name)
+			return
+		}
+
+		changes[f.Name] = configChange{Previous: current[f.Name], Current: next.String(), value: next}
+	})
+
+	if len(unsafe) > 0 {
+		sort.Strings(unsafe)
+		for _, name := range unsafe {
+			errs = append(errs, fmt.Errorf("%s: cannot be changed without restarting the rep", name))
+		}
+	}
+
+	var reloadable reloadableConfig
+	if len(errs) == 0 {
+		rootFSProviders := r.config.Reloadable().rootFSProviders
+		if change, ok := changes["rootFSProvider"]; ok {
+			rootFSProviders = *change.value.(*providers)
+		}
+
+		reloadable, errs = parseReloadable(func(name string) string {
+			if change, ok := changes[name]; ok {
+				return change.Current
+			}
+			return current[name]
+		}, rootFSProviders)
+	}
+
+	if len(errs) > 0 {
+		return nil, reloadableConfig{}, errs
+	}
+	return changes, reloadable, nil
+}
+
+// parseReloadable validates and converts the values of the reloadable
+// options. valueOf returns the value of an option as a flag would print it.
+func parseReloadable(valueOf func(name string) string, rootFSProviders providers) (reloadableConfig, configErrors) {
+	config := reloadableConfig{rootFSProviders: rootFSProviders}
+
+	var errs configErrors
+
+	level, ok := logLevels[valueOf("logLevel")]
+	if !ok {
+		errs = append(errs, fmt.Errorf("logLevel: unknown level %q", valueOf("logLevel")))
+	}
+	config.logLevel = level
+
+	positiveDuration := func(name string) time.Duration {
+		interval, err := time.ParseDuration(valueOf(name))
+		if err != nil || interval <= 0 {
+			errs = append(errs, fmt.Errorf("%s: must be a positive duration", name))
+		}
+		return interval
+	}
+	config.pollingInterval = positiveDuration("pollingInterval")
+	config.evacuationPollingInterval = positiveDuration("evacuationPollingInterval")
+
+	maxInstances, err := strconv.Atoi(valueOf("maxInstancesPerProcess"))
+	if err != nil {
+		errs = append(errs, errors.New("maxInstancesPerProcess: must be an integer"))
+	}
+	config.maxInstancesPerProcess = maxInstances
+
+	memory, memoryErr := strconv.ParseFloat(valueOf("memoryOvercommitFactor"), 64)
+	disk, diskErr := strconv.ParseFloat(valueOf("diskOvercommitFactor"), 64)
+	if memoryErr != nil || diskErr != nil {
+		errs = append(errs, errors.New("overcommit factors must be numbers"))
+	} else if err := rep.NewOvercommitFactors(memory, disk).Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	config.overcommit = rep.NewOvercommitFactors(memory, disk)
+
+	if _, err := rep.ParseRootFSProviderSpecs(rootFSProviders); err != nil {
+		errs = append(errs, fmt.Errorf("rootFSProvider: %s", err))
+	}
+
+	return config, errs
+}
+
+// apply pushes the reloadable values in the config snapshot to the running
+// members of the rep.
+func (r *reloader) apply() {
+	config := r.config.Reloadable()
+
+	r.sink.SetMinLevel(config.logLevel)
+
+	r.bulker.SetPollIntervals(config.pollingInterval, config.evacuationPollingInterval)
+	r.evacuator.SetPollingInterval(config.evacuationPollingInterval)
+
+	r.cellRep.Reconfigure(config.rootFSProviders, config.maxInstancesPerProcess, config.overcommit)
+	r.maintainer.Reconfigure(config.rootFSProviders.Schemes(), config.overcommit)
+}
+
+// freshValue returns an unset value of the same type as the flag's.
+func freshValue(f *flag.Flag) flag.Value {
+	t := reflect.TypeOf(f.Value).Elem()
+	v := reflect.New(t)
+	if t.Kind() == reflect.Map {
+		v.Elem().Set(reflect.MakeMap(t))
+	}
+	return v.Interface().(flag.Value)
+}
+
+// isListFlag reports whether the flag accumulates values. Such flags default
+// to empty.
+func isListFlag(f *flag.Flag) bool {
+	switch f.Value.(type) {
+	case *providers, *stackPathMap, *taskEvacuationPolicies, *argList:
+		return true
+	}
+	return false
+}
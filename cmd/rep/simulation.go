@@ -0,0 +1,119 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/debugserver"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/auction_cell_rep"
+	"code.cloudfoundry.org/rep/evacuation/evacuation_context"
+	"code.cloudfoundry.org/rep/handlers"
+	"code.cloudfoundry.org/rep/simulation"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/grouper"
+	"github.com/tedsuo/ifrit/http_server"
+	"github.com/tedsuo/ifrit/sigmon"
+	"github.com/tedsuo/rata"
+)
+
+var simulationMode = flag.Bool(
+	"simulationMode",
+	false,
+	"serve in-memory simulated cells instead of running containers (for load testing auctions)",
+)
+
+var simulationCellCount = flag.Int(
+	"simulationCellCount",
+	1,
+	"the number of simulated cells to serve, on consecutive ports starting at the listenAddr port",
+)
+
+var simulationMemoryMB = flag.Int(
+	"simulationMemoryMB",
+	16384,
+	"the memory capacity of each simulated cell in megabytes",
+)
+
+var simulationDiskMB = flag.Int(
+	"simulationDiskMB",
+	65536,
+	"the disk capacity of each simulated cell in megabytes",
+)
+
+var simulationContainers = flag.Int(
+	"simulationContainers",
+	256,
+	"the container capacity of each simulated cell",
+)
+
+func runSimulation(
+	logger lager.Logger,
+	reconfigurableSink *lager.ReconfigurableSink,
+	config *configSnapshot,
+	stackMap rep.StackPathMap,
+	supportedProviders []string,
+) error {
+	if *simulationCellCount < 1 {
+		return fmt.Errorf("simulationCellCount must be at least 1, got %d", *simulationCellCount)
+	}
+
+	host, portString, err := net.SplitHostPort(*listenAddr)
+	if err != nil {
+		return err
+	}
+
+	port, err := strconv.Atoi(portString)
+	if err != nil {
+		return err
+	}
+
+	totalResources := rep.NewResources(int32(*simulationMemoryMB), int32(*simulationDiskMB), *simulationContainers)
+	members := grouper.Members{}
+
+	for i := 0; i < *simulationCellCount; i++ {
+		simCellID := *cellID
+		if *simulationCellCount > 1 {
+			simCellID = fmt.Sprintf("%s-%d", *cellID, i)
+		}
+
+		evacuatable, evacuationReporter, _ := evacuation_context.New()
+		cell := simulation.NewCell(
+			simCellID,
+			*zone,
+			totalResources,
+			auction_cell_rep.RootFSProviders(stackMap, supportedProviders),
+			evacuatable,
+			evacuationReporter,
+			generateGuid,
+			clock.NewClock(),
+			logger,
+		)
+
+		router, err := rata.NewRouter(rep.Routes, handlers.NewSimulation(cell, evacuatable, cell.EvacuationStatusReporter(), logger))
+		if err != nil {
+			return err
+		}
+
+		address := net.JoinHostPort(host, strconv.Itoa(port+i))
+		members = append(members, grouper.Member{Name: "simulated-cell-" + simCellID, Runner: http_server.New(address, router)})
+	}
+
+	if dbgAddr := debugserver.DebugAddress(flag.CommandLine); dbgAddr != "" {
+		members = append(grouper.Members{
+			{"debug-server", debugServerRunner(dbgAddr, reconfigurableSink, config, logger)},
+		}, members...)
+	}
+
+	group := grouper.NewOrdered(os.Interrupt, members)
+	monitor := ifrit.Invoke(sigmon.New(group))
+
+	logger.Info("started-simulation", lager.Data{"cell-id": *cellID, "cells": *simulationCellCount})
+
+	return <-monitor.Wait()
+}
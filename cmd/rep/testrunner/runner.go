@@ -28,8 +28,10 @@ type Config struct {
 	GardenAddr          string
 	LogLevel            string
 	ConsulCluster       string
+	LocketAddress       string
 	PollingInterval     time.Duration
 	EvacuationTimeout   time.Duration
+	ConfigPath          string
 }
 
 func New(binPath string, config Config) *Runner {
@@ -66,6 +68,12 @@ func (r *Runner) Start() {
 	for _, provider := range r.config.RootFSProviders {
 		args = append(args, "-rootFSProvider", provider)
 	}
+	if r.config.LocketAddress != "" {
+		args = append(args, "-cellPresenceBackend", "locket", "-locketAddress", r.config.LocketAddress)
+	}
+	if r.config.ConfigPath != "" {
+		args = append(args, "-config", r.config.ConfigPath)
+	}
 	if r.config.CACertsForDownloads != "" {
 		args = append(args, "-caCertsForDownloads", r.config.CACertsForDownloads)
 	}
@@ -0,0 +1,47 @@
+package rep
+
+import (
+	"errors"
+
+	"code.cloudfoundry.org/executor"
+)
+
+// LRPs and tasks share the executor's container namespace, so their
+// container guids are prefixed to keep them apart. Containers created before
+// the prefixes were introduced are named after the bare instance or task
+// guid and are still recognized.
+const (
+	LRPContainerGuidPrefix  = "lrp-"
+	TaskContainerGuidPrefix = "task-"
+)
+
+var ErrContainerGuidCollision = errors.New("container guid is already in use")
+
+func LRPContainerGuid(processGuid, instanceGuid string) string {
+	return LRPContainerGuidPrefix + instanceGuid
+}
+
+func TaskContainerGuid(taskGuid string) string {
+	return TaskContainerGuidPrefix + taskGuid
+}
+
+// LRPContainerGuids returns every guid the container of an LRP instance may
+// have, current scheme first.
+func LRPContainerGuids(processGuid, instanceGuid string) []string {
+	return []string{LRPContainerGuid(processGuid, instanceGuid), instanceGuid}
+}
+
+// TaskContainerGuids returns every guid the container of a task may have,
+// current scheme first.
+func TaskContainerGuids(taskGuid string) []string {
+	return []string{TaskContainerGuid(taskGuid), taskGuid}
+}
+
+// TaskGuidFromContainer returns the guid of the task run by the container.
+// Legacy task containers have no task guid tag and are named after the task.
+func TaskGuidFromContainer(container executor.Container) string {
+	if taskGuid := container.Tags[TaskGuidTag]; taskGuid != "" {
+		return taskGuid
+	}
+	return container.Guid
+}
@@ -0,0 +1,35 @@
+package rep_test
+
+import (
+	"code.cloudfoundry.org/executor"
+	"code.cloudfoundry.org/rep"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Container guids", func() {
+	It("namespaces LRP and task container guids", func() {
+		Expect(rep.LRPContainerGuid("process-guid", "some-guid")).To(Equal("lrp-some-guid"))
+		Expect(rep.TaskContainerGuid("some-guid")).To(Equal("task-some-guid"))
+	})
+
+	It("lists the current guid before the legacy one", func() {
+		Expect(rep.LRPContainerGuids("process-guid", "instance-guid")).To(Equal([]string{"lrp-instance-guid", "instance-guid"}))
+		Expect(rep.TaskContainerGuids("task-guid")).To(Equal([]string{"task-task-guid", "task-guid"}))
+	})
+
+	Describe("TaskGuidFromContainer", func() {
+		It("reads the task guid tag", func() {
+			container := executor.Container{
+				Guid: "task-task-guid",
+				Tags: executor.Tags{rep.TaskGuidTag: "task-guid"},
+			}
+			Expect(rep.TaskGuidFromContainer(container)).To(Equal("task-guid"))
+		})
+
+		It("falls back to the guid of legacy containers", func() {
+			container := executor.Container{Guid: "task-guid"}
+			Expect(rep.TaskGuidFromContainer(container)).To(Equal("task-guid"))
+		})
+	})
+})
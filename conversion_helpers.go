@@ -5,6 +5,7 @@ import (
 	"errors"
 	"net/url"
 	"strconv"
+	"time"
 
 	"code.cloudfoundry.org/bbs/models"
 	"code.cloudfoundry.org/executor"
@@ -18,9 +19,26 @@ const (
 	TaskLifecycle = "task"
 	LRPLifecycle  = "lrp"
 
+	// PrepullLifecycle marks the short-lived containers that download docker
+	// images ahead of the work that needs them.
+	PrepullLifecycle = "prepull"
+
 	ProcessGuidTag  = "process-guid"
 	InstanceGuidTag = "instance-guid"
 	ProcessIndexTag = "process-index"
+	TaskGuidTag     = "task-guid"
+
+	// EvacuationPriorityTag holds an integer priority; containers with higher
+	// priorities are handed off first when the cell evacuates. It is set from
+	// the EvacuationPriorityEnv variable of the desired LRP.
+	EvacuationPriorityTag = "evacuation-priority"
+	// EvacuationGracePeriodTag holds a duration that overrides the grace
+	// period of a "drain-after" task evacuation policy for the container. It is
+	// set from the EvacuationGracePeriodEnv variable of the task.
+	EvacuationGracePeriodTag = "evacuation-grace-period"
+
+	EvacuationPriorityEnv    = "CF_EVACUATION_PRIORITY"
+	EvacuationGracePeriodEnv = "CF_EVACUATION_GRACE_PERIOD"
 )
 
 var (
@@ -86,10 +104,6 @@ func ActualLRPNetInfoFromContainer(container executor.Container) (*models.Actual
 	return &actualLRPNetInfo, nil
 }
 
-func LRPContainerGuid(processGuid, instanceGuid string) string {
-	return instanceGuid
-}
-
 func NewRunRequestFromDesiredLRP(
 	containerGuid string,
 	desiredLRP *models.DesiredLRP,
@@ -138,10 +152,15 @@ func NewRunRequestFromDesiredLRP(
 		Network:                       convertNetwork(desiredLRP.Network),
 	}
 	tags := executor.Tags{}
+	if value, ok := environmentVariable(desiredLRP.EnvironmentVariables, EvacuationPriorityEnv); ok {
+		if priority, err := strconv.Atoi(value); err == nil {
+			tags[EvacuationPriorityTag] = strconv.Itoa(priority)
+		}
+	}
 	return executor.NewRunRequest(containerGuid, &runInfo, tags), nil
 }
 
-func NewRunRequestFromTask(task *models.Task) (executor.RunRequest, error) {
+func NewRunRequestFromTask(containerGuid string, task *models.Task) (executor.RunRequest, error) {
 	diskScope, err := diskScopeForRootFS(task.RootFs)
 	if err != nil {
 		return executor.RunRequest{}, err
@@ -155,6 +174,11 @@ func NewRunRequestFromTask(task *models.Task) (executor.RunRequest, error) {
 	tags := executor.Tags{
 		ResultFileTag: task.ResultFile,
 	}
+	if value, ok := environmentVariable(task.EnvironmentVariables, EvacuationGracePeriodEnv); ok {
+		if gracePeriod, err := time.ParseDuration(value); err == nil && gracePeriod > 0 {
+			tags[EvacuationGracePeriodTag] = gracePeriod.String()
+		}
+	}
 	runInfo := executor.RunInfo{
 		DiskScope:  diskScope,
 		CPUWeight:  uint(task.CpuWeight),
@@ -174,7 +198,16 @@ func NewRunRequestFromTask(task *models.Task) (executor.RunRequest, error) {
 		VolumeMounts:                  mounts,
 		Network:                       convertNetwork(task.Network),
 	}
-	return executor.NewRunRequest(task.TaskGuid, &runInfo, tags), nil
+	return executor.NewRunRequest(containerGuid, &runInfo, tags), nil
+}
+
+func environmentVariable(env []*models.EnvironmentVariable, name string) (string, bool) {
+	for _, variable := range env {
+		if variable.Name == name {
+			return variable.Value, true
+		}
+	}
+	return "", false
 }
 
 func ConvertCachedDependencies(modelDeps []*models.CachedDependency) []executor.CachedDependency {
@@ -336,6 +336,30 @@ var _ = Describe("Resources", func() {
 			})
 		})
 
+		Context("when the desired LRP sets an evacuation priority", func() {
+			BeforeEach(func() {
+				desiredLRP.EnvironmentVariables = append(desiredLRP.EnvironmentVariables, &models.EnvironmentVariable{Name: rep.EvacuationPriorityEnv, Value: "10"})
+			})
+
+			It("tags the container with it", func() {
+				runReq, err := rep.NewRunRequestFromDesiredLRP(containerGuid, desiredLRP, &actualLRP.ActualLRPKey, &actualLRP.ActualLRPInstanceKey)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(runReq.Tags).To(Equal(executor.Tags{rep.EvacuationPriorityTag: "10"}))
+			})
+
+			Context("and the priority is not an integer", func() {
+				BeforeEach(func() {
+					desiredLRP.EnvironmentVariables[len(desiredLRP.EnvironmentVariables)-1].Value = "high"
+				})
+
+				It("does not tag the container", func() {
+					runReq, err := rep.NewRunRequestFromDesiredLRP(containerGuid, desiredLRP, &actualLRP.ActualLRPKey, &actualLRP.ActualLRPInstanceKey)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(runReq.Tags).To(Equal(executor.Tags{}))
+				})
+			})
+		})
+
 		Context("when a volumeMount config is invalid", func() {
 			BeforeEach(func() {
 				desiredLRP.VolumeMounts[0].Config = []byte("{{")
@@ -368,8 +392,9 @@ var _ = Describe("Resources", func() {
 		})
 
 		It("returns a valid run request", func() {
-			runReq, err := rep.NewRunRequestFromTask(task)
+			runReq, err := rep.NewRunRequestFromTask(rep.TaskContainerGuid(task.TaskGuid), task)
 			Expect(err).NotTo(HaveOccurred())
+			Expect(runReq.Guid).To(Equal("task-task-guid"))
 			Expect(runReq.Tags).To(Equal(executor.Tags{
 				rep.ResultFileTag: task.ResultFile,
 			}))
@@ -414,7 +439,7 @@ var _ = Describe("Resources", func() {
 			})
 
 			It("sets a nil network on the result", func() {
-				runReq, err := rep.NewRunRequestFromTask(task)
+				runReq, err := rep.NewRunRequestFromTask(rep.TaskContainerGuid(task.TaskGuid), task)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(runReq.Network).To(BeNil())
 			})
@@ -426,19 +451,43 @@ var _ = Describe("Resources", func() {
 			})
 
 			It("uses TotalDiskLimit as the disk scope", func() {
-				runReq, err := rep.NewRunRequestFromTask(task)
+				runReq, err := rep.NewRunRequestFromTask(rep.TaskContainerGuid(task.TaskGuid), task)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(runReq.DiskScope).To(Equal(executor.TotalDiskLimit))
 			})
 		})
 
+		Context("when the task sets an evacuation grace period", func() {
+			BeforeEach(func() {
+				task.EnvironmentVariables = append(task.EnvironmentVariables, &models.EnvironmentVariable{Name: rep.EvacuationGracePeriodEnv, Value: "90s"})
+			})
+
+			It("tags the container with it", func() {
+				runReq, err := rep.NewRunRequestFromTask(rep.TaskContainerGuid(task.TaskGuid), task)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(runReq.Tags).To(HaveKeyWithValue(rep.EvacuationGracePeriodTag, "1m30s"))
+			})
+
+			Context("and the grace period is invalid", func() {
+				BeforeEach(func() {
+					task.EnvironmentVariables[len(task.EnvironmentVariables)-1].Value = "-5s"
+				})
+
+				It("does not tag the container", func() {
+					runReq, err := rep.NewRunRequestFromTask(rep.TaskContainerGuid(task.TaskGuid), task)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(runReq.Tags).NotTo(HaveKey(rep.EvacuationGracePeriodTag))
+				})
+			})
+		})
+
 		Context("when a volumeMount config is invalid", func() {
 			BeforeEach(func() {
 				task.VolumeMounts[0].Config = []byte("{{")
 			})
 
 			It("returns an error", func() {
-				_, err := rep.NewRunRequestFromTask(task)
+				_, err := rep.NewRunRequestFromTask(rep.TaskContainerGuid(task.TaskGuid), task)
 				Expect(err).To(MatchError("invalid character '{' looking for beginning of object key string"))
 			})
 		})
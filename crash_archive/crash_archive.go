@@ -0,0 +1,450 @@
+// crash_archive keeps files from crashed LRP containers for operators to inspect
+package crash_archive
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/executor"
+	"code.cloudfoundry.org/lager"
+)
+
+// MetadataFile describes a captured crash. It is written last, so crash
+// directories without one are incomplete.
+const MetadataFile = "crash.json"
+
+// stagingDir holds the crashes being captured until they are complete.
+const stagingDir = ".staging"
+
+var (
+	ErrCrashNotFound = errors.New("crash not found")
+	ErrInvalidKey    = errors.New("invalid crash key")
+)
+
+type Config struct {
+	// Dir holds the archive. An empty Dir or no Paths disables capture.
+	Dir string
+	// Paths are fetched from each crashed container.
+	Paths []string
+	// MaxFileSize truncates each fetched path. Zero leaves them whole.
+	MaxFileSize int64
+	// MaxSize and MaxAge bound the archive. The oldest crashes are removed
+	// first.
+	MaxSize int64
+	MaxAge  time.Duration
+	// RotateInterval is how often crashes older than MaxAge are removed.
+	RotateInterval time.Duration
+}
+
+func (c Config) Enabled() bool {
+	return c.Dir != "" && len(c.Paths) > 0
+}
+
+type Crash struct {
+	ID            string      `json:"id"`
+	ProcessGuid   string      `json:"process_guid"`
+	Index         int32       `json:"index"`
+	ContainerGuid string      `json:"container_guid"`
+	FailureReason string      `json:"failure_reason"`
+	CapturedAt    int64       `json:"captured_at"`
+	Files         []CrashFile `json:"files"`
+}
+
+// CrashFile is a tar stream of a path in the crashed container.
+type CrashFile struct {
+	Name      string `json:"name"`
+	Path      string `json:"path"`
+	Size      int64  `json:"size"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+func (c Crash) size() int64 {
+	var size int64
+	for _, file := range c.Files {
+		size += file.Size
+	}
+	return size
+}
+
+//go:generate counterfeiter -o fake_crash_archive/fake_capturer.go . Capturer
+
+// Capturer saves the files of a crashed container before it is deleted.
+type Capturer interface {
+	Capture(logger lager.Logger, processGuid string, index int32, container executor.Container)
+}
+
+//go:generate counterfeiter -o fake_crash_archive/fake_archive.go . Archive
+
+// Archive serves the captured crashes, newest first.
+type Archive interface {
+	List(processGuid string, index int32) ([]Crash, error)
+	Open(processGuid string, index int32, id, name string) (io.ReadCloser, error)
+}
+
+type CrashArchive struct {
+	logger lager.Logger
+	config Config
+	client executor.Client
+	clock  clock.Clock
+
+	// lock guards the archive directory. It is not held while files are
+	// fetched from the executor.
+	lock      sync.Mutex
+	capturing map[string]bool
+}
+
+func New(logger lager.Logger, config Config, client executor.Client, clock clock.Clock) *CrashArchive {
+	return &CrashArchive{
+		logger:    logger.Session("crash-archive"),
+		config:    config,
+		client:    client,
+		clock:     clock,
+		capturing: map[string]bool{},
+	}
+}
+
+func (a *CrashArchive) Capture(logger lager.Logger, processGuid string, index int32, container executor.Container) {
+	if !a.config.Enabled() {
+		return
+	}
+
+	logger = logger.Session("capture-crash", lager.Data{"process-guid": processGuid, "index": index})
+	logger.Info("starting")
+	defer logger.Info("finished")
+
+	if !validKeyPart(processGuid) {
+		logger.Error("invalid-process-guid", ErrInvalidKey)
+		return
+	}
+
+	if !a.startCapture(logger, processGuid, index, container.Guid) {
+		return
+	}
+	defer a.finishCapture(container.Guid)
+
+	now := a.clock.Now()
+	crash := Crash{
+		ID:            strconv.FormatInt(now.UnixNano(), 10),
+		ProcessGuid:   processGuid,
+		Index:         index,
+		ContainerGuid: container.Guid,
+		FailureReason: container.RunResult.FailureReason,
+		CapturedAt:    now.UnixNano(),
+		Files:         []CrashFile{},
+	}
+
+	err := os.MkdirAll(filepath.Join(a.config.Dir, stagingDir), 0700)
+	if err != nil {
+		logger.Error("failed-to-create-staging-dir", err)
+		return
+	}
+
+	stagedDir, err := ioutil.TempDir(filepath.Join(a.config.Dir, stagingDir), crash.ID)
+	if err != nil {
+		logger.Error("failed-to-create-crash-dir", err)
+		return
+	}
+	defer os.RemoveAll(stagedDir)
+
+	for i, path := range a.config.Paths {
+		file, err := a.fetch(logger, stagedDir, container.Guid, i, path)
+		if err != nil {
+			continue
+		}
+		crash.Files = append(crash.Files, file)
+	}
+
+	err = writeMetadata(stagedDir, crash)
+	if err != nil {
+		logger.Error("failed-to-write-metadata", err)
+		return
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	crashDir := a.crashDir(processGuid, index, crash.ID)
+	err = os.MkdirAll(filepath.Dir(crashDir), 0700)
+	if err == nil {
+		err = os.Rename(stagedDir, crashDir)
+	}
+	if err != nil {
+		logger.Error("failed-to-store-crash", err)
+		return
+	}
+
+	logger.Info("captured-crash", lager.Data{"crash-id": crash.ID, "num-files": len(crash.Files)})
+	a.rotate(logger)
+}
+
+// startCapture reports whether the container should be captured, claiming it
+// if so. A container that is processed again once it has been captured, or
+// while it is being captured, is not captured twice.
+func (a *CrashArchive) startCapture(logger lager.Logger, processGuid string, index int32, containerGuid string) bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.capturing[containerGuid] {
+		logger.Info("crash-capture-in-progress", lager.Data{"container-guid": containerGuid})
+		return false
+	}
+
+	crashes, _ := a.list(filepath.Join(a.config.Dir, processGuid, strconv.Itoa(int(index))))
+	for _, crash := range crashes {
+		if crash.ContainerGuid == containerGuid {
+			logger.Info("crash-already-captured", lager.Data{"container-guid": containerGuid, "crash-id": crash.ID})
+			return false
+		}
+	}
+
+	a.capturing[containerGuid] = true
+	return true
+}
+
+func (a *CrashArchive) finishCapture(containerGuid string) {
+	a.lock.Lock()
+	delete(a.capturing, containerGuid)
+	a.lock.Unlock()
+}
+
+func (a *CrashArchive) fetch(logger lager.Logger, crashDir, containerGuid string, i int, path string) (CrashFile, error) {
+	logger = logger.WithData(lager.Data{"path": path})
+
+	stream, err := a.client.GetFiles(logger, containerGuid, path)
+	if err != nil {
+		logger.Error("failed-fetching-files", err)
+		return CrashFile{}, err
+	}
+	defer stream.Close()
+
+	base := filepath.Base(path)
+	if !validKeyPart(base) {
+		base = "root"
+	}
+	name := fmt.Sprintf("%d-%s.tar", i, base)
+	f, err := os.OpenFile(filepath.Join(crashDir, name), os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+	if err != nil {
+		logger.Error("failed-creating-file", err)
+		return CrashFile{}, err
+	}
+	defer f.Close()
+
+	var reader io.Reader = stream
+	if a.config.MaxFileSize > 0 {
+		reader = io.LimitReader(stream, a.config.MaxFileSize)
+	}
+
+	size, err := io.Copy(f, reader)
+	if err != nil {
+		logger.Error("failed-writing-file", err)
+		os.Remove(f.Name())
+		return CrashFile{}, err
+	}
+
+	file := CrashFile{Name: name, Path: path, Size: size}
+	if a.config.MaxFileSize <= 0 {
+		return file, nil
+	}
+	if n, _ := io.ReadFull(stream, make([]byte, 1)); n > 0 {
+		logger.Info("truncated-file", lager.Data{"max-file-size": a.config.MaxFileSize})
+		file.Truncated = true
+	}
+	return file, nil
+}
+
+func (a *CrashArchive) List(processGuid string, index int32) ([]Crash, error) {
+	if !validKeyPart(processGuid) {
+		return nil, ErrInvalidKey
+	}
+	if a.config.Dir == "" {
+		return []Crash{}, nil
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	return a.list(filepath.Join(a.config.Dir, processGuid, strconv.Itoa(int(index))))
+}
+
+func (a *CrashArchive) Open(processGuid string, index int32, id, name string) (io.ReadCloser, error) {
+	if !validKeyPart(processGuid) || !validKeyPart(id) || !validKeyPart(name) {
+		return nil, ErrInvalidKey
+	}
+	if a.config.Dir == "" {
+		return nil, ErrCrashNotFound
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	crashDir := a.crashDir(processGuid, index, id)
+	crash, err := readMetadata(crashDir)
+	if err != nil {
+		return nil, ErrCrashNotFound
+	}
+
+	for _, file := range crash.Files {
+		if file.Name == name {
+			f, err := os.Open(filepath.Join(crashDir, name))
+			if err != nil {
+				return nil, err
+			}
+			return f, nil
+		}
+	}
+	return nil, ErrCrashNotFound
+}
+
+// Run removes the crashes older than MaxAge when it starts and every
+// RotateInterval after that. Crashes over MaxSize are removed as soon as a
+// new one is captured.
+func (a *CrashArchive) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	logger := a.logger
+	logger.Info("starting", lager.Data{"dir": a.config.Dir})
+	defer logger.Info("finished")
+
+	a.lock.Lock()
+	// captures interrupted by a restart are never completed
+	if len(a.capturing) == 0 {
+		os.RemoveAll(filepath.Join(a.config.Dir, stagingDir))
+	}
+	a.rotate(logger)
+	a.lock.Unlock()
+
+	timer := a.clock.NewTimer(a.config.RotateInterval)
+	defer timer.Stop()
+
+	close(ready)
+
+	for {
+		select {
+		case <-timer.C():
+			a.lock.Lock()
+			a.rotate(logger)
+			a.lock.Unlock()
+			timer.Reset(a.config.RotateInterval)
+		case <-signals:
+			return nil
+		}
+	}
+}
+
+// rotate must be called with the lock held.
+func (a *CrashArchive) rotate(logger lager.Logger) {
+	if a.config.Dir == "" {
+		return
+	}
+
+	logger = logger.Session("rotate")
+
+	crashes := []Crash{}
+	processDirs, _ := ioutil.ReadDir(a.config.Dir)
+	for _, processDir := range processDirs {
+		if processDir.Name() == stagingDir {
+			continue
+		}
+		indexDirs, _ := ioutil.ReadDir(filepath.Join(a.config.Dir, processDir.Name()))
+		for _, indexDir := range indexDirs {
+			indexCrashes, _ := a.list(filepath.Join(a.config.Dir, processDir.Name(), indexDir.Name()))
+			crashes = append(crashes, indexCrashes...)
+		}
+	}
+
+	var total int64
+	for _, crash := range crashes {
+		total += crash.size()
+	}
+
+	// oldest first
+	sort.Sort(sort.Reverse(byCapturedAt(crashes)))
+
+	cutoff := a.clock.Now().Add(-a.config.MaxAge).UnixNano()
+	for _, crash := range crashes {
+		expired := a.config.MaxAge > 0 && crash.CapturedAt < cutoff
+		oversized := a.config.MaxSize > 0 && total > a.config.MaxSize
+		if !expired && !oversized {
+			break
+		}
+
+		logger.Info("removing-crash", lager.Data{"process-guid": crash.ProcessGuid, "index": crash.Index, "crash-id": crash.ID, "expired": expired})
+		err := os.RemoveAll(a.crashDir(crash.ProcessGuid, crash.Index, crash.ID))
+		if err != nil {
+			logger.Error("failed-removing-crash", err)
+			continue
+		}
+		total -= crash.size()
+
+		indexDir := filepath.Join(a.config.Dir, crash.ProcessGuid, strconv.Itoa(int(crash.Index)))
+		os.Remove(indexDir)
+		os.Remove(filepath.Dir(indexDir))
+	}
+}
+
+// list returns the complete crashes in an index directory, newest first.
+func (a *CrashArchive) list(indexDir string) ([]Crash, error) {
+	entries, err := ioutil.ReadDir(indexDir)
+	if os.IsNotExist(err) {
+		return []Crash{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	crashes := []Crash{}
+	for _, entry := range entries {
+		crash, err := readMetadata(filepath.Join(indexDir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		crashes = append(crashes, crash)
+	}
+
+	sort.Sort(byCapturedAt(crashes))
+	return crashes, nil
+}
+
+func (a *CrashArchive) crashDir(processGuid string, index int32, id string) string {
+	return filepath.Join(a.config.Dir, processGuid, strconv.Itoa(int(index)), id)
+}
+
+func readMetadata(crashDir string) (Crash, error) {
+	var crash Crash
+	payload, err := ioutil.ReadFile(filepath.Join(crashDir, MetadataFile))
+	if err != nil {
+		return crash, err
+	}
+	err = json.Unmarshal(payload, &crash)
+	return crash, err
+}
+
+func writeMetadata(crashDir string, crash Crash) error {
+	payload, err := json.Marshal(crash)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(crashDir, MetadataFile), payload, 0600)
+}
+
+// validKeyPart reports whether the value can name a single directory entry
+// of the archive. Hidden entries, such as the staging directory, are not part
+// of it.
+func validKeyPart(value string) bool {
+	return value != "" && !strings.HasPrefix(value, ".") && !strings.ContainsAny(value, `/\`)
+}
+
+type byCapturedAt []Crash
+
+func (c byCapturedAt) Len() int           { return len(c) }
+func (c byCapturedAt) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+func (c byCapturedAt) Less(i, j int) bool { return c[i].CapturedAt > c[j].CapturedAt }
@@ -0,0 +1,13 @@
+package crash_archive_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestCrashArchive(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Crash Archive Suite")
+}
@@ -0,0 +1,267 @@
+package crash_archive_test
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/executor"
+	executorfakes "code.cloudfoundry.org/executor/fakes"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/rep/crash_archive"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+)
+
+var _ = Describe("CrashArchive", func() {
+	var (
+		logger    *lagertest.TestLogger
+		client    *executorfakes.FakeClient
+		fakeClock *fakeclock.FakeClock
+		config    crash_archive.Config
+		archive   *crash_archive.CrashArchive
+		container executor.Container
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		client = new(executorfakes.FakeClient)
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+
+		dir, err := ioutil.TempDir("", "crash-archive")
+		Expect(err).NotTo(HaveOccurred())
+
+		config = crash_archive.Config{
+			Dir:            dir,
+			Paths:          []string{"/home/vcap/logs", "/tmp/cores"},
+			MaxFileSize:    1024,
+			MaxSize:        1024 * 1024,
+			MaxAge:         time.Hour,
+			RotateInterval: time.Minute,
+		}
+
+		client.GetFilesStub = func(_ lager.Logger, guid, path string) (io.ReadCloser, error) {
+			return ioutil.NopCloser(strings.NewReader(guid + ":" + path)), nil
+		}
+
+		container = executor.Container{
+			Guid:      "container-guid",
+			RunResult: executor.ContainerRunResult{Failed: true, FailureReason: "exited with status 1"},
+		}
+	})
+
+	JustBeforeEach(func() {
+		archive = crash_archive.New(logger, config, client, fakeClock)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(config.Dir)
+	})
+
+	readFile := func(processGuid string, index int32, id, name string) string {
+		stream, err := archive.Open(processGuid, index, id, name)
+		Expect(err).NotTo(HaveOccurred())
+		defer stream.Close()
+
+		contents, err := ioutil.ReadAll(stream)
+		Expect(err).NotTo(HaveOccurred())
+		return string(contents)
+	}
+
+	It("captures the configured paths of the crashed container", func() {
+		archive.Capture(logger, "process-guid", 2, container)
+
+		Expect(client.GetFilesCallCount()).To(Equal(2))
+
+		crashes, err := archive.List("process-guid", 2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(crashes).To(HaveLen(1))
+
+		crash := crashes[0]
+		Expect(crash.ProcessGuid).To(Equal("process-guid"))
+		Expect(crash.Index).To(Equal(int32(2)))
+		Expect(crash.ContainerGuid).To(Equal("container-guid"))
+		Expect(crash.FailureReason).To(Equal("exited with status 1"))
+		Expect(crash.CapturedAt).To(Equal(fakeClock.Now().UnixNano()))
+		Expect(crash.Files).To(HaveLen(2))
+		Expect(crash.Files[0].Name).To(Equal("0-logs.tar"))
+		Expect(crash.Files[0].Path).To(Equal("/home/vcap/logs"))
+
+		Expect(readFile("process-guid", 2, crash.ID, "0-logs.tar")).To(Equal("container-guid:/home/vcap/logs"))
+		Expect(readFile("process-guid", 2, crash.ID, "1-cores.tar")).To(Equal("container-guid:/tmp/cores"))
+	})
+
+	otherContainer := func(guid string) executor.Container {
+		other := container
+		other.Guid = guid
+		return other
+	}
+
+	It("keeps the crashes of each instance apart, newest first", func() {
+		archive.Capture(logger, "process-guid", 0, container)
+		fakeClock.Increment(time.Second)
+		archive.Capture(logger, "process-guid", 0, otherContainer("container-guid-2"))
+		archive.Capture(logger, "process-guid", 1, otherContainer("container-guid-3"))
+
+		crashes, err := archive.List("process-guid", 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(crashes).To(HaveLen(2))
+		Expect(crashes[0].CapturedAt).To(BeNumerically(">", crashes[1].CapturedAt))
+
+		crashes, err = archive.List("other-process-guid", 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(crashes).To(BeEmpty())
+	})
+
+	It("skips paths that cannot be fetched", func() {
+		client.GetFilesStub = func(_ lager.Logger, guid, path string) (io.ReadCloser, error) {
+			if path == "/tmp/cores" {
+				return nil, errors.New("no such file")
+			}
+			return ioutil.NopCloser(strings.NewReader("logs")), nil
+		}
+
+		archive.Capture(logger, "process-guid", 0, container)
+
+		crashes, err := archive.List("process-guid", 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(crashes).To(HaveLen(1))
+		Expect(crashes[0].Files).To(HaveLen(1))
+	})
+
+	Context("when a path is larger than the maximum file size", func() {
+		BeforeEach(func() {
+			config.MaxFileSize = 4
+		})
+
+		It("truncates it", func() {
+			archive.Capture(logger, "process-guid", 0, container)
+
+			crashes, err := archive.List("process-guid", 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(crashes[0].Files[0].Size).To(Equal(int64(4)))
+			Expect(crashes[0].Files[0].Truncated).To(BeTrue())
+			Expect(readFile("process-guid", 0, crashes[0].ID, "0-logs.tar")).To(Equal("cont"))
+		})
+	})
+
+	Context("when the archive grows over its maximum size", func() {
+		BeforeEach(func() {
+			// each capture holds 55 to 59 bytes
+			config.MaxSize = 130
+		})
+
+		It("removes the oldest crashes", func() {
+			archive.Capture(logger, "process-guid", 0, container)
+			fakeClock.Increment(time.Second)
+			archive.Capture(logger, "process-guid", 1, otherContainer("container-guid-2"))
+			fakeClock.Increment(time.Second)
+			archive.Capture(logger, "process-guid", 0, otherContainer("container-guid-3"))
+
+			crashes, err := archive.List("process-guid", 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(crashes).To(HaveLen(1))
+			Expect(crashes[0].CapturedAt).To(Equal(fakeClock.Now().UnixNano()))
+
+			crashes, err = archive.List("process-guid", 1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(crashes).To(HaveLen(1))
+		})
+	})
+
+	Context("when crashes grow older than the maximum age", func() {
+		var process ifrit.Process
+
+		JustBeforeEach(func() {
+			archive.Capture(logger, "process-guid", 0, container)
+			process = ifrit.Invoke(archive)
+		})
+
+		AfterEach(func() {
+			ifrit.Interrupt(process)
+			Eventually(process.Wait()).Should(Receive())
+		})
+
+		It("removes them on the next rotation", func() {
+			fakeClock.Increment(time.Hour + time.Minute)
+
+			Eventually(func() ([]crash_archive.Crash, error) {
+				return archive.List("process-guid", 0)
+			}).Should(BeEmpty())
+
+			_, err := os.Stat(filepath.Join(config.Dir, "process-guid"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+	})
+
+	It("captures a container only once", func() {
+		archive.Capture(logger, "process-guid", 0, container)
+		fakeClock.Increment(time.Second)
+		archive.Capture(logger, "process-guid", 0, container)
+
+		Expect(client.GetFilesCallCount()).To(Equal(2))
+		crashes, err := archive.List("process-guid", 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(crashes).To(HaveLen(1))
+	})
+
+	It("does not hold the archive while fetching files", func() {
+		listed := make(chan error, 1)
+		client.GetFilesStub = func(_ lager.Logger, guid, path string) (io.ReadCloser, error) {
+			if client.GetFilesCallCount() == 1 {
+				go func() {
+					_, err := archive.List("process-guid", 0)
+					listed <- err
+				}()
+				Eventually(listed).Should(Receive(BeNil()))
+			}
+			return ioutil.NopCloser(strings.NewReader(guid + ":" + path)), nil
+		}
+
+		archive.Capture(logger, "process-guid", 0, container)
+
+		crashes, err := archive.List("process-guid", 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(crashes).To(HaveLen(1))
+	})
+
+	It("rejects keys that do not name a single directory entry", func() {
+		_, err := archive.List("../etc", 0)
+		Expect(err).To(Equal(crash_archive.ErrInvalidKey))
+
+		_, err = archive.Open("process-guid", 0, "..", "crash.json")
+		Expect(err).To(Equal(crash_archive.ErrInvalidKey))
+	})
+
+	It("only opens captured files", func() {
+		archive.Capture(logger, "process-guid", 0, container)
+
+		crashes, err := archive.List("process-guid", 0)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = archive.Open("process-guid", 0, crashes[0].ID, crash_archive.MetadataFile)
+		Expect(err).To(Equal(crash_archive.ErrCrashNotFound))
+	})
+
+	Context("when no paths are configured", func() {
+		BeforeEach(func() {
+			config.Paths = nil
+		})
+
+		It("does not capture anything", func() {
+			archive.Capture(logger, "process-guid", 0, container)
+
+			Expect(client.GetFilesCallCount()).To(BeZero())
+			crashes, err := archive.List("process-guid", 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(crashes).To(BeEmpty())
+		})
+	})
+})
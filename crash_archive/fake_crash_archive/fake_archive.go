@@ -0,0 +1,106 @@
+// This file was generated by counterfeiter
+package fake_crash_archive
+
+import (
+	"io"
+	"sync"
+
+	"code.cloudfoundry.org/rep/crash_archive"
+)
+
+type FakeArchive struct {
+	ListStub        func(processGuid string, index int32) ([]crash_archive.Crash, error)
+	listMutex       sync.RWMutex
+	listArgsForCall []struct {
+		processGuid string
+		index       int32
+	}
+	listReturns struct {
+		result1 []crash_archive.Crash
+		result2 error
+	}
+	OpenStub        func(processGuid string, index int32, id string, name string) (io.ReadCloser, error)
+	openMutex       sync.RWMutex
+	openArgsForCall []struct {
+		processGuid string
+		index       int32
+		id          string
+		name        string
+	}
+	openReturns struct {
+		result1 io.ReadCloser
+		result2 error
+	}
+}
+
+func (fake *FakeArchive) List(processGuid string, index int32) ([]crash_archive.Crash, error) {
+	fake.listMutex.Lock()
+	fake.listArgsForCall = append(fake.listArgsForCall, struct {
+		processGuid string
+		index       int32
+	}{processGuid, index})
+	fake.listMutex.Unlock()
+	if fake.ListStub != nil {
+		return fake.ListStub(processGuid, index)
+	} else {
+		return fake.listReturns.result1, fake.listReturns.result2
+	}
+}
+
+func (fake *FakeArchive) ListCallCount() int {
+	fake.listMutex.RLock()
+	defer fake.listMutex.RUnlock()
+	return len(fake.listArgsForCall)
+}
+
+func (fake *FakeArchive) ListArgsForCall(i int) (string, int32) {
+	fake.listMutex.RLock()
+	defer fake.listMutex.RUnlock()
+	return fake.listArgsForCall[i].processGuid, fake.listArgsForCall[i].index
+}
+
+func (fake *FakeArchive) ListReturns(result1 []crash_archive.Crash, result2 error) {
+	fake.ListStub = nil
+	fake.listReturns = struct {
+		result1 []crash_archive.Crash
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeArchive) Open(processGuid string, index int32, id string, name string) (io.ReadCloser, error) {
+	fake.openMutex.Lock()
+	fake.openArgsForCall = append(fake.openArgsForCall, struct {
+		processGuid string
+		index       int32
+		id          string
+		name        string
+	}{processGuid, index, id, name})
+	fake.openMutex.Unlock()
+	if fake.OpenStub != nil {
+		return fake.OpenStub(processGuid, index, id, name)
+	} else {
+		return fake.openReturns.result1, fake.openReturns.result2
+	}
+}
+
+func (fake *FakeArchive) OpenCallCount() int {
+	fake.openMutex.RLock()
+	defer fake.openMutex.RUnlock()
+	return len(fake.openArgsForCall)
+}
+
+func (fake *FakeArchive) OpenArgsForCall(i int) (string, int32, string, string) {
+	fake.openMutex.RLock()
+	defer fake.openMutex.RUnlock()
+	return fake.openArgsForCall[i].processGuid, fake.openArgsForCall[i].index, fake.openArgsForCall[i].id, fake.openArgsForCall[i].name
+}
+
+func (fake *FakeArchive) OpenReturns(result1 io.ReadCloser, result2 error) {
+	fake.OpenStub = nil
+	fake.openReturns = struct {
+		result1 io.ReadCloser
+		result2 error
+	}{result1, result2}
+}
+
+var _ crash_archive.Archive = new(FakeArchive)
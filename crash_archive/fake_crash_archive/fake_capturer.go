@@ -0,0 +1,49 @@
+// This file was generated by counterfeiter
+package fake_crash_archive
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/executor"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep/crash_archive"
+)
+
+type FakeCapturer struct {
+	CaptureStub        func(logger lager.Logger, processGuid string, index int32, container executor.Container)
+	captureMutex       sync.RWMutex
+	captureArgsForCall []struct {
+		logger      lager.Logger
+		processGuid string
+		index       int32
+		container   executor.Container
+	}
+}
+
+func (fake *FakeCapturer) Capture(logger lager.Logger, processGuid string, index int32, container executor.Container) {
+	fake.captureMutex.Lock()
+	fake.captureArgsForCall = append(fake.captureArgsForCall, struct {
+		logger      lager.Logger
+		processGuid string
+		index       int32
+		container   executor.Container
+	}{logger, processGuid, index, container})
+	fake.captureMutex.Unlock()
+	if fake.CaptureStub != nil {
+		fake.CaptureStub(logger, processGuid, index, container)
+	}
+}
+
+func (fake *FakeCapturer) CaptureCallCount() int {
+	fake.captureMutex.RLock()
+	defer fake.captureMutex.RUnlock()
+	return len(fake.captureArgsForCall)
+}
+
+func (fake *FakeCapturer) CaptureArgsForCall(i int) (lager.Logger, string, int32, executor.Container) {
+	fake.captureMutex.RLock()
+	defer fake.captureMutex.RUnlock()
+	return fake.captureArgsForCall[i].logger, fake.captureArgsForCall[i].processGuid, fake.captureArgsForCall[i].index, fake.captureArgsForCall[i].container
+}
+
+var _ crash_archive.Capturer = new(FakeCapturer)
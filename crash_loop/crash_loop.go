@@ -0,0 +1,154 @@
+// crash_loop detects the processes whose instances keep crashing on the cell
+package crash_loop
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/lager"
+)
+
+var ErrInvalidWindow = errors.New("crash loop window must be positive when the crash threshold is set")
+
+type Config struct {
+	// CrashThreshold is the number of crashes of a process within Window
+	// beyond which the process is crash looping. Zero disables detection.
+	CrashThreshold int
+	Window         time.Duration
+	// CoolDown is how long a process stays crash looping after its last
+	// crash.
+	CoolDown time.Duration
+	// RejectInstances refuses new instances of crash looping processes so
+	// that they are placed on other cells.
+	RejectInstances bool
+}
+
+// Validate rejects a config whose window would forget every crash as soon as
+// it is recorded, silently disabling detection.
+func (c Config) Validate() error {
+	if c.CrashThreshold > 0 && c.Window <= 0 {
+		return ErrInvalidWindow
+	}
+	return nil
+}
+
+//go:generate counterfeiter -o fake_crash_loop/fake_tracker.go . Tracker
+
+// Tracker counts the crashes of each process on the cell.
+type Tracker interface {
+	// RecordCrash counts a crash of the process. The crashed container is
+	// counted once, however often its completion is processed.
+	RecordCrash(logger lager.Logger, processGuid, containerGuid string)
+	// CrashLooping returns the guids of the crash looping processes, sorted.
+	CrashLooping() []string
+	// Rejects reports whether new instances of the process should be refused.
+	Rejects(processGuid string) bool
+}
+
+type tracker struct {
+	config Config
+	clock  clock.Clock
+
+	lock         sync.Mutex
+	crashes      map[string][]crash
+	loopingUntil map[string]time.Time
+}
+
+type crash struct {
+	at            time.Time
+	containerGuid string
+}
+
+func NewTracker(config Config, clock clock.Clock) Tracker {
+	return &tracker{
+		config:       config,
+		clock:        clock,
+		crashes:      map[string][]crash{},
+		loopingUntil: map[string]time.Time{},
+	}
+}
+
+func (t *tracker) RecordCrash(logger lager.Logger, processGuid, containerGuid string) {
+	if t.config.CrashThreshold <= 0 {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := t.clock.Now()
+	t.prune(now)
+	for _, recorded := range t.crashes[processGuid] {
+		if recorded.containerGuid == containerGuid {
+			return
+		}
+	}
+	t.crashes[processGuid] = append(t.crashes[processGuid], crash{at: now, containerGuid: containerGuid})
+
+	crashes := len(t.crashes[processGuid])
+	if crashes <= t.config.CrashThreshold {
+		return
+	}
+
+	if _, looping := t.loopingUntil[processGuid]; !looping {
+		logger.Info("process-crash-looping", lager.Data{
+			"process-guid": processGuid,
+			"crashes":      crashes,
+			"window":       t.config.Window.String(),
+		})
+	}
+	t.loopingUntil[processGuid] = now.Add(t.config.CoolDown)
+}
+
+func (t *tracker) CrashLooping() []string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.prune(t.clock.Now())
+
+	processGuids := make([]string, 0, len(t.loopingUntil))
+	for processGuid := range t.loopingUntil {
+		processGuids = append(processGuids, processGuid)
+	}
+	sort.Strings(processGuids)
+	return processGuids
+}
+
+func (t *tracker) Rejects(processGuid string) bool {
+	if !t.config.RejectInstances {
+		return false
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.prune(t.clock.Now())
+
+	_, looping := t.loopingUntil[processGuid]
+	return looping
+}
+
+// prune forgets the crashes outside of the window and the processes whose
+// cool-down has elapsed. It must be called with the lock held.
+func (t *tracker) prune(now time.Time) {
+	cutoff := now.Add(-t.config.Window)
+	for processGuid, crashes := range t.crashes {
+		for len(crashes) > 0 && !crashes[0].at.After(cutoff) {
+			crashes = crashes[1:]
+		}
+		if len(crashes) == 0 {
+			delete(t.crashes, processGuid)
+			continue
+		}
+		t.crashes[processGuid] = crashes
+	}
+
+	for processGuid, until := range t.loopingUntil {
+		if !now.Before(until) {
+			delete(t.loopingUntil, processGuid)
+		}
+	}
+}
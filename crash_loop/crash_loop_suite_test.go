@@ -0,0 +1,13 @@
+package crash_loop_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestCrashLoop(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Crash Loop Suite")
+}
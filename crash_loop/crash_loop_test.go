@@ -0,0 +1,149 @@
+package crash_loop_test
+
+import (
+	"fmt"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/rep/crash_loop"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Tracker", func() {
+	var (
+		logger    *lagertest.TestLogger
+		fakeClock *fakeclock.FakeClock
+		config    crash_loop.Config
+		tracker   crash_loop.Tracker
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+
+		config = crash_loop.Config{
+			CrashThreshold:  2,
+			Window:          time.Minute,
+			CoolDown:        5 * time.Minute,
+			RejectInstances: true,
+		}
+	})
+
+	JustBeforeEach(func() {
+		tracker = crash_loop.NewTracker(config, fakeClock)
+	})
+
+	var crashes int
+	crash := func(processGuid string, times int) {
+		for i := 0; i < times; i++ {
+			crashes++
+			tracker.RecordCrash(logger, processGuid, fmt.Sprintf("container-guid-%d", crashes))
+		}
+	}
+
+	It("starts with no crash looping processes", func() {
+		Expect(tracker.CrashLooping()).To(BeEmpty())
+		Expect(tracker.Rejects("process-guid")).To(BeFalse())
+	})
+
+	It("tolerates crashes up to the threshold", func() {
+		crash("process-guid", 2)
+
+		Expect(tracker.CrashLooping()).To(BeEmpty())
+		Expect(tracker.Rejects("process-guid")).To(BeFalse())
+	})
+
+	It("reports the processes that crash more than the threshold within the window", func() {
+		crash("process-b", 3)
+		crash("process-a", 3)
+		crash("process-c", 1)
+
+		Expect(tracker.CrashLooping()).To(Equal([]string{"process-a", "process-b"}))
+		Expect(tracker.Rejects("process-a")).To(BeTrue())
+		Expect(tracker.Rejects("process-c")).To(BeFalse())
+		Expect(logger).To(Say("process-crash-looping"))
+	})
+
+	It("counts each crashed container once", func() {
+		for i := 0; i < 3; i++ {
+			tracker.RecordCrash(logger, "process-guid", "container-guid")
+		}
+
+		Expect(tracker.CrashLooping()).To(BeEmpty())
+	})
+
+	It("forgets the crashes outside of the window", func() {
+		crash("process-guid", 2)
+		fakeClock.Increment(time.Minute + time.Second)
+		crash("process-guid", 1)
+
+		Expect(tracker.CrashLooping()).To(BeEmpty())
+	})
+
+	Context("when a process is crash looping", func() {
+		JustBeforeEach(func() {
+			crash("process-guid", 3)
+		})
+
+		It("stops reporting it once the cool-down has elapsed", func() {
+			fakeClock.Increment(5*time.Minute - time.Second)
+			Expect(tracker.CrashLooping()).To(ConsistOf("process-guid"))
+
+			fakeClock.Increment(time.Second)
+			Expect(tracker.CrashLooping()).To(BeEmpty())
+			Expect(tracker.Rejects("process-guid")).To(BeFalse())
+		})
+
+		It("extends the cool-down while it keeps crashing", func() {
+			fakeClock.Increment(30 * time.Second)
+			crash("process-guid", 1)
+
+			fakeClock.Increment(5*time.Minute - time.Second)
+			Expect(tracker.CrashLooping()).To(ConsistOf("process-guid"))
+		})
+	})
+
+	Context("when rejecting instances is disabled", func() {
+		BeforeEach(func() {
+			config.RejectInstances = false
+		})
+
+		It("still reports crash looping processes without rejecting them", func() {
+			crash("process-guid", 3)
+
+			Expect(tracker.CrashLooping()).To(ConsistOf("process-guid"))
+			Expect(tracker.Rejects("process-guid")).To(BeFalse())
+		})
+	})
+
+	Context("when the threshold is zero", func() {
+		BeforeEach(func() {
+			config.CrashThreshold = 0
+		})
+
+		It("does not track crashes", func() {
+			crash("process-guid", 10)
+
+			Expect(tracker.CrashLooping()).To(BeEmpty())
+			Expect(tracker.Rejects("process-guid")).To(BeFalse())
+		})
+	})
+
+	Describe("Config.Validate", func() {
+		It("accepts a threshold with a window", func() {
+			Expect(config.Validate()).To(Succeed())
+		})
+
+		It("rejects a threshold without a window", func() {
+			config.Window = 0
+			Expect(config.Validate()).To(MatchError(crash_loop.ErrInvalidWindow))
+		})
+
+		It("accepts no window when detection is disabled", func() {
+			Expect(crash_loop.Config{}.Validate()).To(Succeed())
+		})
+	})
+})
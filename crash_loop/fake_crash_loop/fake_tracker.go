@@ -0,0 +1,116 @@
+// This file was generated by counterfeiter
+package fake_crash_loop
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep/crash_loop"
+)
+
+type FakeTracker struct {
+	RecordCrashStub        func(logger lager.Logger, processGuid, containerGuid string)
+	recordCrashMutex       sync.RWMutex
+	recordCrashArgsForCall []struct {
+		logger        lager.Logger
+		processGuid   string
+		containerGuid string
+	}
+	CrashLoopingStub        func() []string
+	crashLoopingMutex       sync.RWMutex
+	crashLoopingArgsForCall []struct{}
+	crashLoopingReturns     struct {
+		result1 []string
+	}
+	RejectsStub        func(processGuid string) bool
+	rejectsMutex       sync.RWMutex
+	rejectsArgsForCall []struct {
+		processGuid string
+	}
+	rejectsReturns struct {
+		result1 bool
+	}
+}
+
+func (fake *FakeTracker) RecordCrash(logger lager.Logger, processGuid string, containerGuid string) {
+	fake.recordCrashMutex.Lock()
+	fake.recordCrashArgsForCall = append(fake.recordCrashArgsForCall, struct {
+		logger        lager.Logger
+		processGuid   string
+		containerGuid string
+	}{logger, processGuid, containerGuid})
+	fake.recordCrashMutex.Unlock()
+	if fake.RecordCrashStub != nil {
+		fake.RecordCrashStub(logger, processGuid, containerGuid)
+	}
+}
+
+func (fake *FakeTracker) RecordCrashCallCount() int {
+	fake.recordCrashMutex.RLock()
+	defer fake.recordCrashMutex.RUnlock()
+	return len(fake.recordCrashArgsForCall)
+}
+
+func (fake *FakeTracker) RecordCrashArgsForCall(i int) (lager.Logger, string, string) {
+	fake.recordCrashMutex.RLock()
+	defer fake.recordCrashMutex.RUnlock()
+	return fake.recordCrashArgsForCall[i].logger, fake.recordCrashArgsForCall[i].processGuid, fake.recordCrashArgsForCall[i].containerGuid
+}
+
+func (fake *FakeTracker) CrashLooping() []string {
+	fake.crashLoopingMutex.Lock()
+	fake.crashLoopingArgsForCall = append(fake.crashLoopingArgsForCall, struct{}{})
+	fake.crashLoopingMutex.Unlock()
+	if fake.CrashLoopingStub != nil {
+		return fake.CrashLoopingStub()
+	} else {
+		return fake.crashLoopingReturns.result1
+	}
+}
+
+func (fake *FakeTracker) CrashLoopingCallCount() int {
+	fake.crashLoopingMutex.RLock()
+	defer fake.crashLoopingMutex.RUnlock()
+	return len(fake.crashLoopingArgsForCall)
+}
+
+func (fake *FakeTracker) CrashLoopingReturns(result1 []string) {
+	fake.CrashLoopingStub = nil
+	fake.crashLoopingReturns = struct {
+		result1 []string
+	}{result1}
+}
+
+func (fake *FakeTracker) Rejects(processGuid string) bool {
+	fake.rejectsMutex.Lock()
+	fake.rejectsArgsForCall = append(fake.rejectsArgsForCall, struct {
+		processGuid string
+	}{processGuid})
+	fake.rejectsMutex.Unlock()
+	if fake.RejectsStub != nil {
+		return fake.RejectsStub(processGuid)
+	} else {
+		return fake.rejectsReturns.result1
+	}
+}
+
+func (fake *FakeTracker) RejectsCallCount() int {
+	fake.rejectsMutex.RLock()
+	defer fake.rejectsMutex.RUnlock()
+	return len(fake.rejectsArgsForCall)
+}
+
+func (fake *FakeTracker) RejectsArgsForCall(i int) string {
+	fake.rejectsMutex.RLock()
+	defer fake.rejectsMutex.RUnlock()
+	return fake.rejectsArgsForCall[i].processGuid
+}
+
+func (fake *FakeTracker) RejectsReturns(result1 bool) {
+	fake.RejectsStub = nil
+	fake.rejectsReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+var _ crash_loop.Tracker = new(FakeTracker)
@@ -1,23 +1,59 @@
 package evacuation
 
 import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"sort"
+	"sync"
 	"time"
 
 	"code.cloudfoundry.org/clock"
 	"code.cloudfoundry.org/executor"
 	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep"
 	"code.cloudfoundry.org/rep/evacuation/evacuation_context"
+	"code.cloudfoundry.org/rep/evacuation/evacuation_order"
+	"code.cloudfoundry.org/runtimeschema/metric"
 )
 
+const evacuationDuration = metric.Duration("EvacuationDuration")
+
+var evacuationContainerMetrics = map[string]metric.Metric{
+	rep.EvacuationDispositionHandedOff: metric.Metric("EvacuationContainersHandedOff"),
+	rep.EvacuationDispositionCrashed:   metric.Metric("EvacuationContainersCrashed"),
+	rep.EvacuationDispositionStopped:   metric.Metric("EvacuationContainersStopped"),
+	rep.EvacuationDispositionCompleted: metric.Metric("EvacuationContainersCompleted"),
+	rep.EvacuationDispositionTimedOut:  metric.Metric("EvacuationContainersTimedOut"),
+	rep.EvacuationDispositionUnknown:   metric.Metric("EvacuationContainersUnknown"),
+}
+
+type trackedContainer struct {
+	rep.EvacuationReportContainer
+	present bool
+}
+
 type Evacuator struct {
 	logger             lager.Logger
 	clock              clock.Clock
 	executorClient     executor.Client
 	evacuationNotifier evacuation_context.EvacuationNotifier
+	orderer            evacuation_order.Orderer
 	cellID             string
 	evacuationTimeout  time.Duration
-	pollingInterval    time.Duration
+	reportDir          string
+
+	intervalLock    sync.Mutex
+	pollingInterval time.Duration
+
+	statusLock  sync.Mutex
+	startedAt   time.Time
+	completedAt time.Time
+	remaining   map[string]map[string]int
+	failures    map[string]rep.EvacuationFailure
+	tracked     map[string]*trackedContainer
 }
 
 func NewEvacuator(
@@ -25,18 +61,25 @@ func NewEvacuator(
 	clock clock.Clock,
 	executorClient executor.Client,
 	evacuationNotifier evacuation_context.EvacuationNotifier,
+	orderer evacuation_order.Orderer,
 	cellID string,
 	evacuationTimeout time.Duration,
 	pollingInterval time.Duration,
+	reportDir string,
 ) *Evacuator {
 	return &Evacuator{
 		logger:             logger,
 		clock:              clock,
 		executorClient:     executorClient,
 		evacuationNotifier: evacuationNotifier,
+		orderer:            orderer,
 		cellID:             cellID,
 		evacuationTimeout:  evacuationTimeout,
 		pollingInterval:    pollingInterval,
+		reportDir:          reportDir,
+		remaining:          map[string]map[string]int{},
+		failures:           map[string]rep.EvacuationFailure{},
+		tracked:            map[string]*trackedContainer{},
 	}
 }
 
@@ -48,53 +91,115 @@ func (e *Evacuator) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
 	evacuationNotify := e.evacuationNotifier.EvacuateNotify()
 	close(ready)
 
-	select {
-	case signal := <-signals:
-		logger.Info("signaled", lager.Data{"signal": signal.String()})
-		return nil
-	case <-evacuationNotify:
-		evacuationNotify = nil
-		logger.Info("notified-of-evacuation")
+	for {
+		select {
+		case signal := <-signals:
+			logger.Info("signaled", lager.Data{"signal": signal.String()})
+			return nil
+		case <-evacuationNotify:
+			logger.Info("notified-of-evacuation")
+		}
+
+		if !e.runEvacuation(logger, signals) {
+			return nil
+		}
+
+		evacuationNotify = e.evacuationNotifier.EvacuateNotify()
 	}
+}
+
+// runEvacuation waits for the cell to empty and reports whether the
+// evacuation was cancelled, in which case the evacuator waits for the next one.
+func (e *Evacuator) runEvacuation(logger lager.Logger, signals <-chan os.Signal) bool {
+	cancelNotify := e.evacuationNotifier.CancelNotify()
+
+	e.statusLock.Lock()
+	e.startedAt = e.clock.Now()
+	e.statusLock.Unlock()
 
 	timer := e.clock.NewTimer(e.evacuationTimeout)
 	defer timer.Stop()
 
 	doneCh := make(chan struct{})
-	go e.evacuate(logger, doneCh)
+	stopCh := make(chan struct{})
+	stoppedCh := make(chan struct{})
+	go func() {
+		defer close(stoppedCh)
+		e.evacuate(logger, doneCh, stopCh)
+	}()
 
+	var outcome string
 	select {
 	case <-doneCh:
 		logger.Info("evacuation-complete")
-		return nil
+		outcome = rep.EvacuationOutcomeComplete
 	case <-timer.C():
 		logger.Error("failed-to-evacuate-before-timeout", nil)
-		return nil
+		outcome = rep.EvacuationOutcomeTimedOut
 	case signal := <-signals:
 		logger.Info("signaled", lager.Data{"signal": signal.String()})
-		return nil
+		outcome = rep.EvacuationOutcomeSignaled
+	case <-cancelNotify:
+		logger.Info("evacuation-cancelled")
+		outcome = rep.EvacuationOutcomeCancelled
+	}
+
+	// The scans stop on every path, so that none updates the status once the
+	// outcome is reported.
+	close(stopCh)
+	<-stoppedCh
+
+	e.report(logger, outcome)
+	if outcome != rep.EvacuationOutcomeCancelled {
+		return false
 	}
 
-	return nil
+	e.resetStatus()
+	e.orderer.Reset()
+	return true
+}
+
+// SetPollingInterval changes how often the executor is scanned while
+// evacuating, from the next scan onwards.
+func (e *Evacuator) SetPollingInterval(pollingInterval time.Duration) {
+	e.intervalLock.Lock()
+	defer e.intervalLock.Unlock()
+	e.pollingInterval = pollingInterval
 }
 
-func (e *Evacuator) evacuate(logger lager.Logger, doneCh chan<- struct{}) {
+func (e *Evacuator) currentPollingInterval() time.Duration {
+	e.intervalLock.Lock()
+	defer e.intervalLock.Unlock()
+	return e.pollingInterval
+}
+
+func (e *Evacuator) evacuate(logger lager.Logger, doneCh chan<- struct{}, stopCh <-chan struct{}) {
 	logger = logger.Session("evacuating")
 	logger.Info("started")
 
-	timer := e.clock.NewTimer(e.pollingInterval)
+	timer := e.clock.NewTimer(e.currentPollingInterval())
 	defer timer.Stop()
 
 	for {
 		evacuated := e.allContainersEvacuated(logger)
 
 		if !evacuated {
-			logger.Info("evacuation-incomplete", lager.Data{"polling-interval": e.pollingInterval})
-			timer.Reset(e.pollingInterval)
-			<-timer.C()
+			pollingInterval := e.currentPollingInterval()
+			logger.Info("evacuation-incomplete", lager.Data{"polling-interval": pollingInterval})
+			timer.Reset(pollingInterval)
+			select {
+			case <-timer.C():
+			case <-stopCh:
+				logger.Info("stopped")
+				return
+			}
 			continue
 		}
 
+		e.statusLock.Lock()
+		e.completedAt = e.clock.Now()
+		e.statusLock.Unlock()
+
 		close(doneCh)
 		logger.Info("succeeded")
 
@@ -109,5 +214,228 @@ func (e *Evacuator) allContainersEvacuated(logger lager.Logger) bool {
 		return false
 	}
 
+	e.recordRemaining(containers)
+	e.orderer.Update(logger, containers)
+	logger.Info("evacuation-progress", lager.Data{"progress": e.orderer.Progress()})
+
 	return len(containers) == 0
 }
+
+func (e *Evacuator) resetStatus() {
+	e.statusLock.Lock()
+	defer e.statusLock.Unlock()
+
+	e.startedAt = time.Time{}
+	e.completedAt = time.Time{}
+	e.remaining = map[string]map[string]int{}
+	e.failures = map[string]rep.EvacuationFailure{}
+	e.tracked = map[string]*trackedContainer{}
+}
+
+func (e *Evacuator) recordRemaining(containers []executor.Container) {
+	remaining := map[string]map[string]int{}
+	for i := range containers {
+		lifecycle := containers[i].Tags[rep.LifecycleTag]
+		if remaining[lifecycle] == nil {
+			remaining[lifecycle] = map[string]int{}
+		}
+		remaining[lifecycle][string(containers[i].State)]++
+	}
+
+	e.statusLock.Lock()
+	defer e.statusLock.Unlock()
+
+	e.remaining = remaining
+
+	now := e.clock.Now()
+	present := make(map[string]struct{}, len(containers))
+	for i := range containers {
+		container := &containers[i]
+		present[container.Guid] = struct{}{}
+
+		tracked := e.track(container.Guid)
+		tracked.Lifecycle = container.Tags[rep.LifecycleTag]
+		tracked.Domain = container.Tags[rep.DomainTag]
+		tracked.present = true
+	}
+
+	for guid, tracked := range e.tracked {
+		if _, ok := present[guid]; ok || !tracked.present {
+			continue
+		}
+		tracked.present = false
+		if tracked.FinishedAt.IsZero() {
+			e.finish(tracked, now)
+		}
+	}
+}
+
+// track must be called with the status lock held.
+func (e *Evacuator) track(containerGuid string) *trackedContainer {
+	tracked, ok := e.tracked[containerGuid]
+	if !ok {
+		tracked = &trackedContainer{}
+		tracked.ContainerGuid = containerGuid
+		e.tracked[containerGuid] = tracked
+	}
+	return tracked
+}
+
+// finish must be called with the status lock held.
+func (e *Evacuator) finish(tracked *trackedContainer, finishedAt time.Time) {
+	tracked.FinishedAt = finishedAt
+	tracked.Duration = finishedAt.Sub(e.startedAt)
+}
+
+// RecordEvacuationDisposition records how the container left the cell for
+// the evacuation report.
+func (e *Evacuator) RecordEvacuationDisposition(logger lager.Logger, containerGuid, disposition string) {
+	e.statusLock.Lock()
+	defer e.statusLock.Unlock()
+
+	if e.startedAt.IsZero() {
+		return
+	}
+
+	tracked := e.track(containerGuid)
+	if tracked.Disposition != "" {
+		return
+	}
+
+	tracked.Disposition = disposition
+	e.finish(tracked, e.clock.Now())
+}
+
+func (e *Evacuator) report(logger lager.Logger, outcome string) {
+	report := e.buildReport(outcome)
+
+	logger.Info("evacuation-report", lager.Data{"report": report})
+
+	err := evacuationDuration.Send(report.Duration)
+	if err != nil {
+		logger.Error("failed-to-send-evacuation-duration-metric", err)
+	}
+	for disposition, containerMetric := range evacuationContainerMetrics {
+		err = containerMetric.Send(report.Totals[disposition])
+		if err != nil {
+			logger.Error("failed-to-send-evacuation-container-metric", err, lager.Data{"disposition": disposition})
+		}
+	}
+
+	if e.reportDir == "" {
+		return
+	}
+
+	payload, err := json.Marshal(report)
+	if err != nil {
+		logger.Error("failed-to-marshal-evacuation-report", err)
+		return
+	}
+
+	path := filepath.Join(e.reportDir, fmt.Sprintf("evacuation-report-%s-%d.json", e.cellID, report.StartedAt.Unix()))
+	err = ioutil.WriteFile(path, payload, 0644)
+	if err != nil {
+		logger.Error("failed-to-write-evacuation-report", err, lager.Data{"path": path})
+		return
+	}
+
+	logger.Info("wrote-evacuation-report", lager.Data{"path": path})
+}
+
+func (e *Evacuator) buildReport(outcome string) rep.EvacuationReport {
+	e.statusLock.Lock()
+	defer e.statusLock.Unlock()
+
+	now := e.clock.Now()
+	report := rep.EvacuationReport{
+		CellID:     e.cellID,
+		Outcome:    outcome,
+		StartedAt:  e.startedAt,
+		FinishedAt: now,
+		Duration:   now.Sub(e.startedAt),
+		Containers: make([]rep.EvacuationReportContainer, 0, len(e.tracked)),
+		Totals:     map[string]int{},
+	}
+
+	for _, tracked := range e.tracked {
+		container := tracked.EvacuationReportContainer
+		switch {
+		case container.Disposition != "":
+		case tracked.present:
+			container.Disposition = rep.EvacuationDispositionTimedOut
+			container.FinishedAt = now
+			container.Duration = report.Duration
+		default:
+			container.Disposition = rep.EvacuationDispositionUnknown
+		}
+
+		report.Containers = append(report.Containers, container)
+		report.Totals[container.Disposition]++
+	}
+	sort.Sort(byReportContainerGuid(report.Containers))
+
+	return report
+}
+
+// RecordEvacuationFailure keeps the most recent failed BBS evacuate call for
+// the container so that it can be reported in the evacuation status.
+func (e *Evacuator) RecordEvacuationFailure(logger lager.Logger, containerGuid, operation string, err error) {
+	e.statusLock.Lock()
+	defer e.statusLock.Unlock()
+
+	e.failures[containerGuid] = rep.NewEvacuationFailure(containerGuid, operation, err, e.clock.Now())
+}
+
+func (e *Evacuator) ClearEvacuationFailure(containerGuid string) {
+	e.statusLock.Lock()
+	defer e.statusLock.Unlock()
+
+	delete(e.failures, containerGuid)
+}
+
+func (e *Evacuator) EvacuationStatus() rep.EvacuationStatus {
+	e.statusLock.Lock()
+	defer e.statusLock.Unlock()
+
+	status := rep.EvacuationStatus{
+		Evacuating:          !e.startedAt.IsZero(),
+		Complete:            !e.completedAt.IsZero(),
+		StartedAt:           e.startedAt,
+		Timeout:             e.evacuationTimeout,
+		RemainingContainers: make(map[string]map[string]int, len(e.remaining)),
+		FailedContainers:    make([]rep.EvacuationFailure, 0, len(e.failures)),
+	}
+
+	switch {
+	case status.Complete:
+		status.Elapsed = e.completedAt.Sub(e.startedAt)
+	case status.Evacuating:
+		status.Elapsed = e.clock.Since(e.startedAt)
+	}
+
+	for lifecycle, states := range e.remaining {
+		status.RemainingContainers[lifecycle] = make(map[string]int, len(states))
+		for state, count := range states {
+			status.RemainingContainers[lifecycle][state] = count
+		}
+	}
+
+	for _, failure := range e.failures {
+		status.FailedContainers = append(status.FailedContainers, failure)
+	}
+	sort.Sort(byContainerGuid(status.FailedContainers))
+
+	return status
+}
+
+type byContainerGuid []rep.EvacuationFailure
+
+func (f byContainerGuid) Len() int           { return len(f) }
+func (f byContainerGuid) Less(i, j int) bool { return f[i].ContainerGuid < f[j].ContainerGuid }
+func (f byContainerGuid) Swap(i, j int)      { f[i], f[j] = f[j], f[i] }
+
+type byReportContainerGuid []rep.EvacuationReportContainer
+
+func (c byReportContainerGuid) Len() int           { return len(c) }
+func (c byReportContainerGuid) Less(i, j int) bool { return c[i].ContainerGuid < c[j].ContainerGuid }
+func (c byReportContainerGuid) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
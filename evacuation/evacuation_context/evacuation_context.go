@@ -1,10 +1,18 @@
 package evacuation_context
 
-import "sync"
+import (
+	"sync"
+
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep"
+)
 
 //go:generate counterfeiter -o fake_evacuation_context/fake_evacuatable.go . Evacuatable
 type Evacuatable interface {
 	Evacuate()
+	// CancelEvacuation stops an evacuation in progress and reports whether
+	// there was one to stop.
+	CancelEvacuation() bool
 }
 
 //go:generate counterfeiter -o fake_evacuation_context/fake_evacuation_reporter.go . EvacuationReporter
@@ -14,17 +22,44 @@ type EvacuationReporter interface {
 
 //go:generate counterfeiter -o fake_evacuation_context/fake_evacuation_notifier.go . EvacuationNotifier
 type EvacuationNotifier interface {
+	// EvacuateNotify returns a channel that is closed when the cell starts
+	// evacuating. A new channel is handed out once an evacuation is cancelled.
 	EvacuateNotify() <-chan struct{}
+	// CancelNotify returns a channel that is closed when the current
+	// evacuation is cancelled.
+	CancelNotify() <-chan struct{}
+}
+
+//go:generate counterfeiter -o fake_evacuation_context/fake_evacuation_failure_recorder.go . EvacuationFailureRecorder
+type EvacuationFailureRecorder interface {
+	RecordEvacuationFailure(logger lager.Logger, containerGuid, operation string, err error)
+	ClearEvacuationFailure(containerGuid string)
+}
+
+//go:generate counterfeiter -o fake_evacuation_context/fake_evacuation_disposition_recorder.go . EvacuationDispositionRecorder
+
+// EvacuationDispositionRecorder records how a container left an evacuating
+// cell, using one of the rep.EvacuationDisposition values. Calls made while
+// the cell is not evacuating are ignored.
+type EvacuationDispositionRecorder interface {
+	RecordEvacuationDisposition(logger lager.Logger, containerGuid, disposition string)
+}
+
+//go:generate counterfeiter -o fake_evacuation_context/fake_evacuation_status_reporter.go . EvacuationStatusReporter
+type EvacuationStatusReporter interface {
+	EvacuationStatus() rep.EvacuationStatus
 }
 
 type evacuationContext struct {
 	evacuated chan struct{}
+	cancelled chan struct{}
 	mu        sync.Mutex
 }
 
 func New() (Evacuatable, EvacuationReporter, EvacuationNotifier) {
 	evacuationContext := &evacuationContext{
 		evacuated: make(chan struct{}),
+		cancelled: make(chan struct{}),
 	}
 
 	return evacuationContext, evacuationContext, evacuationContext
@@ -37,11 +72,29 @@ func (e *evacuationContext) Evacuate() {
 	select {
 	case <-e.evacuated:
 	default:
+		e.cancelled = make(chan struct{})
 		close(e.evacuated)
 	}
 }
 
+func (e *evacuationContext) CancelEvacuation() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	select {
+	case <-e.evacuated:
+		e.evacuated = make(chan struct{})
+		close(e.cancelled)
+		return true
+	default:
+		return false
+	}
+}
+
 func (e *evacuationContext) Evacuating() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
 	select {
 	case <-e.evacuated:
 		return true
@@ -51,5 +104,15 @@ func (e *evacuationContext) Evacuating() bool {
 }
 
 func (e *evacuationContext) EvacuateNotify() <-chan struct{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
 	return e.evacuated
 }
+
+func (e *evacuationContext) CancelNotify() <-chan struct{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.cancelled
+}
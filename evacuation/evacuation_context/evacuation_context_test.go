@@ -47,6 +47,40 @@ var _ = Describe("EvacuationContext", func() {
 			})
 		})
 
+		Context("when the evacuation is cancelled", func() {
+			It("reports whether there was an evacuation to cancel", func() {
+				Expect(evacuatable.CancelEvacuation()).To(BeFalse())
+				evacuatable.Evacuate()
+				Expect(evacuatable.CancelEvacuation()).To(BeTrue())
+				Expect(evacuatable.CancelEvacuation()).To(BeFalse())
+			})
+
+			It("makes the evacuation reporter return false for Evacuating", func() {
+				evacuatable.Evacuate()
+				evacuatable.CancelEvacuation()
+				Expect(evacuationReporter.Evacuating()).To(BeFalse())
+			})
+
+			It("closes the channel provided by CancelNotify", func() {
+				evacuatable.Evacuate()
+				cancelNotify := evacuationNotifier.CancelNotify()
+				Consistently(cancelNotify).ShouldNot(BeClosed())
+				evacuatable.CancelEvacuation()
+				Eventually(cancelNotify).Should(BeClosed())
+			})
+
+			It("hands out a new evacuation channel", func() {
+				evacuatable.Evacuate()
+				evacuatable.CancelEvacuation()
+
+				evacuateNotify := evacuationNotifier.EvacuateNotify()
+				Consistently(evacuateNotify).ShouldNot(BeClosed())
+				evacuatable.Evacuate()
+				Eventually(evacuateNotify).Should(BeClosed())
+				Expect(evacuationReporter.Evacuating()).To(BeTrue())
+			})
+		})
+
 		Context("when Evacuate is called repeatedly", func() {
 			It("does not panic", func() {
 				defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(runtime.NumCPU()))
@@ -8,9 +8,15 @@ import (
 )
 
 type FakeEvacuatable struct {
-	EvacuateStub        func()
-	evacuateMutex       sync.RWMutex
-	evacuateArgsForCall []struct{}
+	EvacuateStub                func()
+	evacuateMutex               sync.RWMutex
+	evacuateArgsForCall         []struct{}
+	CancelEvacuationStub        func() bool
+	cancelEvacuationMutex       sync.RWMutex
+	cancelEvacuationArgsForCall []struct{}
+	cancelEvacuationReturns     struct {
+		result1 bool
+	}
 }
 
 func (fake *FakeEvacuatable) Evacuate() {
@@ -28,4 +34,28 @@ func (fake *FakeEvacuatable) EvacuateCallCount() int {
 	return len(fake.evacuateArgsForCall)
 }
 
+func (fake *FakeEvacuatable) CancelEvacuation() bool {
+	fake.cancelEvacuationMutex.Lock()
+	fake.cancelEvacuationArgsForCall = append(fake.cancelEvacuationArgsForCall, struct{}{})
+	fake.cancelEvacuationMutex.Unlock()
+	if fake.CancelEvacuationStub != nil {
+		return fake.CancelEvacuationStub()
+	} else {
+		return fake.cancelEvacuationReturns.result1
+	}
+}
+
+func (fake *FakeEvacuatable) CancelEvacuationCallCount() int {
+	fake.cancelEvacuationMutex.RLock()
+	defer fake.cancelEvacuationMutex.RUnlock()
+	return len(fake.cancelEvacuationArgsForCall)
+}
+
+func (fake *FakeEvacuatable) CancelEvacuationReturns(result1 bool) {
+	fake.CancelEvacuationStub = nil
+	fake.cancelEvacuationReturns = struct {
+		result1 bool
+	}{result1}
+}
+
 var _ evacuation_context.Evacuatable = new(FakeEvacuatable)
@@ -0,0 +1,46 @@
+// This file was generated by counterfeiter
+package fake_evacuation_context
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep/evacuation/evacuation_context"
+)
+
+type FakeEvacuationDispositionRecorder struct {
+	RecordEvacuationDispositionStub        func(logger lager.Logger, containerGuid string, disposition string)
+	recordEvacuationDispositionMutex       sync.RWMutex
+	recordEvacuationDispositionArgsForCall []struct {
+		logger        lager.Logger
+		containerGuid string
+		disposition   string
+	}
+}
+
+func (fake *FakeEvacuationDispositionRecorder) RecordEvacuationDisposition(logger lager.Logger, containerGuid string, disposition string) {
+	fake.recordEvacuationDispositionMutex.Lock()
+	fake.recordEvacuationDispositionArgsForCall = append(fake.recordEvacuationDispositionArgsForCall, struct {
+		logger        lager.Logger
+		containerGuid string
+		disposition   string
+	}{logger, containerGuid, disposition})
+	fake.recordEvacuationDispositionMutex.Unlock()
+	if fake.RecordEvacuationDispositionStub != nil {
+		fake.RecordEvacuationDispositionStub(logger, containerGuid, disposition)
+	}
+}
+
+func (fake *FakeEvacuationDispositionRecorder) RecordEvacuationDispositionCallCount() int {
+	fake.recordEvacuationDispositionMutex.RLock()
+	defer fake.recordEvacuationDispositionMutex.RUnlock()
+	return len(fake.recordEvacuationDispositionArgsForCall)
+}
+
+func (fake *FakeEvacuationDispositionRecorder) RecordEvacuationDispositionArgsForCall(i int) (lager.Logger, string, string) {
+	fake.recordEvacuationDispositionMutex.RLock()
+	defer fake.recordEvacuationDispositionMutex.RUnlock()
+	return fake.recordEvacuationDispositionArgsForCall[i].logger, fake.recordEvacuationDispositionArgsForCall[i].containerGuid, fake.recordEvacuationDispositionArgsForCall[i].disposition
+}
+
+var _ evacuation_context.EvacuationDispositionRecorder = new(FakeEvacuationDispositionRecorder)
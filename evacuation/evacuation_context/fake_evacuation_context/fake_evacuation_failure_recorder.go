@@ -0,0 +1,76 @@
+// This file was generated by counterfeiter
+package fake_evacuation_context
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep/evacuation/evacuation_context"
+)
+
+type FakeEvacuationFailureRecorder struct {
+	RecordEvacuationFailureStub        func(logger lager.Logger, containerGuid string, operation string, err error)
+	recordEvacuationFailureMutex       sync.RWMutex
+	recordEvacuationFailureArgsForCall []struct {
+		logger        lager.Logger
+		containerGuid string
+		operation     string
+		err           error
+	}
+	ClearEvacuationFailureStub        func(containerGuid string)
+	clearEvacuationFailureMutex       sync.RWMutex
+	clearEvacuationFailureArgsForCall []struct {
+		containerGuid string
+	}
+}
+
+func (fake *FakeEvacuationFailureRecorder) RecordEvacuationFailure(logger lager.Logger, containerGuid string, operation string, err error) {
+	fake.recordEvacuationFailureMutex.Lock()
+	fake.recordEvacuationFailureArgsForCall = append(fake.recordEvacuationFailureArgsForCall, struct {
+		logger        lager.Logger
+		containerGuid string
+		operation     string
+		err           error
+	}{logger, containerGuid, operation, err})
+	fake.recordEvacuationFailureMutex.Unlock()
+	if fake.RecordEvacuationFailureStub != nil {
+		fake.RecordEvacuationFailureStub(logger, containerGuid, operation, err)
+	}
+}
+
+func (fake *FakeEvacuationFailureRecorder) RecordEvacuationFailureCallCount() int {
+	fake.recordEvacuationFailureMutex.RLock()
+	defer fake.recordEvacuationFailureMutex.RUnlock()
+	return len(fake.recordEvacuationFailureArgsForCall)
+}
+
+func (fake *FakeEvacuationFailureRecorder) RecordEvacuationFailureArgsForCall(i int) (lager.Logger, string, string, error) {
+	fake.recordEvacuationFailureMutex.RLock()
+	defer fake.recordEvacuationFailureMutex.RUnlock()
+	return fake.recordEvacuationFailureArgsForCall[i].logger, fake.recordEvacuationFailureArgsForCall[i].containerGuid, fake.recordEvacuationFailureArgsForCall[i].operation, fake.recordEvacuationFailureArgsForCall[i].err
+}
+
+func (fake *FakeEvacuationFailureRecorder) ClearEvacuationFailure(containerGuid string) {
+	fake.clearEvacuationFailureMutex.Lock()
+	fake.clearEvacuationFailureArgsForCall = append(fake.clearEvacuationFailureArgsForCall, struct {
+		containerGuid string
+	}{containerGuid})
+	fake.clearEvacuationFailureMutex.Unlock()
+	if fake.ClearEvacuationFailureStub != nil {
+		fake.ClearEvacuationFailureStub(containerGuid)
+	}
+}
+
+func (fake *FakeEvacuationFailureRecorder) ClearEvacuationFailureCallCount() int {
+	fake.clearEvacuationFailureMutex.RLock()
+	defer fake.clearEvacuationFailureMutex.RUnlock()
+	return len(fake.clearEvacuationFailureArgsForCall)
+}
+
+func (fake *FakeEvacuationFailureRecorder) ClearEvacuationFailureArgsForCall(i int) string {
+	fake.clearEvacuationFailureMutex.RLock()
+	defer fake.clearEvacuationFailureMutex.RUnlock()
+	return fake.clearEvacuationFailureArgsForCall[i].containerGuid
+}
+
+var _ evacuation_context.EvacuationFailureRecorder = new(FakeEvacuationFailureRecorder)
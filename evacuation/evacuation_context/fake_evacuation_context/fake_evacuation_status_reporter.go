@@ -0,0 +1,44 @@
+// This file was generated by counterfeiter
+package fake_evacuation_context
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/evacuation/evacuation_context"
+)
+
+type FakeEvacuationStatusReporter struct {
+	EvacuationStatusStub        func() rep.EvacuationStatus
+	evacuationStatusMutex       sync.RWMutex
+	evacuationStatusArgsForCall []struct{}
+	evacuationStatusReturns     struct {
+		result1 rep.EvacuationStatus
+	}
+}
+
+func (fake *FakeEvacuationStatusReporter) EvacuationStatus() rep.EvacuationStatus {
+	fake.evacuationStatusMutex.Lock()
+	fake.evacuationStatusArgsForCall = append(fake.evacuationStatusArgsForCall, struct{}{})
+	fake.evacuationStatusMutex.Unlock()
+	if fake.EvacuationStatusStub != nil {
+		return fake.EvacuationStatusStub()
+	} else {
+		return fake.evacuationStatusReturns.result1
+	}
+}
+
+func (fake *FakeEvacuationStatusReporter) EvacuationStatusCallCount() int {
+	fake.evacuationStatusMutex.RLock()
+	defer fake.evacuationStatusMutex.RUnlock()
+	return len(fake.evacuationStatusArgsForCall)
+}
+
+func (fake *FakeEvacuationStatusReporter) EvacuationStatusReturns(result1 rep.EvacuationStatus) {
+	fake.EvacuationStatusStub = nil
+	fake.evacuationStatusReturns = struct {
+		result1 rep.EvacuationStatus
+	}{result1}
+}
+
+var _ evacuation_context.EvacuationStatusReporter = new(FakeEvacuationStatusReporter)
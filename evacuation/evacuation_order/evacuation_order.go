@@ -0,0 +1,308 @@
+// evacuation_order decides the order in which containers leave an evacuating cell
+package evacuation_order
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/executor"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep"
+)
+
+const (
+	PhaseTasks    = "tasks"
+	PhaseLRPs     = "lrps"
+	PhaseComplete = "complete"
+
+	DefaultMaxTaskWait = 5 * time.Minute
+)
+
+type Config struct {
+	// TaskDomains lists the domains whose tasks must finish before any LRP
+	// is handed off.
+	TaskDomains []string
+	// MaxTaskWait bounds how long LRPs are held back for those tasks. Once it
+	// elapses LRPs are handed off even though tasks are still running. Zero
+	// uses DefaultMaxTaskWait.
+	MaxTaskWait time.Duration
+	// LRPDomains orders the hand-off of LRPs by domain. LRPs in earlier
+	// domains leave first and LRPs in unlisted domains leave last. An
+	// EvacuationPriorityTag on the container takes precedence over its domain.
+	LRPDomains []string
+	// MaxConcurrentPerProcess caps the number of instances of a process that
+	// may be handed off at once. Zero means unlimited.
+	MaxConcurrentPerProcess int
+}
+
+type PhaseProgress struct {
+	Phase      string `json:"phase"`
+	Total      int    `json:"total"`
+	Remaining  int    `json:"remaining"`
+	Evacuating int    `json:"evacuating"`
+}
+
+type Progress struct {
+	CurrentPhase string          `json:"current_phase"`
+	Phases       []PhaseProgress `json:"phases"`
+}
+
+//go:generate counterfeiter -o fake_evacuation_order/fake_orderer.go . Orderer
+
+// Orderer plans evacuation from the containers on the cell. The plan is
+// refreshed by Update and consulted by AdmitLRP before a running LRP is handed
+// off to another cell. Reset discards the plan when an evacuation is cancelled.
+type Orderer interface {
+	Update(logger lager.Logger, containers []executor.Container)
+	AdmitLRP(logger lager.Logger, container executor.Container) bool
+	Progress() Progress
+	Reset()
+}
+
+type rank struct {
+	priority    int
+	domainIndex int
+}
+
+// pendingLRP is a running LRP that has not been admitted for evacuation yet.
+type pendingLRP struct {
+	rank        rank
+	processGuid string
+}
+
+func (r rank) before(other rank) bool {
+	if r.priority != other.priority {
+		return r.priority > other.priority
+	}
+	return r.domainIndex < other.domainIndex
+}
+
+type orderer struct {
+	config      Config
+	clock       clock.Clock
+	taskDomains map[string]struct{}
+	lrpDomains  map[string]int
+
+	lock         sync.Mutex
+	updated      bool
+	plannedAt    time.Time
+	taskWaitOver bool
+	pendingTasks int
+	pendingLRPs  map[string]pendingLRP
+	admitted     map[string]string
+	seenTasks    map[string]struct{}
+	seenLRPs     map[string]struct{}
+}
+
+func New(config Config, clock clock.Clock) Orderer {
+	if config.MaxTaskWait <= 0 {
+		config.MaxTaskWait = DefaultMaxTaskWait
+	}
+
+	taskDomains := make(map[string]struct{}, len(config.TaskDomains))
+	for _, domain := range config.TaskDomains {
+		taskDomains[domain] = struct{}{}
+	}
+
+	lrpDomains := make(map[string]int, len(config.LRPDomains))
+	for i, domain := range config.LRPDomains {
+		lrpDomains[domain] = i
+	}
+
+	return &orderer{
+		config:      config,
+		clock:       clock,
+		taskDomains: taskDomains,
+		lrpDomains:  lrpDomains,
+		pendingLRPs: map[string]pendingLRP{},
+		admitted:    map[string]string{},
+		seenTasks:   map[string]struct{}{},
+		seenLRPs:    map[string]struct{}{},
+	}
+}
+
+func (o *orderer) Update(logger lager.Logger, containers []executor.Container) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if !o.updated {
+		o.plannedAt = o.clock.Now()
+	}
+	o.updated = true
+	o.pendingTasks = 0
+	o.pendingLRPs = map[string]pendingLRP{}
+	present := make(map[string]struct{}, len(containers))
+
+	for i := range containers {
+		container := &containers[i]
+		present[container.Guid] = struct{}{}
+
+		switch container.Tags[rep.LifecycleTag] {
+		case rep.TaskLifecycle:
+			if _, ok := o.taskDomains[container.Tags[rep.DomainTag]]; !ok {
+				continue
+			}
+			o.seenTasks[container.Guid] = struct{}{}
+			if container.State != executor.StateCompleted {
+				o.pendingTasks++
+			}
+		case rep.LRPLifecycle:
+			if container.State != executor.StateRunning {
+				continue
+			}
+			o.seenLRPs[container.Guid] = struct{}{}
+			if _, ok := o.admitted[container.Guid]; !ok {
+				o.pendingLRPs[container.Guid] = pendingLRP{
+					rank:        o.rank(logger, container),
+					processGuid: container.Tags[rep.ProcessGuidTag],
+				}
+			}
+		}
+	}
+
+	for guid := range o.admitted {
+		if _, ok := present[guid]; !ok {
+			delete(o.admitted, guid)
+		}
+	}
+}
+
+func (o *orderer) AdmitLRP(logger lager.Logger, container executor.Container) bool {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if _, ok := o.admitted[container.Guid]; ok {
+		return true
+	}
+
+	if !o.updated {
+		logger.Info("deferring-evacuation-until-planned")
+		return false
+	}
+
+	if o.pendingTasks > 0 {
+		if !o.taskWaitElapsed() {
+			logger.Info("deferring-evacuation-for-tasks", lager.Data{"pending-tasks": o.pendingTasks})
+			return false
+		}
+
+		if !o.taskWaitOver {
+			o.taskWaitOver = true
+			logger.Info("task-wait-elapsed", lager.Data{"pending-tasks": o.pendingTasks, "max-task-wait": o.config.MaxTaskWait.String()})
+		}
+	}
+
+	evacuating := o.evacuatingPerProcess()
+
+	// An LRP held back by the limit of its own process does not hold back the
+	// lower ranked LRPs of other processes.
+	containerRank := o.rank(logger, &container)
+	for guid, pending := range o.pendingLRPs {
+		if guid == container.Guid || o.atProcessLimit(evacuating[pending.processGuid]) {
+			continue
+		}
+		if pending.rank.before(containerRank) {
+			logger.Info("deferring-evacuation-for-higher-priority-lrps")
+			return false
+		}
+	}
+
+	processGuid := container.Tags[rep.ProcessGuidTag]
+	if o.atProcessLimit(evacuating[processGuid]) {
+		logger.Info("deferring-evacuation-for-process-limit", lager.Data{"process-guid": processGuid, "evacuating": evacuating[processGuid]})
+		return false
+	}
+
+	o.admitted[container.Guid] = processGuid
+	delete(o.pendingLRPs, container.Guid)
+	return true
+}
+
+// evacuatingPerProcess counts the admitted LRPs of each process. It must be
+// called with the lock held.
+func (o *orderer) evacuatingPerProcess() map[string]int {
+	evacuating := map[string]int{}
+	for _, processGuid := range o.admitted {
+		evacuating[processGuid]++
+	}
+	return evacuating
+}
+
+func (o *orderer) atProcessLimit(evacuating int) bool {
+	return o.config.MaxConcurrentPerProcess > 0 && evacuating >= o.config.MaxConcurrentPerProcess
+}
+
+func (o *orderer) Reset() {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.updated = false
+	o.taskWaitOver = false
+	o.pendingTasks = 0
+	o.pendingLRPs = map[string]pendingLRP{}
+	o.admitted = map[string]string{}
+	o.seenTasks = map[string]struct{}{}
+	o.seenLRPs = map[string]struct{}{}
+}
+
+func (o *orderer) Progress() Progress {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	tasks := PhaseProgress{
+		Phase:     PhaseTasks,
+		Total:     len(o.seenTasks),
+		Remaining: o.pendingTasks,
+	}
+
+	lrps := PhaseProgress{
+		Phase:      PhaseLRPs,
+		Total:      len(o.seenLRPs),
+		Remaining:  len(o.pendingLRPs) + len(o.admitted),
+		Evacuating: len(o.admitted),
+	}
+
+	var currentPhase string
+	switch {
+	case !o.updated:
+	case tasks.Remaining > 0 && !o.taskWaitElapsed():
+		currentPhase = PhaseTasks
+	case lrps.Remaining > 0:
+		currentPhase = PhaseLRPs
+	case tasks.Remaining > 0:
+		currentPhase = PhaseTasks
+	default:
+		currentPhase = PhaseComplete
+	}
+
+	return Progress{
+		CurrentPhase: currentPhase,
+		Phases:       []PhaseProgress{tasks, lrps},
+	}
+}
+
+// taskWaitElapsed must be called with the lock held.
+func (o *orderer) taskWaitElapsed() bool {
+	return o.clock.Since(o.plannedAt) >= o.config.MaxTaskWait
+}
+
+func (o *orderer) rank(logger lager.Logger, container *executor.Container) rank {
+	domainIndex, ok := o.lrpDomains[container.Tags[rep.DomainTag]]
+	if !ok {
+		domainIndex = len(o.config.LRPDomains)
+	}
+
+	priority := 0
+	if value, ok := container.Tags[rep.EvacuationPriorityTag]; ok {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			logger.Error("invalid-evacuation-priority", err, lager.Data{"container-guid": container.Guid, "priority": value})
+		} else {
+			priority = parsed
+		}
+	}
+
+	return rank{priority: priority, domainIndex: domainIndex}
+}
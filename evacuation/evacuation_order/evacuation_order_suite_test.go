@@ -0,0 +1,13 @@
+package evacuation_order_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestEvacuationOrder(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "EvacuationOrder Suite")
+}
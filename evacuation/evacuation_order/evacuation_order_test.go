@@ -0,0 +1,248 @@
+package evacuation_order_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/bbs/models/test/model_helpers"
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/executor"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/evacuation/evacuation_order"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Orderer", func() {
+	var (
+		logger    *lagertest.TestLogger
+		fakeClock *fakeclock.FakeClock
+		config    evacuation_order.Config
+		orderer   evacuation_order.Orderer
+	)
+
+	lrpContainer := func(guid, processGuid, domain string) executor.Container {
+		return executor.Container{
+			Guid:  guid,
+			State: executor.StateRunning,
+			Tags: executor.Tags{
+				rep.LifecycleTag:   rep.LRPLifecycle,
+				rep.ProcessGuidTag: processGuid,
+				rep.DomainTag:      domain,
+			},
+		}
+	}
+
+	taskContainer := func(guid, domain string, state executor.State) executor.Container {
+		return executor.Container{
+			Guid:  guid,
+			State: state,
+			Tags: executor.Tags{
+				rep.LifecycleTag: rep.TaskLifecycle,
+				rep.DomainTag:    domain,
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		config = evacuation_order.Config{}
+	})
+
+	JustBeforeEach(func() {
+		orderer = evacuation_order.New(config, fakeClock)
+	})
+
+	It("defers LRPs until the plan has been updated", func() {
+		lrp := lrpContainer("lrp-1", "process-1", "domain")
+		Expect(orderer.AdmitLRP(logger, lrp)).To(BeFalse())
+
+		orderer.Update(logger, []executor.Container{lrp})
+		Expect(orderer.AdmitLRP(logger, lrp)).To(BeTrue())
+	})
+
+	Context("when task domains are configured", func() {
+		BeforeEach(func() {
+			config.TaskDomains = []string{"priority-tasks"}
+		})
+
+		It("defers LRPs until the tasks in those domains complete", func() {
+			lrp := lrpContainer("lrp-1", "process-1", "domain")
+			orderer.Update(logger, []executor.Container{
+				lrp,
+				taskContainer("task-1", "priority-tasks", executor.StateRunning),
+				taskContainer("task-2", "other-tasks", executor.StateRunning),
+			})
+			Expect(orderer.AdmitLRP(logger, lrp)).To(BeFalse())
+			Expect(orderer.Progress().CurrentPhase).To(Equal(evacuation_order.PhaseTasks))
+
+			orderer.Update(logger, []executor.Container{
+				lrp,
+				taskContainer("task-1", "priority-tasks", executor.StateCompleted),
+				taskContainer("task-2", "other-tasks", executor.StateRunning),
+			})
+			Expect(orderer.AdmitLRP(logger, lrp)).To(BeTrue())
+			Expect(orderer.Progress().CurrentPhase).To(Equal(evacuation_order.PhaseLRPs))
+		})
+
+		Context("when the tasks outlast the maximum task wait", func() {
+			BeforeEach(func() {
+				config.MaxTaskWait = time.Minute
+			})
+
+			It("admits LRPs anyway", func() {
+				lrp := lrpContainer("lrp-1", "process-1", "domain")
+				containers := []executor.Container{
+					lrp,
+					taskContainer("task-1", "priority-tasks", executor.StateRunning),
+				}
+
+				orderer.Update(logger, containers)
+				Expect(orderer.AdmitLRP(logger, lrp)).To(BeFalse())
+
+				fakeClock.Increment(time.Minute)
+				orderer.Update(logger, containers)
+				Expect(orderer.AdmitLRP(logger, lrp)).To(BeTrue())
+				Expect(orderer.Progress().CurrentPhase).To(Equal(evacuation_order.PhaseLRPs))
+			})
+		})
+	})
+
+	Context("when LRP domains are configured", func() {
+		BeforeEach(func() {
+			config.LRPDomains = []string{"first", "second"}
+		})
+
+		It("admits LRPs in domain order, with unlisted domains last", func() {
+			first := lrpContainer("lrp-1", "process-1", "first")
+			second := lrpContainer("lrp-2", "process-2", "second")
+			unlisted := lrpContainer("lrp-3", "process-3", "unlisted")
+			orderer.Update(logger, []executor.Container{unlisted, second, first})
+
+			Expect(orderer.AdmitLRP(logger, second)).To(BeFalse())
+			Expect(orderer.AdmitLRP(logger, unlisted)).To(BeFalse())
+
+			Expect(orderer.AdmitLRP(logger, first)).To(BeTrue())
+			Expect(orderer.AdmitLRP(logger, unlisted)).To(BeFalse())
+			Expect(orderer.AdmitLRP(logger, second)).To(BeTrue())
+			Expect(orderer.AdmitLRP(logger, unlisted)).To(BeTrue())
+		})
+
+		It("prefers the evacuation priority tag over the domain", func() {
+			first := lrpContainer("lrp-1", "process-1", "first")
+			urgent := lrpContainer("lrp-2", "process-2", "second")
+			urgent.Tags[rep.EvacuationPriorityTag] = "10"
+			orderer.Update(logger, []executor.Container{first, urgent})
+
+			Expect(orderer.AdmitLRP(logger, first)).To(BeFalse())
+			Expect(orderer.AdmitLRP(logger, urgent)).To(BeTrue())
+			Expect(orderer.AdmitLRP(logger, first)).To(BeTrue())
+		})
+
+		It("takes the priority from the desired LRP the container runs", func() {
+			first := lrpContainer("lrp-1", "process-1", "first")
+			urgent := lrpContainer("lrp-2", "process-2", "second")
+
+			desiredLRP := model_helpers.NewValidDesiredLRP("process-2")
+			desiredLRP.RootFs = "preloaded://linux"
+			desiredLRP.EnvironmentVariables = append(desiredLRP.EnvironmentVariables, &models.EnvironmentVariable{Name: rep.EvacuationPriorityEnv, Value: "10"})
+			lrpKey := models.NewActualLRPKey("process-2", 0, "second")
+			instanceKey := models.NewActualLRPInstanceKey("instance-guid", "cell-id")
+			runReq, err := rep.NewRunRequestFromDesiredLRP(urgent.Guid, desiredLRP, &lrpKey, &instanceKey)
+			Expect(err).NotTo(HaveOccurred())
+
+			// the executor adds the tags of the run request to those of the allocation
+			for name, value := range runReq.Tags {
+				urgent.Tags[name] = value
+			}
+
+			orderer.Update(logger, []executor.Container{first, urgent})
+			Expect(orderer.AdmitLRP(logger, first)).To(BeFalse())
+			Expect(orderer.AdmitLRP(logger, urgent)).To(BeTrue())
+		})
+	})
+
+	Context("when the concurrent evacuations per process are capped", func() {
+		BeforeEach(func() {
+			config.MaxConcurrentPerProcess = 1
+		})
+
+		It("admits another instance only once the previous one has left the cell", func() {
+			instanceOne := lrpContainer("lrp-1", "process-1", "domain")
+			instanceTwo := lrpContainer("lrp-2", "process-1", "domain")
+			otherProcess := lrpContainer("lrp-3", "process-2", "domain")
+			orderer.Update(logger, []executor.Container{instanceOne, instanceTwo, otherProcess})
+
+			Expect(orderer.AdmitLRP(logger, instanceOne)).To(BeTrue())
+			Expect(orderer.AdmitLRP(logger, instanceOne)).To(BeTrue())
+			Expect(orderer.AdmitLRP(logger, instanceTwo)).To(BeFalse())
+			Expect(orderer.AdmitLRP(logger, otherProcess)).To(BeTrue())
+
+			orderer.Update(logger, []executor.Container{instanceTwo, otherProcess})
+			Expect(orderer.AdmitLRP(logger, instanceTwo)).To(BeTrue())
+		})
+
+		Context("when a higher ranked process is at its limit", func() {
+			BeforeEach(func() {
+				config.LRPDomains = []string{"first", "second"}
+			})
+
+			It("still admits the lower ranked LRPs of other processes", func() {
+				instanceOne := lrpContainer("lrp-1", "process-1", "first")
+				instanceTwo := lrpContainer("lrp-2", "process-1", "first")
+				otherProcess := lrpContainer("lrp-3", "process-2", "second")
+				orderer.Update(logger, []executor.Container{instanceOne, instanceTwo, otherProcess})
+
+				Expect(orderer.AdmitLRP(logger, otherProcess)).To(BeFalse())
+				Expect(orderer.AdmitLRP(logger, instanceOne)).To(BeTrue())
+				Expect(orderer.AdmitLRP(logger, instanceTwo)).To(BeFalse())
+				Expect(orderer.AdmitLRP(logger, otherProcess)).To(BeTrue())
+			})
+		})
+	})
+
+	Describe("Reset", func() {
+		It("discards the plan", func() {
+			lrp := lrpContainer("lrp-1", "process-1", "domain")
+			orderer.Update(logger, []executor.Container{lrp})
+			Expect(orderer.AdmitLRP(logger, lrp)).To(BeTrue())
+
+			orderer.Reset()
+			Expect(orderer.AdmitLRP(logger, lrp)).To(BeFalse())
+			Expect(orderer.Progress().CurrentPhase).To(BeEmpty())
+		})
+	})
+
+	Describe("Progress", func() {
+		BeforeEach(func() {
+			config.TaskDomains = []string{"priority-tasks"}
+		})
+
+		It("reports the progress of each phase", func() {
+			lrpOne := lrpContainer("lrp-1", "process-1", "domain")
+			lrpTwo := lrpContainer("lrp-2", "process-2", "domain")
+			orderer.Update(logger, []executor.Container{
+				lrpOne,
+				lrpTwo,
+				taskContainer("task-1", "priority-tasks", executor.StateCompleted),
+			})
+			Expect(orderer.AdmitLRP(logger, lrpOne)).To(BeTrue())
+
+			Expect(orderer.Progress()).To(Equal(evacuation_order.Progress{
+				CurrentPhase: evacuation_order.PhaseLRPs,
+				Phases: []evacuation_order.PhaseProgress{
+					{Phase: evacuation_order.PhaseTasks, Total: 1, Remaining: 0},
+					{Phase: evacuation_order.PhaseLRPs, Total: 2, Remaining: 2, Evacuating: 1},
+				},
+			}))
+
+			orderer.Update(logger, []executor.Container{})
+			progress := orderer.Progress()
+			Expect(progress.CurrentPhase).To(Equal(evacuation_order.PhaseComplete))
+			Expect(progress.Phases[1]).To(Equal(evacuation_order.PhaseProgress{Phase: evacuation_order.PhaseLRPs, Total: 2}))
+		})
+	})
+})
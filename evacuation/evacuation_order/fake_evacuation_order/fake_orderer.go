@@ -0,0 +1,135 @@
+// This file was generated by counterfeiter
+package fake_evacuation_order
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/executor"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep/evacuation/evacuation_order"
+)
+
+type FakeOrderer struct {
+	UpdateStub        func(logger lager.Logger, containers []executor.Container)
+	updateMutex       sync.RWMutex
+	updateArgsForCall []struct {
+		logger     lager.Logger
+		containers []executor.Container
+	}
+	AdmitLRPStub        func(logger lager.Logger, container executor.Container) bool
+	admitLRPMutex       sync.RWMutex
+	admitLRPArgsForCall []struct {
+		logger    lager.Logger
+		container executor.Container
+	}
+	admitLRPReturns struct {
+		result1 bool
+	}
+	ProgressStub        func() evacuation_order.Progress
+	progressMutex       sync.RWMutex
+	progressArgsForCall []struct{}
+	progressReturns     struct {
+		result1 evacuation_order.Progress
+	}
+	ResetStub        func()
+	resetMutex       sync.RWMutex
+	resetArgsForCall []struct{}
+}
+
+func (fake *FakeOrderer) Update(logger lager.Logger, containers []executor.Container) {
+	fake.updateMutex.Lock()
+	fake.updateArgsForCall = append(fake.updateArgsForCall, struct {
+		logger     lager.Logger
+		containers []executor.Container
+	}{logger, containers})
+	fake.updateMutex.Unlock()
+	if fake.UpdateStub != nil {
+		fake.UpdateStub(logger, containers)
+	}
+}
+
+func (fake *FakeOrderer) UpdateCallCount() int {
+	fake.updateMutex.RLock()
+	defer fake.updateMutex.RUnlock()
+	return len(fake.updateArgsForCall)
+}
+
+func (fake *FakeOrderer) UpdateArgsForCall(i int) (lager.Logger, []executor.Container) {
+	fake.updateMutex.RLock()
+	defer fake.updateMutex.RUnlock()
+	return fake.updateArgsForCall[i].logger, fake.updateArgsForCall[i].containers
+}
+
+func (fake *FakeOrderer) AdmitLRP(logger lager.Logger, container executor.Container) bool {
+	fake.admitLRPMutex.Lock()
+	fake.admitLRPArgsForCall = append(fake.admitLRPArgsForCall, struct {
+		logger    lager.Logger
+		container executor.Container
+	}{logger, container})
+	fake.admitLRPMutex.Unlock()
+	if fake.AdmitLRPStub != nil {
+		return fake.AdmitLRPStub(logger, container)
+	} else {
+		return fake.admitLRPReturns.result1
+	}
+}
+
+func (fake *FakeOrderer) AdmitLRPCallCount() int {
+	fake.admitLRPMutex.RLock()
+	defer fake.admitLRPMutex.RUnlock()
+	return len(fake.admitLRPArgsForCall)
+}
+
+func (fake *FakeOrderer) AdmitLRPArgsForCall(i int) (lager.Logger, executor.Container) {
+	fake.admitLRPMutex.RLock()
+	defer fake.admitLRPMutex.RUnlock()
+	return fake.admitLRPArgsForCall[i].logger, fake.admitLRPArgsForCall[i].container
+}
+
+func (fake *FakeOrderer) AdmitLRPReturns(result1 bool) {
+	fake.AdmitLRPStub = nil
+	fake.admitLRPReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeOrderer) Progress() evacuation_order.Progress {
+	fake.progressMutex.Lock()
+	fake.progressArgsForCall = append(fake.progressArgsForCall, struct{}{})
+	fake.progressMutex.Unlock()
+	if fake.ProgressStub != nil {
+		return fake.ProgressStub()
+	} else {
+		return fake.progressReturns.result1
+	}
+}
+
+func (fake *FakeOrderer) ProgressCallCount() int {
+	fake.progressMutex.RLock()
+	defer fake.progressMutex.RUnlock()
+	return len(fake.progressArgsForCall)
+}
+
+func (fake *FakeOrderer) ProgressReturns(result1 evacuation_order.Progress) {
+	fake.ProgressStub = nil
+	fake.progressReturns = struct {
+		result1 evacuation_order.Progress
+	}{result1}
+}
+
+func (fake *FakeOrderer) Reset() {
+	fake.resetMutex.Lock()
+	fake.resetArgsForCall = append(fake.resetArgsForCall, struct{}{})
+	fake.resetMutex.Unlock()
+	if fake.ResetStub != nil {
+		fake.ResetStub()
+	}
+}
+
+func (fake *FakeOrderer) ResetCallCount() int {
+	fake.resetMutex.RLock()
+	defer fake.resetMutex.RUnlock()
+	return len(fake.resetArgsForCall)
+}
+
+var _ evacuation_order.Orderer = new(FakeOrderer)
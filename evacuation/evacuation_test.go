@@ -1,8 +1,11 @@
 package evacuation_test
 
 import (
+	"encoding/json"
 	"errors"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"time"
 
 	"code.cloudfoundry.org/clock/fakeclock"
@@ -13,10 +16,12 @@ import (
 	"code.cloudfoundry.org/rep"
 	"code.cloudfoundry.org/rep/evacuation"
 	"code.cloudfoundry.org/rep/evacuation/evacuation_context"
+	"code.cloudfoundry.org/rep/evacuation/evacuation_order/fake_evacuation_order"
 	"github.com/tedsuo/ifrit"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
 )
 
 var _ = Describe("Evacuation", func() {
@@ -32,11 +37,13 @@ var _ = Describe("Evacuation", func() {
 		executorClient     *fakes.FakeClient
 		evacuatable        evacuation_context.Evacuatable
 		evacuationNotifier evacuation_context.EvacuationNotifier
+		orderer            *fake_evacuation_order.FakeOrderer
 
 		evacuator *evacuation.Evacuator
 		process   ifrit.Process
 
-		errChan chan error
+		errChan   chan error
+		reportDir string
 
 		TaskTags   map[string]string
 		LRPTags    map[string]string
@@ -49,15 +56,22 @@ var _ = Describe("Evacuation", func() {
 		executorClient = &fakes.FakeClient{}
 
 		evacuatable, _, evacuationNotifier = evacuation_context.New()
+		orderer = &fake_evacuation_order.FakeOrderer{}
+
+		var err error
+		reportDir, err = ioutil.TempDir("", "evacuation-reports")
+		Expect(err).NotTo(HaveOccurred())
 
 		evacuator = evacuation.NewEvacuator(
 			logger,
 			fakeClock,
 			executorClient,
 			evacuationNotifier,
+			orderer,
 			cellID,
 			evacuationTimeout,
 			pollingInterval,
+			reportDir,
 		)
 
 		process = ifrit.Invoke(evacuator)
@@ -83,12 +97,133 @@ var _ = Describe("Evacuation", func() {
 		}
 	})
 
+	AfterEach(func() {
+		os.RemoveAll(reportDir)
+	})
+
 	Describe("before evacuating", func() {
 		It("exits when interrupted", func() {
 			process.Signal(os.Interrupt)
 
 			Eventually(errChan).Should(Receive(BeNil()))
 		})
+
+		It("reports that the cell is not evacuating", func() {
+			status := evacuator.EvacuationStatus()
+			Expect(status.Evacuating).To(BeFalse())
+			Expect(status.Timeout).To(Equal(evacuationTimeout))
+		})
+	})
+
+	Describe("EvacuationStatus", func() {
+		BeforeEach(func() {
+			executorClient.ListContainersReturns(containers, nil)
+		})
+
+		JustBeforeEach(func() {
+			evacuatable.Evacuate()
+			Eventually(executorClient.ListContainersCallCount).Should(BeNumerically(">=", 1))
+		})
+
+		It("reports the start time and the remaining containers", func() {
+			startedAt := fakeClock.Now()
+			Eventually(func() map[string]map[string]int {
+				return evacuator.EvacuationStatus().RemainingContainers
+			}).Should(Equal(map[string]map[string]int{
+				rep.TaskLifecycle: {string(executor.StateRunning): 1},
+				rep.LRPLifecycle:  {string(executor.StateRunning): 1},
+			}))
+
+			fakeClock.Increment(time.Second)
+			status := evacuator.EvacuationStatus()
+			Expect(status.Evacuating).To(BeTrue())
+			Expect(status.Complete).To(BeFalse())
+			Expect(status.StartedAt).To(Equal(startedAt))
+			Expect(status.Elapsed).To(Equal(time.Second))
+		})
+
+		It("reports the most recent failure for each container until it is cleared", func() {
+			evacuator.RecordEvacuationFailure(logger, "guid-2", "evacuate-running-actual-lrp", errors.New("first"))
+			evacuator.RecordEvacuationFailure(logger, "guid-2", "evacuate-running-actual-lrp", errors.New("second"))
+			evacuator.RecordEvacuationFailure(logger, "guid-1", "evacuate-claimed-actual-lrp", errors.New("boom"))
+
+			Expect(evacuator.EvacuationStatus().FailedContainers).To(Equal([]rep.EvacuationFailure{
+				rep.NewEvacuationFailure("guid-1", "evacuate-claimed-actual-lrp", errors.New("boom"), fakeClock.Now()),
+				rep.NewEvacuationFailure("guid-2", "evacuate-running-actual-lrp", errors.New("second"), fakeClock.Now()),
+			}))
+
+			evacuator.ClearEvacuationFailure("guid-2")
+			Expect(evacuator.EvacuationStatus().FailedContainers).To(HaveLen(1))
+		})
+	})
+
+	Describe("the evacuation report", func() {
+		BeforeEach(func() {
+			containerResponses := [][]executor.Container{
+				containers,
+				{containers[0]},
+			}
+
+			index := 0
+			executorClient.ListContainersStub = func(lager.Logger) ([]executor.Container, error) {
+				containersToReturn := containerResponses[index]
+				if index < len(containerResponses)-1 {
+					index++
+				}
+				return containersToReturn, nil
+			}
+		})
+
+		JustBeforeEach(func() {
+			evacuatable.Evacuate()
+			Eventually(executorClient.ListContainersCallCount).Should(Equal(1))
+
+			fakeClock.Increment(time.Second)
+			evacuator.RecordEvacuationDisposition(logger, "guid-2", rep.EvacuationDispositionHandedOff)
+
+			fakeClock.Increment(pollingInterval)
+			Eventually(executorClient.ListContainersCallCount).Should(Equal(2))
+
+			fakeClock.Increment(evacuationTimeout)
+			Eventually(errChan).Should(Receive(BeNil()))
+		})
+
+		readReport := func() rep.EvacuationReport {
+			paths, err := filepath.Glob(filepath.Join(reportDir, "evacuation-report-"+cellID+"-*.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(paths).To(HaveLen(1))
+
+			payload, err := ioutil.ReadFile(paths[0])
+			Expect(err).NotTo(HaveOccurred())
+
+			var report rep.EvacuationReport
+			Expect(json.Unmarshal(payload, &report)).To(Succeed())
+			return report
+		}
+
+		It("writes the disposition of each container to the report directory", func() {
+			report := readReport()
+			Expect(report.CellID).To(Equal(cellID))
+			Expect(report.Outcome).To(Equal(rep.EvacuationOutcomeTimedOut))
+
+			Expect(report.Containers).To(HaveLen(2))
+			Expect(report.Containers[0].ContainerGuid).To(Equal("guid-1"))
+			Expect(report.Containers[0].Lifecycle).To(Equal(rep.TaskLifecycle))
+			Expect(report.Containers[0].Disposition).To(Equal(rep.EvacuationDispositionTimedOut))
+			Expect(report.Containers[1].ContainerGuid).To(Equal("guid-2"))
+			Expect(report.Containers[1].Domain).To(Equal("domain"))
+			Expect(report.Containers[1].Disposition).To(Equal(rep.EvacuationDispositionHandedOff))
+			Expect(report.Containers[1].Duration).To(Equal(time.Second))
+
+			Expect(report.Totals).To(Equal(map[string]int{
+				rep.EvacuationDispositionTimedOut:  1,
+				rep.EvacuationDispositionHandedOff: 1,
+			}))
+		})
+
+		It("logs the report", func() {
+			Expect(logger).To(gbytes.Say("evacuation-report"))
+		})
 	})
 
 	Describe("during evacuation", func() {
@@ -122,6 +257,18 @@ var _ = Describe("Evacuation", func() {
 					Eventually(errChan).Should(Receive(BeNil()))
 				})
 
+				It("updates the evacuation order with the remaining containers", func() {
+					fakeClock.Increment(pollingInterval)
+					Eventually(orderer.UpdateCallCount).Should(Equal(1))
+					_, updatedContainers := orderer.UpdateArgsForCall(0)
+					Expect(updatedContainers).To(Equal(containers))
+
+					fakeClock.Increment(pollingInterval)
+					Eventually(orderer.UpdateCallCount).Should(Equal(2))
+					_, updatedContainers = orderer.UpdateArgsForCall(1)
+					Expect(updatedContainers).To(BeEmpty())
+				})
+
 				Context("when the executor client returns an error", func() {
 					BeforeEach(func() {
 						index := 0
@@ -160,12 +307,58 @@ var _ = Describe("Evacuation", func() {
 					Eventually(errChan).Should(Receive(BeNil()))
 				})
 
+				It("stops scanning the executor once it exits", func() {
+					Eventually(fakeClock.WatcherCount).Should(Equal(2))
+
+					fakeClock.Increment(evacuationTimeout + time.Second)
+					Eventually(errChan).Should(Receive(BeNil()))
+					Expect(fakeClock.WatcherCount()).To(Equal(0))
+
+					scans := executorClient.ListContainersCallCount()
+					fakeClock.Increment(pollingInterval)
+					Consistently(executorClient.ListContainersCallCount).Should(Equal(scans))
+				})
+
 				Context("when signaled", func() {
 					It("exits", func() {
 						process.Signal(os.Interrupt)
 
 						Eventually(errChan).Should(Receive(BeNil()))
 					})
+
+					It("stops scanning the executor", func() {
+						Eventually(fakeClock.WatcherCount).Should(Equal(2))
+
+						process.Signal(os.Interrupt)
+						Eventually(errChan).Should(Receive(BeNil()))
+						Expect(fakeClock.WatcherCount()).To(Equal(0))
+					})
+				})
+
+				Context("when the evacuation is cancelled", func() {
+					JustBeforeEach(func() {
+						Eventually(executorClient.ListContainersCallCount).Should(Equal(1))
+						evacuatable.CancelEvacuation()
+					})
+
+					It("does not exit", func() {
+						Consistently(errChan).ShouldNot(Receive())
+						fakeClock.Increment(evacuationTimeout + time.Second)
+						Consistently(errChan).ShouldNot(Receive())
+					})
+
+					It("resets the evacuation order and status", func() {
+						Eventually(orderer.ResetCallCount).Should(Equal(1))
+						Expect(evacuator.EvacuationStatus().Evacuating).To(BeFalse())
+						Expect(evacuator.EvacuationStatus().RemainingContainers).To(BeEmpty())
+					})
+
+					It("evacuates again when notified", func() {
+						Eventually(orderer.ResetCallCount).Should(Equal(1))
+						evacuatable.Evacuate()
+						Eventually(executorClient.ListContainersCallCount).Should(Equal(2))
+						Expect(evacuator.EvacuationStatus().Evacuating).To(BeTrue())
+					})
 				})
 			})
 		})
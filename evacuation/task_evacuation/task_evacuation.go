@@ -0,0 +1,125 @@
+// task_evacuation decides what happens to the tasks on an evacuating cell
+package task_evacuation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/executor"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep"
+)
+
+const (
+	// PolicyWait lets tasks run until they complete or the evacuation times out.
+	PolicyWait = "wait"
+	// PolicyFailFast stops tasks as soon as evacuation starts and fails them
+	// so that they can be rescheduled elsewhere.
+	PolicyFailFast = "fail-fast"
+	// PolicyDrainAfter lets tasks run for a grace period and then fails them.
+	PolicyDrainAfter = "drain-after"
+)
+
+var ErrInvalidPolicy = errors.New("invalid task evacuation policy")
+
+type Policy struct {
+	Name        string
+	GracePeriod time.Duration
+}
+
+// ParsePolicy parses a policy of the form "wait", "fail-fast" or
+// "drain-after:<grace period>".
+func ParsePolicy(value string) (Policy, error) {
+	parts := strings.SplitN(value, ":", 2)
+
+	policy := Policy{Name: parts[0]}
+	if len(parts) == 2 {
+		gracePeriod, err := time.ParseDuration(parts[1])
+		if err != nil {
+			return Policy{}, fmt.Errorf("%s: %s", ErrInvalidPolicy, err)
+		}
+		policy.GracePeriod = gracePeriod
+	}
+
+	return policy, policy.Validate()
+}
+
+func (p Policy) Validate() error {
+	switch p.Name {
+	case PolicyWait, PolicyFailFast:
+		if p.GracePeriod != 0 {
+			return fmt.Errorf("%s: %q does not take a grace period", ErrInvalidPolicy, p.Name)
+		}
+	case PolicyDrainAfter:
+		if p.GracePeriod <= 0 {
+			return fmt.Errorf("%s: %q requires a positive grace period", ErrInvalidPolicy, p.Name)
+		}
+	default:
+		return fmt.Errorf("%s: unknown policy %q", ErrInvalidPolicy, p.Name)
+	}
+
+	return nil
+}
+
+// ShouldFail reports whether a task should be failed once evacuation has been
+// running for the given time.
+func (p Policy) ShouldFail(elapsed time.Duration) bool {
+	switch p.Name {
+	case PolicyFailFast:
+		return true
+	case PolicyDrainAfter:
+		return elapsed >= p.GracePeriod
+	default:
+		return false
+	}
+}
+
+// Policies holds the policy for each task domain, falling back to Default for
+// domains without one.
+type Policies struct {
+	Default Policy
+	Domains map[string]Policy
+}
+
+func NewPolicies(defaultPolicy Policy, domains map[string]Policy) Policies {
+	return Policies{
+		Default: defaultPolicy,
+		Domains: domains,
+	}
+}
+
+func (p Policies) PolicyFor(domain string) Policy {
+	if policy, ok := p.Domains[domain]; ok {
+		return policy
+	}
+	if p.Default.Name == "" {
+		return Policy{Name: PolicyWait}
+	}
+	return p.Default
+}
+
+// PolicyForContainer returns the policy for the container's domain. An
+// EvacuationGracePeriodTag on the container, set from the task's environment,
+// overrides the grace period of a "drain-after" policy.
+func (p Policies) PolicyForContainer(logger lager.Logger, container executor.Container) Policy {
+	policy := p.PolicyFor(container.Tags[rep.DomainTag])
+	if policy.Name != PolicyDrainAfter {
+		return policy
+	}
+
+	value, ok := container.Tags[rep.EvacuationGracePeriodTag]
+	if !ok {
+		return policy
+	}
+
+	gracePeriod, err := time.ParseDuration(value)
+	if err != nil || gracePeriod <= 0 {
+		logger.Error("invalid-evacuation-grace-period", err, lager.Data{"container-guid": container.Guid, "grace-period": value})
+		return policy
+	}
+
+	policy.GracePeriod = gracePeriod
+	return policy
+}
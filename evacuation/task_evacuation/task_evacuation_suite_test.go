@@ -0,0 +1,13 @@
+package task_evacuation_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestTaskEvacuation(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "TaskEvacuation Suite")
+}
@@ -0,0 +1,119 @@
+package task_evacuation_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/executor"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/evacuation/task_evacuation"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Task evacuation policies", func() {
+	Describe("ParsePolicy", func() {
+		It("parses the wait policy", func() {
+			policy, err := task_evacuation.ParsePolicy("wait")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(policy).To(Equal(task_evacuation.Policy{Name: task_evacuation.PolicyWait}))
+		})
+
+		It("parses the fail-fast policy", func() {
+			policy, err := task_evacuation.ParsePolicy("fail-fast")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(policy).To(Equal(task_evacuation.Policy{Name: task_evacuation.PolicyFailFast}))
+		})
+
+		It("parses the drain-after policy with its grace period", func() {
+			policy, err := task_evacuation.ParsePolicy("drain-after:90s")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(policy).To(Equal(task_evacuation.Policy{Name: task_evacuation.PolicyDrainAfter, GracePeriod: 90 * time.Second}))
+		})
+
+		It("rejects drain-after without a grace period", func() {
+			_, err := task_evacuation.ParsePolicy("drain-after")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("rejects a grace period on other policies", func() {
+			_, err := task_evacuation.ParsePolicy("wait:10s")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("rejects an invalid grace period", func() {
+			_, err := task_evacuation.ParsePolicy("drain-after:soon")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("rejects unknown policies", func() {
+			_, err := task_evacuation.ParsePolicy("eventually")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("ShouldFail", func() {
+		It("never fails tasks under the wait policy", func() {
+			policy := task_evacuation.Policy{Name: task_evacuation.PolicyWait}
+			Expect(policy.ShouldFail(time.Hour)).To(BeFalse())
+		})
+
+		It("fails tasks immediately under the fail-fast policy", func() {
+			policy := task_evacuation.Policy{Name: task_evacuation.PolicyFailFast}
+			Expect(policy.ShouldFail(0)).To(BeTrue())
+		})
+
+		It("fails tasks once the grace period has elapsed under the drain-after policy", func() {
+			policy := task_evacuation.Policy{Name: task_evacuation.PolicyDrainAfter, GracePeriod: time.Minute}
+			Expect(policy.ShouldFail(59 * time.Second)).To(BeFalse())
+			Expect(policy.ShouldFail(time.Minute)).To(BeTrue())
+		})
+	})
+
+	Describe("Policies", func() {
+		var (
+			logger    *lagertest.TestLogger
+			policies  task_evacuation.Policies
+			container executor.Container
+		)
+
+		BeforeEach(func() {
+			logger = lagertest.NewTestLogger("test")
+			policies = task_evacuation.NewPolicies(
+				task_evacuation.Policy{Name: task_evacuation.PolicyFailFast},
+				map[string]task_evacuation.Policy{
+					"batch": {Name: task_evacuation.PolicyDrainAfter, GracePeriod: time.Minute},
+				},
+			)
+			container = executor.Container{
+				Guid: "some-guid",
+				Tags: executor.Tags{rep.DomainTag: "batch"},
+			}
+		})
+
+		It("returns the policy for the domain", func() {
+			Expect(policies.PolicyFor("batch").Name).To(Equal(task_evacuation.PolicyDrainAfter))
+		})
+
+		It("falls back to the default policy", func() {
+			Expect(policies.PolicyFor("other").Name).To(Equal(task_evacuation.PolicyFailFast))
+		})
+
+		It("defaults to wait when no default is configured", func() {
+			Expect(task_evacuation.Policies{}.PolicyFor("other").Name).To(Equal(task_evacuation.PolicyWait))
+		})
+
+		It("uses the grace period from the container tag", func() {
+			container.Tags[rep.EvacuationGracePeriodTag] = "5m"
+			Expect(policies.PolicyForContainer(logger, container).GracePeriod).To(Equal(5 * time.Minute))
+		})
+
+		It("ignores an invalid grace period tag", func() {
+			container.Tags[rep.EvacuationGracePeriodTag] = "later"
+			Expect(policies.PolicyForContainer(logger, container).GracePeriod).To(Equal(time.Minute))
+			Expect(logger).To(gbytes.Say("invalid-evacuation-grace-period"))
+		})
+	})
+})
@@ -0,0 +1,76 @@
+package rep
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrNoEvacuationInProgress = errors.New("no evacuation in progress")
+
+// EvacuationStatus describes the progress of an evacuation. Remaining
+// containers are counted by lifecycle and then by executor state.
+type EvacuationStatus struct {
+	Evacuating          bool                      `json:"evacuating"`
+	Complete            bool                      `json:"complete"`
+	StartedAt           time.Time                 `json:"started_at"`
+	Timeout             time.Duration             `json:"timeout"`
+	Elapsed             time.Duration             `json:"elapsed"`
+	RemainingContainers map[string]map[string]int `json:"remaining_containers"`
+	FailedContainers    []EvacuationFailure       `json:"failed_containers"`
+}
+
+// EvacuationFailure records the most recent failed BBS evacuate call for a
+// container.
+type EvacuationFailure struct {
+	ContainerGuid string    `json:"container_guid"`
+	Operation     string    `json:"operation"`
+	Error         string    `json:"error"`
+	FailedAt      time.Time `json:"failed_at"`
+}
+
+func NewEvacuationFailure(containerGuid, operation string, err error, failedAt time.Time) EvacuationFailure {
+	return EvacuationFailure{
+		ContainerGuid: containerGuid,
+		Operation:     operation,
+		Error:         err.Error(),
+		FailedAt:      failedAt,
+	}
+}
+
+const (
+	EvacuationDispositionHandedOff = "handed-off"
+	EvacuationDispositionCrashed   = "crashed"
+	EvacuationDispositionStopped   = "stopped"
+	EvacuationDispositionCompleted = "completed"
+	EvacuationDispositionTimedOut  = "timed-out"
+	EvacuationDispositionUnknown   = "unknown"
+)
+
+const (
+	EvacuationOutcomeComplete  = "complete"
+	EvacuationOutcomeTimedOut  = "timed-out"
+	EvacuationOutcomeSignaled  = "signaled"
+	EvacuationOutcomeCancelled = "cancelled"
+)
+
+// EvacuationReport records how each container left the cell during an
+// evacuation. Container durations are measured from the start of the
+// evacuation.
+type EvacuationReport struct {
+	CellID     string                      `json:"cell_id"`
+	Outcome    string                      `json:"outcome"`
+	StartedAt  time.Time                   `json:"started_at"`
+	FinishedAt time.Time                   `json:"finished_at"`
+	Duration   time.Duration               `json:"duration"`
+	Containers []EvacuationReportContainer `json:"containers"`
+	Totals     map[string]int              `json:"totals"`
+}
+
+type EvacuationReportContainer struct {
+	ContainerGuid string        `json:"container_guid"`
+	Lifecycle     string        `json:"lifecycle"`
+	Domain        string        `json:"domain"`
+	Disposition   string        `json:"disposition"`
+	FinishedAt    time.Time     `json:"finished_at"`
+	Duration      time.Duration `json:"duration"`
+}
@@ -6,12 +6,20 @@ import (
 
 	"code.cloudfoundry.org/bbs"
 	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/clock"
 	"code.cloudfoundry.org/executor"
 	"code.cloudfoundry.org/lager"
 	"code.cloudfoundry.org/operationq"
 	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/crash_archive"
+	"code.cloudfoundry.org/rep/crash_loop"
 	"code.cloudfoundry.org/rep/evacuation/evacuation_context"
+	"code.cloudfoundry.org/rep/evacuation/evacuation_order"
+	"code.cloudfoundry.org/rep/evacuation/task_evacuation"
 	"code.cloudfoundry.org/rep/generator/internal"
+	"code.cloudfoundry.org/rep/image_cache"
+	"code.cloudfoundry.org/rep/quarantine"
+	"code.cloudfoundry.org/rep/task_hooks"
 )
 
 //go:generate counterfeiter -o fake_generator/fake_generator.go . Generator
@@ -32,18 +40,44 @@ type generator struct {
 	lrpProcessor      internal.LRPProcessor
 	taskProcessor     internal.TaskProcessor
 	containerDelegate internal.ContainerDelegate
+	prepuller         image_cache.Prepuller
+}
+
+// EvacuationConfig holds the collaborators that take part in evacuating the
+// containers of the cell.
+type EvacuationConfig struct {
+	Reporter            evacuation_context.EvacuationReporter
+	StatusReporter      evacuation_context.EvacuationStatusReporter
+	FailureRecorder     evacuation_context.EvacuationFailureRecorder
+	DispositionRecorder evacuation_context.EvacuationDispositionRecorder
+	Orderer             evacuation_order.Orderer
+	TaskPolicies        task_evacuation.Policies
+	TTLInSeconds        uint64
+	// StrictHandOff keeps an evacuating LRP serving until its replacement
+	// is observed running on another cell.
+	StrictHandOff bool
+}
+
+// CrashConfig holds the collaborators told about crashed LRP instances.
+type CrashConfig struct {
+	Capturer    crash_archive.Capturer
+	LoopTracker crash_loop.Tracker
 }
 
 func New(
 	cellID string,
 	bbs bbs.InternalClient,
 	executorClient executor.Client,
-	evacuationReporter evacuation_context.EvacuationReporter,
-	evacuationTTLInSeconds uint64,
+	evacuation EvacuationConfig,
+	crashes CrashConfig,
+	quarantineTracker quarantine.Tracker,
+	taskHooks task_hooks.Dispatcher,
+	prepuller image_cache.Prepuller,
+	clock clock.Clock,
 ) Generator {
-	containerDelegate := internal.NewContainerDelegate(executorClient)
-	lrpProcessor := internal.NewLRPProcessor(bbs, containerDelegate, cellID, evacuationReporter, evacuationTTLInSeconds)
-	taskProcessor := internal.NewTaskProcessor(bbs, containerDelegate, cellID)
+	containerDelegate := internal.NewContainerDelegate(executorClient, quarantineTracker)
+	lrpProcessor := internal.NewLRPProcessor(bbs, containerDelegate, cellID, evacuation.Reporter, evacuation.StatusReporter, evacuation.TTLInSeconds, evacuation.Orderer, evacuation.FailureRecorder, evacuation.DispositionRecorder, evacuation.StrictHandOff, crashes.Capturer, crashes.LoopTracker, clock)
+	taskProcessor := internal.NewTaskProcessor(bbs, containerDelegate, cellID, evacuation.StatusReporter, evacuation.TaskPolicies, evacuation.DispositionRecorder, taskHooks)
 
 	return &generator{
 		cellID:            cellID,
@@ -52,6 +86,7 @@ func New(
 		lrpProcessor:      lrpProcessor,
 		taskProcessor:     taskProcessor,
 		containerDelegate: containerDelegate,
+		prepuller:         prepuller,
 	}
 }
 
@@ -136,7 +171,7 @@ func (g *generator) BatchOperations(logger lager.Logger) (map[string]operationq.
 
 	// create operations for instance lrps with no containers
 	for guid, lrp := range instanceLRPs {
-		if _, foundContainer := batch[guid]; foundContainer {
+		if hasContainer(containers, rep.LRPContainerGuids(lrp.ProcessGuid, lrp.InstanceGuid)) {
 			continue
 		}
 		if _, foundEvacuatingLRP := evacuatingLRPs[guid]; foundEvacuatingLRP {
@@ -149,16 +184,15 @@ func (g *generator) BatchOperations(logger lager.Logger) (map[string]operationq.
 	// create operations for evacuating lrps with no containers
 	for guid, lrp := range evacuatingLRPs {
 		_, found := batch[guid]
-		if !found {
+		if !found && !hasContainer(containers, rep.LRPContainerGuids(lrp.ProcessGuid, lrp.InstanceGuid)) {
 			batch[guid] = NewResidualEvacuatingLRPOperation(logger, g.bbs, g.containerDelegate, lrp.ActualLRPKey, lrp.ActualLRPInstanceKey)
 		}
 	}
 
 	// create operations for tasks with no containers
-	for guid, _ := range tasks {
-		_, found := batch[guid]
-		if !found {
-			batch[guid] = NewResidualTaskOperation(logger, guid, g.bbs, g.containerDelegate)
+	for taskGuid, _ := range tasks {
+		if !hasContainer(containers, rep.TaskContainerGuids(taskGuid)) {
+			batch[rep.TaskContainerGuid(taskGuid)] = NewResidualTaskOperation(logger, taskGuid, g.bbs, g.containerDelegate)
 		}
 	}
 
@@ -166,6 +200,17 @@ func (g *generator) BatchOperations(logger lager.Logger) (map[string]operationq.
 	return batch, nil
 }
 
+// hasContainer reports whether a container has any of the given guids, so
+// that containers named under the legacy scheme are still found.
+func hasContainer(containers map[string]executor.Container, guids []string) bool {
+	for _, guid := range guids {
+		if _, ok := containers[guid]; ok {
+			return true
+		}
+	}
+	return false
+}
+
 func (g *generator) OperationStream(logger lager.Logger) (<-chan operationq.Operation, error) {
 	streamLogger := logger.Session("operation-stream")
 
@@ -206,5 +251,5 @@ func (g *generator) OperationStream(logger lager.Logger) (<-chan operationq.Oper
 }
 
 func (g *generator) operationFromContainer(logger lager.Logger, guid string) operationq.Operation {
-	return NewContainerOperation(logger, g.lrpProcessor, g.taskProcessor, g.containerDelegate, guid)
+	return NewContainerOperation(logger, g.lrpProcessor, g.taskProcessor, g.containerDelegate, g.prepuller, guid)
 }
@@ -2,14 +2,23 @@ package generator_test
 
 import (
 	"errors"
+	"time"
 
 	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/clock/fakeclock"
 	"code.cloudfoundry.org/executor"
 	efakes "code.cloudfoundry.org/executor/fakes"
 	"code.cloudfoundry.org/operationq"
 	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/crash_archive/fake_crash_archive"
+	"code.cloudfoundry.org/rep/crash_loop/fake_crash_loop"
 	"code.cloudfoundry.org/rep/evacuation/evacuation_context/fake_evacuation_context"
+	"code.cloudfoundry.org/rep/evacuation/evacuation_order/fake_evacuation_order"
+	"code.cloudfoundry.org/rep/evacuation/task_evacuation"
 	"code.cloudfoundry.org/rep/generator"
+	"code.cloudfoundry.org/rep/image_cache/fake_image_cache"
+	"code.cloudfoundry.org/rep/quarantine/fake_quarantine"
+	"code.cloudfoundry.org/rep/task_hooks/fake_task_hooks"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -34,7 +43,19 @@ var _ = Describe("Generator", func() {
 		cellID = "some-cell-id"
 		fakeExecutorClient = new(efakes.FakeClient)
 		fakeEvacuationReporter := &fake_evacuation_context.FakeEvacuationReporter{}
-		opGenerator = generator.New(cellID, fakeBBS, fakeExecutorClient, fakeEvacuationReporter, 0)
+		evacuation := generator.EvacuationConfig{
+			Reporter:            fakeEvacuationReporter,
+			StatusReporter:      new(fake_evacuation_context.FakeEvacuationStatusReporter),
+			FailureRecorder:     new(fake_evacuation_context.FakeEvacuationFailureRecorder),
+			DispositionRecorder: new(fake_evacuation_context.FakeEvacuationDispositionRecorder),
+			Orderer:             new(fake_evacuation_order.FakeOrderer),
+			TaskPolicies:        task_evacuation.Policies{},
+		}
+		crashes := generator.CrashConfig{
+			Capturer:    new(fake_crash_archive.FakeCapturer),
+			LoopTracker: new(fake_crash_loop.FakeTracker),
+		}
+		opGenerator = generator.New(cellID, fakeBBS, fakeExecutorClient, evacuation, crashes, new(fake_quarantine.FakeTracker), new(fake_task_hooks.FakeDispatcher), new(fake_image_cache.FakePrepuller), fakeclock.NewFakeClock(time.Now()))
 	})
 
 	Describe("BatchOperations", func() {
@@ -79,6 +100,7 @@ var _ = Describe("Generator", func() {
 				instanceGuidEvacuatingLRPOnly             = "guid-evacuating-lrp-only"
 				instanceGuidInstanceAndEvacuatingLRPsOnly = "guid-instance-and-evacuating-lrps-only"
 				guidTaskOnly                              = "guid-task-only"
+				instanceGuidLegacyContainer               = "guid-legacy-container"
 
 				processGuid = "process-guid"
 			)
@@ -89,6 +111,7 @@ var _ = Describe("Generator", func() {
 					{Guid: rep.LRPContainerGuid(processGuid, instanceGuidContainerForInstanceLRP)},
 					{Guid: rep.LRPContainerGuid(processGuid, instanceGuidContainerForEvacuatingLRP)},
 					{Guid: guidContainerForTask},
+					{Guid: instanceGuidLegacyContainer},
 				}
 
 				actualLRPKey := models.ActualLRPKey{ProcessGuid: processGuid}
@@ -99,11 +122,14 @@ var _ = Describe("Generator", func() {
 				containerForEvacuatingLRP := models.ActualLRP{ActualLRPKey: actualLRPKey, ActualLRPInstanceKey: models.NewActualLRPInstanceKey(instanceGuidContainerForEvacuatingLRP, cellID)}
 				evacuatingOnlyLRP := models.ActualLRP{ActualLRPKey: actualLRPKey, ActualLRPInstanceKey: models.NewActualLRPInstanceKey(instanceGuidEvacuatingLRPOnly, cellID)}
 
+				legacyContainerLRP := models.ActualLRP{ActualLRPKey: actualLRPKey, ActualLRPInstanceKey: models.NewActualLRPInstanceKey(instanceGuidLegacyContainer, cellID)}
+
 				instanceAndEvacuatingLRP := models.ActualLRP{ActualLRPKey: actualLRPKey, ActualLRPInstanceKey: models.NewActualLRPInstanceKey(instanceGuidInstanceAndEvacuatingLRPsOnly, cellID)}
 
 				lrpGroups := []*models.ActualLRPGroup{
 					{Instance: &containerOnlyLRP, Evacuating: nil},
 					{Instance: &instanceOnlyLRP, Evacuating: nil},
+					{Instance: &legacyContainerLRP, Evacuating: nil},
 					{Instance: &instanceAndEvacuatingLRP, Evacuating: &instanceAndEvacuatingLRP},
 					{Instance: nil, Evacuating: &containerForEvacuatingLRP},
 					{Instance: nil, Evacuating: &evacuatingOnlyLRP},
@@ -129,7 +155,7 @@ var _ = Describe("Generator", func() {
 			})
 
 			It("returns a batch of the correct size", func() {
-				Expect(batch).To(HaveLen(8))
+				Expect(batch).To(HaveLen(9))
 			})
 
 			batchHasAContainerOperationForGuid := func(guid string, batch map[string]operationq.Operation) {
@@ -149,6 +175,11 @@ var _ = Describe("Generator", func() {
 				batchHasAContainerOperationForGuid(guidContainerForTask, batch)
 			})
 
+			It("returns only a container operation for an lrp whose container has a legacy guid", func() {
+				batchHasAContainerOperationForGuid(instanceGuidLegacyContainer, batch)
+				Expect(batch).NotTo(HaveKey(rep.LRPContainerGuid(processGuid, instanceGuidLegacyContainer)))
+			})
+
 			It("returns a container operation for a container with nothing in bbs", func() {
 				batchHasAContainerOperationForGuid(rep.LRPContainerGuid(processGuid, instanceGuidContainerOnly), batch)
 			})
@@ -172,7 +203,7 @@ var _ = Describe("Generator", func() {
 			})
 
 			It("returns a residual task operation for a task with no container", func() {
-				guid := guidTaskOnly
+				guid := rep.TaskContainerGuid(guidTaskOnly)
 				Expect(batch).To(HaveKey(guid))
 				Expect(batch[guid]).To(BeAssignableToTypeOf(new(generator.ResidualTaskOperation)))
 			})
@@ -7,6 +7,7 @@ import (
 
 	"code.cloudfoundry.org/executor"
 	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep/quarantine"
 )
 
 const MAX_RESULT_SIZE = 1024 * 10
@@ -25,13 +26,17 @@ type ContainerDelegate interface {
 	FetchContainerResultFile(logger lager.Logger, guid string, filename string) (string, error)
 }
 
+const quarantineReasonRunFailed = "running container failed"
+
 type containerDelegate struct {
-	client executor.Client
+	client     executor.Client
+	quarantine quarantine.Tracker
 }
 
-func NewContainerDelegate(client executor.Client) ContainerDelegate {
+func NewContainerDelegate(client executor.Client, quarantineTracker quarantine.Tracker) ContainerDelegate {
 	return &containerDelegate{
-		client: client,
+		client:     client,
+		quarantine: quarantineTracker,
 	}
 }
 
@@ -51,6 +56,7 @@ func (d *containerDelegate) RunContainer(logger lager.Logger, req *executor.RunR
 	err := d.client.RunContainer(logger, req)
 	if err != nil {
 		logInfoOrError(logger, "failed-running-container", err)
+		d.quarantine.RecordRunFailure(logger, quarantineReasonRunFailed)
 		d.DeleteContainer(logger, req.Guid)
 		return false
 	}
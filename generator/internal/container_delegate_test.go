@@ -9,6 +9,7 @@ import (
 	"code.cloudfoundry.org/executor/fakes"
 	"code.cloudfoundry.org/lager/lagertest"
 	"code.cloudfoundry.org/rep/generator/internal"
+	"code.cloudfoundry.org/rep/quarantine/fake_quarantine"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -18,13 +19,15 @@ import (
 var _ = Describe("ContainerDelegate", func() {
 	var containerDelegate internal.ContainerDelegate
 	var executorClient *fakes.FakeClient
+	var quarantineTracker *fake_quarantine.FakeTracker
 	var logger *lagertest.TestLogger
 	var expectedGuid = "some-instance-guid"
 	const sessionPrefix = "test"
 
 	BeforeEach(func() {
 		executorClient = new(fakes.FakeClient)
-		containerDelegate = internal.NewContainerDelegate(executorClient)
+		quarantineTracker = new(fake_quarantine.FakeTracker)
+		containerDelegate = internal.NewContainerDelegate(executorClient, quarantineTracker)
 		logger = lagertest.NewTestLogger(sessionPrefix)
 	})
 
@@ -55,6 +58,11 @@ var _ = Describe("ContainerDelegate", func() {
 				Expect(logger).To(gbytes.Say(sessionPrefix + ".running-container"))
 				Expect(logger).To(gbytes.Say(sessionPrefix + ".succeeded-running-container"))
 			})
+
+			It("does not report to the quarantine tracker", func() {
+				Expect(quarantineTracker.RecordSuccessCallCount()).To(Equal(0))
+				Expect(quarantineTracker.RecordRunFailureCallCount()).To(Equal(0))
+			})
 		})
 
 		Context("when running fails", func() {
@@ -70,6 +78,12 @@ var _ = Describe("ContainerDelegate", func() {
 				Expect(logger).To(gbytes.Say(sessionPrefix + ".failed-running-container"))
 			})
 
+			It("records the run failure with the quarantine tracker", func() {
+				Expect(quarantineTracker.RecordRunFailureCallCount()).To(Equal(1))
+				Expect(quarantineTracker.RecordFailureCallCount()).To(Equal(0))
+				Expect(quarantineTracker.RecordSuccessCallCount()).To(Equal(0))
+			})
+
 			It("deletes the container", func() {
 				Expect(executorClient.DeleteContainerCallCount()).To(Equal(1))
 				_, containerGuid := executorClient.DeleteContainerArgsForCall(0)
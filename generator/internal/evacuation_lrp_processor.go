@@ -1,25 +1,60 @@
 package internal
 
 import (
+	"sync"
+	"time"
+
 	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/clock"
 	"code.cloudfoundry.org/executor"
 	"code.cloudfoundry.org/lager"
 	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/evacuation/evacuation_context"
+	"code.cloudfoundry.org/rep/evacuation/evacuation_order"
 )
 
 type evacuationLRPProcessor struct {
 	bbsClient              bbs.InternalClient
 	containerDelegate      ContainerDelegate
 	cellID                 string
+	statusReporter         evacuation_context.EvacuationStatusReporter
 	evacuationTTLInSeconds uint64
+	orderer                evacuation_order.Orderer
+	failureRecorder        evacuation_context.EvacuationFailureRecorder
+	dispositionRecorder    evacuation_context.EvacuationDispositionRecorder
+	strictHandOff          bool
+	clock                  clock.Clock
+
+	handOffLock      sync.Mutex
+	handOffStartedAt time.Time
+	handOffDeadlines map[string]time.Time
 }
 
-func newEvacuationLRPProcessor(bbsClient bbs.InternalClient, containerDelegate ContainerDelegate, cellID string, evacuationTTLInSeconds uint64) LRPProcessor {
+func newEvacuationLRPProcessor(
+	bbsClient bbs.InternalClient,
+	containerDelegate ContainerDelegate,
+	cellID string,
+	statusReporter evacuation_context.EvacuationStatusReporter,
+	evacuationTTLInSeconds uint64,
+	orderer evacuation_order.Orderer,
+	failureRecorder evacuation_context.EvacuationFailureRecorder,
+	dispositionRecorder evacuation_context.EvacuationDispositionRecorder,
+	strictHandOff bool,
+	clock clock.Clock,
+) LRPProcessor {
 	return &evacuationLRPProcessor{
 		bbsClient:              bbsClient,
 		containerDelegate:      containerDelegate,
 		cellID:                 cellID,
+		statusReporter:         statusReporter,
 		evacuationTTLInSeconds: evacuationTTLInSeconds,
+		orderer:                orderer,
+		failureRecorder:        failureRecorder,
+		dispositionRecorder:    dispositionRecorder,
+		strictHandOff:          strictHandOff,
+		clock:                  clock,
+		handOffDeadlines:       map[string]time.Time{},
 	}
 }
 
@@ -78,6 +113,10 @@ func (p *evacuationLRPProcessor) processCreatedContainer(logger lager.Logger, lr
 func (p *evacuationLRPProcessor) processRunningContainer(logger lager.Logger, lrpContainer *lrpContainer) {
 	logger = logger.Session("process-running-container")
 
+	if !p.orderer.AdmitLRP(logger, lrpContainer.Container) {
+		return
+	}
+
 	logger.Debug("extracting-net-info-from-container")
 	netInfo, err := rep.ActualLRPNetInfoFromContainer(lrpContainer.Container)
 	if err != nil {
@@ -86,30 +125,126 @@ func (p *evacuationLRPProcessor) processRunningContainer(logger lager.Logger, lr
 	}
 	logger.Debug("succeeded-extracting-net-info-from-container")
 
+	if p.strictHandOff && !p.readyToHandOff(logger, lrpContainer) {
+		return
+	}
+
 	logger.Info("bbs-evacuate-running-actual-lrp", lager.Data{"net_info": netInfo})
 	keepContainer, err := p.bbsClient.EvacuateRunningActualLRP(logger, lrpContainer.ActualLRPKey, lrpContainer.ActualLRPInstanceKey, netInfo, p.evacuationTTLInSeconds)
 	if keepContainer == false {
+		p.failureRecorder.ClearEvacuationFailure(lrpContainer.Container.Guid)
+		p.forgetHandOff(lrpContainer.Container.Guid)
+
+		if err != nil {
+			p.dispositionRecorder.RecordEvacuationDisposition(logger, lrpContainer.Container.Guid, rep.EvacuationDispositionStopped)
+		} else {
+			p.dispositionRecorder.RecordEvacuationDisposition(logger, lrpContainer.Container.Guid, rep.EvacuationDispositionHandedOff)
+		}
 		p.containerDelegate.DeleteContainer(logger, lrpContainer.Container.Guid)
 	} else if err != nil {
 		logger.Error("failed-to-evacuate-running-actual-lrp", err, lager.Data{"lrp-key": lrpContainer.ActualLRPKey})
+		p.failureRecorder.RecordEvacuationFailure(logger, lrpContainer.Container.Guid, "evacuate-running-actual-lrp", err)
+	} else {
+		p.failureRecorder.ClearEvacuationFailure(lrpContainer.Container.Guid)
+		if p.strictHandOff {
+			p.startHandOff(logger, lrpContainer.Container.Guid)
+		}
 	}
 }
 
+// readyToHandOff reports whether the evacuating LRP may be offered to the BBS
+// again. The first evacuation asks the BBS for a replacement; after that the
+// container keeps serving, without the BBS being asked to release it, until
+// the replacement is observed running on another cell or the hand-off
+// deadline has passed while waiting for it.
+func (p *evacuationLRPProcessor) readyToHandOff(logger lager.Logger, lrpContainer *lrpContainer) bool {
+	logger = logger.Session("strict-hand-off")
+
+	startedAt := p.statusReporter.EvacuationStatus().StartedAt
+
+	p.handOffLock.Lock()
+	p.resetStaleHandOffs(startedAt)
+	deadline, waiting := p.handOffDeadlines[lrpContainer.Container.Guid]
+	p.handOffLock.Unlock()
+
+	if !waiting {
+		return true
+	}
+
+	group, err := p.bbsClient.ActualLRPGroupByProcessGuidAndIndex(logger, lrpContainer.ProcessGuid, int(lrpContainer.Index))
+	if err != nil {
+		logger.Error("failed-fetching-replacement-actual-lrp", err)
+		if models.ConvertError(err).Type == models.Error_ResourceNotFound {
+			return true
+		}
+	} else if group.Instance != nil && group.Instance.State == models.ActualLRPStateRunning && group.Instance.CellId != p.cellID {
+		logger.Info("observed-replacement-running", lager.Data{"replacement-cell-id": group.Instance.CellId})
+		return true
+	}
+
+	if p.clock.Now().Before(deadline) {
+		logger.Debug("still-waiting-for-replacement")
+		return false
+	}
+
+	logger.Info("timed-out-waiting-for-replacement")
+	return true
+}
+
+// startHandOff waits for a replacement until half the evacuation TTL has
+// passed. The BBS is then asked again, which refreshes the evacuating record
+// before it expires, and the wait is restarted if the container is kept.
+func (p *evacuationLRPProcessor) startHandOff(logger lager.Logger, guid string) {
+	startedAt := p.statusReporter.EvacuationStatus().StartedAt
+
+	p.handOffLock.Lock()
+	defer p.handOffLock.Unlock()
+
+	p.resetStaleHandOffs(startedAt)
+	deadline := p.clock.Now().Add(time.Duration(p.evacuationTTLInSeconds) * time.Second / 2)
+	p.handOffDeadlines[guid] = deadline
+	logger.Session("strict-hand-off").Info("waiting-for-replacement", lager.Data{"deadline": deadline})
+}
+
+// resetStaleHandOffs forgets the deadlines recorded during an earlier
+// evacuation, which has since been cancelled. It must be called with the
+// hand-off lock held.
+func (p *evacuationLRPProcessor) resetStaleHandOffs(startedAt time.Time) {
+	if startedAt.Equal(p.handOffStartedAt) {
+		return
+	}
+
+	p.handOffStartedAt = startedAt
+	p.handOffDeadlines = map[string]time.Time{}
+}
+
+func (p *evacuationLRPProcessor) forgetHandOff(guid string) {
+	p.handOffLock.Lock()
+	defer p.handOffLock.Unlock()
+
+	delete(p.handOffDeadlines, guid)
+}
+
 func (p *evacuationLRPProcessor) processCompletedContainer(logger lager.Logger, lrpContainer *lrpContainer) {
 	logger = logger.Session("process-completed-container")
 
 	if lrpContainer.RunResult.Stopped {
+		p.dispositionRecorder.RecordEvacuationDisposition(logger, lrpContainer.Guid, rep.EvacuationDispositionStopped)
 		_, err := p.bbsClient.EvacuateStoppedActualLRP(logger, lrpContainer.ActualLRPKey, lrpContainer.ActualLRPInstanceKey)
 		if err != nil {
 			logger.Error("failed-to-evacuate-stopped-actual-lrp", err, lager.Data{"lrp-key": lrpContainer.ActualLRPKey})
+			p.failureRecorder.RecordEvacuationFailure(logger, lrpContainer.Guid, "evacuate-stopped-actual-lrp", err)
 		}
 	} else {
+		p.dispositionRecorder.RecordEvacuationDisposition(logger, lrpContainer.Guid, rep.EvacuationDispositionCrashed)
 		_, err := p.bbsClient.EvacuateCrashedActualLRP(logger, lrpContainer.ActualLRPKey, lrpContainer.ActualLRPInstanceKey, lrpContainer.RunResult.FailureReason)
 		if err != nil {
 			logger.Error("failed-to-evacuate-crashed-actual-lrp", err, lager.Data{"lrp-key": lrpContainer.ActualLRPKey})
+			p.failureRecorder.RecordEvacuationFailure(logger, lrpContainer.Guid, "evacuate-crashed-actual-lrp", err)
 		}
 	}
 
+	p.forgetHandOff(lrpContainer.Guid)
 	p.containerDelegate.DeleteContainer(logger, lrpContainer.Guid)
 }
 
@@ -122,6 +257,9 @@ func (p *evacuationLRPProcessor) evacuateClaimedLRPContainer(logger lager.Logger
 	_, err := p.bbsClient.EvacuateClaimedActualLRP(logger, lrpContainer.ActualLRPKey, lrpContainer.ActualLRPInstanceKey)
 	if err != nil {
 		logger.Error("failed-to-unclaim-actual-lrp", err, lager.Data{"lrp-key": lrpContainer.ActualLRPKey})
+		p.failureRecorder.RecordEvacuationFailure(logger, lrpContainer.Container.Guid, "evacuate-claimed-actual-lrp", err)
+	} else {
+		p.dispositionRecorder.RecordEvacuationDisposition(logger, lrpContainer.Container.Guid, rep.EvacuationDispositionHandedOff)
 	}
 
 	p.containerDelegate.DeleteContainer(logger, lrpContainer.Container.Guid)
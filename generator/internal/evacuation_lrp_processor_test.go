@@ -4,13 +4,18 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"time"
 
 	"code.cloudfoundry.org/bbs/fake_bbs"
 	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/clock/fakeclock"
 	"code.cloudfoundry.org/executor"
 	"code.cloudfoundry.org/lager/lagertest"
 	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/crash_archive/fake_crash_archive"
+	"code.cloudfoundry.org/rep/crash_loop/fake_crash_loop"
 	"code.cloudfoundry.org/rep/evacuation/evacuation_context/fake_evacuation_context"
+	"code.cloudfoundry.org/rep/evacuation/evacuation_order/fake_evacuation_order"
 	"code.cloudfoundry.org/rep/generator/internal"
 	"code.cloudfoundry.org/rep/generator/internal/fake_internal"
 
@@ -27,10 +32,16 @@ var _ = Describe("EvacuationLrpProcessor", func() {
 		)
 
 		var (
-			logger                 *lagertest.TestLogger
-			fakeBBS                *fake_bbs.FakeInternalClient
-			fakeContainerDelegate  *fake_internal.FakeContainerDelegate
-			fakeEvacuationReporter *fake_evacuation_context.FakeEvacuationReporter
+			logger                  *lagertest.TestLogger
+			fakeBBS                 *fake_bbs.FakeInternalClient
+			fakeContainerDelegate   *fake_internal.FakeContainerDelegate
+			fakeEvacuationReporter  *fake_evacuation_context.FakeEvacuationReporter
+			fakeStatusReporter      *fake_evacuation_context.FakeEvacuationStatusReporter
+			fakeEvacuationOrderer   *fake_evacuation_order.FakeOrderer
+			fakeFailureRecorder     *fake_evacuation_context.FakeEvacuationFailureRecorder
+			fakeDispositionRecorder *fake_evacuation_context.FakeEvacuationDispositionRecorder
+			fakeClock               *fakeclock.FakeClock
+			strictHandOff           bool
 
 			lrpProcessor internal.LRPProcessor
 
@@ -52,8 +63,14 @@ var _ = Describe("EvacuationLrpProcessor", func() {
 			fakeContainerDelegate = &fake_internal.FakeContainerDelegate{}
 			fakeEvacuationReporter = &fake_evacuation_context.FakeEvacuationReporter{}
 			fakeEvacuationReporter.EvacuatingReturns(true)
-
-			lrpProcessor = internal.NewLRPProcessor(fakeBBS, fakeContainerDelegate, localCellID, fakeEvacuationReporter, evacuationTTL)
+			fakeStatusReporter = &fake_evacuation_context.FakeEvacuationStatusReporter{}
+			fakeEvacuationOrderer = &fake_evacuation_order.FakeOrderer{}
+			fakeEvacuationOrderer.AdmitLRPReturns(true)
+			fakeFailureRecorder = &fake_evacuation_context.FakeEvacuationFailureRecorder{}
+			fakeDispositionRecorder = &fake_evacuation_context.FakeEvacuationDispositionRecorder{}
+			fakeClock = fakeclock.NewFakeClock(time.Now())
+			fakeStatusReporter.EvacuationStatusReturns(rep.EvacuationStatus{Evacuating: true, StartedAt: fakeClock.Now()})
+			strictHandOff = false
 
 			processGuid = "process-guid"
 			desiredLRP = models.DesiredLRP{
@@ -85,6 +102,7 @@ var _ = Describe("EvacuationLrpProcessor", func() {
 		})
 
 		JustBeforeEach(func() {
+			lrpProcessor = internal.NewLRPProcessor(fakeBBS, fakeContainerDelegate, localCellID, fakeEvacuationReporter, fakeStatusReporter, evacuationTTL, fakeEvacuationOrderer, fakeFailureRecorder, fakeDispositionRecorder, strictHandOff, new(fake_crash_archive.FakeCapturer), new(fake_crash_loop.FakeTracker), fakeClock)
 			lrpProcessor.Process(logger, container)
 		})
 
@@ -110,6 +128,13 @@ var _ = Describe("EvacuationLrpProcessor", func() {
 					_, actualContainerGuid := fakeContainerDelegate.DeleteContainerArgsForCall(0)
 					Expect(actualContainerGuid).To(Equal(container.Guid))
 				})
+
+				It("records the container as handed off", func() {
+					Expect(fakeDispositionRecorder.RecordEvacuationDispositionCallCount()).To(Equal(1))
+					_, containerGuid, disposition := fakeDispositionRecorder.RecordEvacuationDispositionArgsForCall(0)
+					Expect(containerGuid).To(Equal(container.Guid))
+					Expect(disposition).To(Equal(rep.EvacuationDispositionHandedOff))
+				})
 			})
 
 			Context("when the evacuation returns that it failed to unclaim the LRP", func() {
@@ -272,6 +297,167 @@ var _ = Describe("EvacuationLrpProcessor", func() {
 				It("does not delete the container", func() {
 					Expect(fakeContainerDelegate.DeleteContainerCallCount()).To(Equal(0))
 				})
+
+				It("does not record a disposition", func() {
+					Expect(fakeDispositionRecorder.RecordEvacuationDispositionCallCount()).To(Equal(0))
+				})
+
+				It("clears any previously recorded failure", func() {
+					Expect(fakeFailureRecorder.ClearEvacuationFailureCallCount()).To(Equal(1))
+					Expect(fakeFailureRecorder.ClearEvacuationFailureArgsForCall(0)).To(Equal(container.Guid))
+				})
+			})
+
+			Context("when the evacuation reports that the LRP has been handed off", func() {
+				BeforeEach(func() {
+					fakeBBS.EvacuateRunningActualLRPReturns(false, nil)
+				})
+
+				It("deletes the container", func() {
+					Expect(fakeContainerDelegate.DeleteContainerCallCount()).To(Equal(1))
+				})
+
+				It("records the container as handed off", func() {
+					Expect(fakeDispositionRecorder.RecordEvacuationDispositionCallCount()).To(Equal(1))
+					_, containerGuid, disposition := fakeDispositionRecorder.RecordEvacuationDispositionArgsForCall(0)
+					Expect(containerGuid).To(Equal(container.Guid))
+					Expect(disposition).To(Equal(rep.EvacuationDispositionHandedOff))
+				})
+			})
+
+			Context("when strict hand-off is enabled", func() {
+				BeforeEach(func() {
+					strictHandOff = true
+					fakeBBS.EvacuateRunningActualLRPReturns(true, nil)
+				})
+
+				It("asks the BBS for a replacement without looking it up", func() {
+					Expect(fakeBBS.EvacuateRunningActualLRPCallCount()).To(Equal(1))
+					Expect(fakeBBS.ActualLRPGroupByProcessGuidAndIndexCallCount()).To(Equal(0))
+					Expect(logger).To(Say("waiting-for-replacement"))
+				})
+
+				Context("when the replacement is not running yet", func() {
+					BeforeEach(func() {
+						fakeBBS.ActualLRPGroupByProcessGuidAndIndexReturns(&models.ActualLRPGroup{
+							Instance: &models.ActualLRP{
+								ActualLRPInstanceKey: models.NewActualLRPInstanceKey("other-instance-guid", "other-cell"),
+								State:                models.ActualLRPStateClaimed,
+							},
+						}, nil)
+					})
+
+					It("looks up the replacement before evacuating again", func() {
+						lrpProcessor.Process(logger, container)
+
+						Expect(fakeBBS.ActualLRPGroupByProcessGuidAndIndexCallCount()).To(Equal(1))
+						_, actualProcessGuid, actualIndex := fakeBBS.ActualLRPGroupByProcessGuidAndIndexArgsForCall(0)
+						Expect(actualProcessGuid).To(Equal(processGuid))
+						Expect(actualIndex).To(Equal(index))
+					})
+
+					It("keeps the container serving without evacuating it again", func() {
+						fakeBBS.EvacuateRunningActualLRPReturns(false, nil)
+						lrpProcessor.Process(logger, container)
+
+						Expect(fakeBBS.EvacuateRunningActualLRPCallCount()).To(Equal(1))
+						Expect(fakeContainerDelegate.DeleteContainerCallCount()).To(Equal(0))
+						Expect(fakeDispositionRecorder.RecordEvacuationDispositionCallCount()).To(Equal(0))
+					})
+
+					It("evacuates it again once half the evacuation TTL passes", func() {
+						fakeBBS.EvacuateRunningActualLRPReturns(false, nil)
+
+						fakeClock.Increment(evacuationTTL*time.Second/2 - time.Second)
+						lrpProcessor.Process(logger, container)
+						Expect(fakeBBS.EvacuateRunningActualLRPCallCount()).To(Equal(1))
+
+						fakeClock.Increment(time.Second)
+						lrpProcessor.Process(logger, container)
+						Expect(logger).To(Say("timed-out-waiting-for-replacement"))
+						Expect(fakeBBS.EvacuateRunningActualLRPCallCount()).To(Equal(2))
+						Expect(fakeContainerDelegate.DeleteContainerCallCount()).To(Equal(1))
+					})
+
+					It("keeps waiting after refreshing the evacuating record", func() {
+						fakeClock.Increment(evacuationTTL * time.Second / 2)
+						lrpProcessor.Process(logger, container)
+						Expect(fakeBBS.EvacuateRunningActualLRPCallCount()).To(Equal(2))
+
+						fakeClock.Increment(evacuationTTL*time.Second/2 - time.Second)
+						lrpProcessor.Process(logger, container)
+						Expect(fakeBBS.EvacuateRunningActualLRPCallCount()).To(Equal(2))
+						Expect(fakeContainerDelegate.DeleteContainerCallCount()).To(Equal(0))
+					})
+
+					Context("when the evacuation is cancelled and started again", func() {
+						It("asks the BBS for a replacement without waiting for the earlier one", func() {
+							fakeStatusReporter.EvacuationStatusReturns(rep.EvacuationStatus{Evacuating: true, StartedAt: fakeClock.Now().Add(time.Second)})
+							lrpProcessor.Process(logger, container)
+
+							Expect(fakeBBS.ActualLRPGroupByProcessGuidAndIndexCallCount()).To(Equal(0))
+							Expect(fakeBBS.EvacuateRunningActualLRPCallCount()).To(Equal(2))
+						})
+					})
+
+					Context("when the container completes", func() {
+						It("forgets the hand-off", func() {
+							completed := container
+							completed.State = executor.StateCompleted
+							completed.RunResult.Stopped = true
+							lrpProcessor.Process(logger, completed)
+
+							lrpProcessor.Process(logger, container)
+							Expect(fakeBBS.ActualLRPGroupByProcessGuidAndIndexCallCount()).To(Equal(0))
+							Expect(fakeBBS.EvacuateRunningActualLRPCallCount()).To(Equal(2))
+						})
+					})
+				})
+
+				Context("when the replacement is running on another cell", func() {
+					BeforeEach(func() {
+						fakeBBS.ActualLRPGroupByProcessGuidAndIndexReturns(&models.ActualLRPGroup{
+							Instance: &models.ActualLRP{
+								ActualLRPInstanceKey: models.NewActualLRPInstanceKey("other-instance-guid", "other-cell"),
+								State:                models.ActualLRPStateRunning,
+							},
+						}, nil)
+					})
+
+					It("evacuates it again and deletes the container once the BBS releases it", func() {
+						fakeBBS.EvacuateRunningActualLRPReturns(false, nil)
+						lrpProcessor.Process(logger, container)
+
+						Expect(fakeBBS.EvacuateRunningActualLRPCallCount()).To(Equal(2))
+						Expect(fakeContainerDelegate.DeleteContainerCallCount()).To(Equal(1))
+						_, containerGuid, disposition := fakeDispositionRecorder.RecordEvacuationDispositionArgsForCall(0)
+						Expect(containerGuid).To(Equal(container.Guid))
+						Expect(disposition).To(Equal(rep.EvacuationDispositionHandedOff))
+					})
+				})
+
+				Context("when the replacement can no longer be found", func() {
+					BeforeEach(func() {
+						fakeBBS.ActualLRPGroupByProcessGuidAndIndexReturns(nil, models.ErrResourceNotFound)
+					})
+
+					It("evacuates it again", func() {
+						lrpProcessor.Process(logger, container)
+						Expect(fakeBBS.EvacuateRunningActualLRPCallCount()).To(Equal(2))
+					})
+				})
+
+				Context("when looking up the replacement fails", func() {
+					BeforeEach(func() {
+						fakeBBS.ActualLRPGroupByProcessGuidAndIndexReturns(nil, errors.New("boom"))
+					})
+
+					It("keeps the container serving", func() {
+						lrpProcessor.Process(logger, container)
+						Expect(fakeBBS.EvacuateRunningActualLRPCallCount()).To(Equal(1))
+						Expect(fakeContainerDelegate.DeleteContainerCallCount()).To(Equal(0))
+					})
+				})
 			})
 
 			Context("when the evacuation returns that it failed to evacuate the LRP", func() {
@@ -294,6 +480,31 @@ var _ = Describe("EvacuationLrpProcessor", func() {
 				It("does not delete the container", func() {
 					Expect(fakeContainerDelegate.DeleteContainerCallCount()).To(Equal(0))
 				})
+
+				It("records the failure", func() {
+					Expect(fakeFailureRecorder.RecordEvacuationFailureCallCount()).To(Equal(1))
+					_, containerGuid, operation, err := fakeFailureRecorder.RecordEvacuationFailureArgsForCall(0)
+					Expect(containerGuid).To(Equal(container.Guid))
+					Expect(operation).To(Equal("evacuate-running-actual-lrp"))
+					Expect(err).To(MatchError("whoops"))
+				})
+			})
+
+			It("asks the orderer whether the lrp may be evacuated", func() {
+				Expect(fakeEvacuationOrderer.AdmitLRPCallCount()).To(Equal(1))
+				_, admittedContainer := fakeEvacuationOrderer.AdmitLRPArgsForCall(0)
+				Expect(admittedContainer.Guid).To(Equal(container.Guid))
+			})
+
+			Context("when the orderer defers the evacuation", func() {
+				BeforeEach(func() {
+					fakeEvacuationOrderer.AdmitLRPReturns(false)
+				})
+
+				It("leaves the lrp running", func() {
+					Expect(fakeBBS.EvacuateRunningActualLRPCallCount()).To(Equal(0))
+					Expect(fakeContainerDelegate.DeleteContainerCallCount()).To(Equal(0))
+				})
 			})
 		})
 
@@ -310,6 +521,13 @@ var _ = Describe("EvacuationLrpProcessor", func() {
 				Expect(*actualLRPContainerKey).To(Equal(lrpInstanceKey))
 			})
 
+			It("records the container as stopped", func() {
+				Expect(fakeDispositionRecorder.RecordEvacuationDispositionCallCount()).To(Equal(1))
+				_, containerGuid, disposition := fakeDispositionRecorder.RecordEvacuationDispositionArgsForCall(0)
+				Expect(containerGuid).To(Equal(container.Guid))
+				Expect(disposition).To(Equal(rep.EvacuationDispositionStopped))
+			})
+
 			Context("when the evacuation returns successfully", func() {
 				BeforeEach(func() {
 					fakeBBS.EvacuateStoppedActualLRPReturns(false, nil)
@@ -362,6 +580,13 @@ var _ = Describe("EvacuationLrpProcessor", func() {
 				Expect(reason).To(Equal("crashed"))
 			})
 
+			It("records the container as crashed", func() {
+				Expect(fakeDispositionRecorder.RecordEvacuationDispositionCallCount()).To(Equal(1))
+				_, containerGuid, disposition := fakeDispositionRecorder.RecordEvacuationDispositionArgsForCall(0)
+				Expect(containerGuid).To(Equal(container.Guid))
+				Expect(disposition).To(Equal(rep.EvacuationDispositionCrashed))
+			})
+
 			Context("when the evacuation returns successfully", func() {
 				BeforeEach(func() {
 					fakeBBS.EvacuateCrashedActualLRPReturns(false, nil)
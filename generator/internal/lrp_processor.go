@@ -3,9 +3,13 @@ package internal
 import (
 	"code.cloudfoundry.org/bbs"
 	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/clock"
 	"code.cloudfoundry.org/executor"
 	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep/crash_archive"
+	"code.cloudfoundry.org/rep/crash_loop"
 	"code.cloudfoundry.org/rep/evacuation/evacuation_context"
+	"code.cloudfoundry.org/rep/evacuation/evacuation_order"
 )
 
 type lrpContainer struct {
@@ -39,10 +43,18 @@ func NewLRPProcessor(
 	containerDelegate ContainerDelegate,
 	cellID string,
 	evacuationReporter evacuation_context.EvacuationReporter,
+	evacuationStatusReporter evacuation_context.EvacuationStatusReporter,
 	evacuationTTLInSeconds uint64,
+	evacuationOrderer evacuation_order.Orderer,
+	evacuationFailureRecorder evacuation_context.EvacuationFailureRecorder,
+	evacuationDispositionRecorder evacuation_context.EvacuationDispositionRecorder,
+	strictEvacuationHandOff bool,
+	crashCapturer crash_archive.Capturer,
+	crashLoopTracker crash_loop.Tracker,
+	clock clock.Clock,
 ) LRPProcessor {
-	ordinaryProcessor := newOrdinaryLRPProcessor(bbsClient, containerDelegate, cellID)
-	evacuationProcessor := newEvacuationLRPProcessor(bbsClient, containerDelegate, cellID, evacuationTTLInSeconds)
+	ordinaryProcessor := newOrdinaryLRPProcessor(bbsClient, containerDelegate, cellID, crashCapturer, crashLoopTracker)
+	evacuationProcessor := newEvacuationLRPProcessor(bbsClient, containerDelegate, cellID, evacuationStatusReporter, evacuationTTLInSeconds, evacuationOrderer, evacuationFailureRecorder, evacuationDispositionRecorder, strictEvacuationHandOff, clock)
 	return &lrpProcessor{
 		evacuationReporter:  evacuationReporter,
 		ordinaryProcessor:   ordinaryProcessor,
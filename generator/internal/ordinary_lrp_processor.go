@@ -6,23 +6,31 @@ import (
 	"code.cloudfoundry.org/executor"
 	"code.cloudfoundry.org/lager"
 	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/crash_archive"
+	"code.cloudfoundry.org/rep/crash_loop"
 )
 
 type ordinaryLRPProcessor struct {
 	bbsClient         bbs.InternalClient
 	containerDelegate ContainerDelegate
 	cellID            string
+	crashCapturer     crash_archive.Capturer
+	crashLoopTracker  crash_loop.Tracker
 }
 
 func newOrdinaryLRPProcessor(
 	bbsClient bbs.InternalClient,
 	containerDelegate ContainerDelegate,
 	cellID string,
+	crashCapturer crash_archive.Capturer,
+	crashLoopTracker crash_loop.Tracker,
 ) LRPProcessor {
 	return &ordinaryLRPProcessor{
 		bbsClient:         bbsClient,
 		containerDelegate: containerDelegate,
 		cellID:            cellID,
+		crashCapturer:     crashCapturer,
+		crashLoopTracker:  crashLoopTracker,
 	}
 }
 
@@ -132,6 +140,9 @@ func (p *ordinaryLRPProcessor) processCompletedContainer(logger lager.Logger, lr
 		if err != nil {
 			logger.Info("failed-to-crash-actual-lrp", lager.Data{"error": err})
 		}
+
+		p.crashCapturer.Capture(logger, lrpContainer.ProcessGuid, lrpContainer.Index, lrpContainer.Container)
+		p.crashLoopTracker.RecordCrash(logger, lrpContainer.ProcessGuid, lrpContainer.Guid)
 	}
 
 	p.containerDelegate.DeleteContainer(logger, lrpContainer.Guid)
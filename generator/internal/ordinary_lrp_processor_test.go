@@ -4,14 +4,20 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"time"
 
 	"code.cloudfoundry.org/bbs/fake_bbs"
 	"code.cloudfoundry.org/bbs/models"
 	"code.cloudfoundry.org/bbs/models/test/model_helpers"
+	"code.cloudfoundry.org/clock/fakeclock"
 	"code.cloudfoundry.org/executor"
+	"code.cloudfoundry.org/lager"
 	"code.cloudfoundry.org/lager/lagertest"
 	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/crash_archive/fake_crash_archive"
+	"code.cloudfoundry.org/rep/crash_loop/fake_crash_loop"
 	"code.cloudfoundry.org/rep/evacuation/evacuation_context/fake_evacuation_context"
+	"code.cloudfoundry.org/rep/evacuation/evacuation_order/fake_evacuation_order"
 	"code.cloudfoundry.org/rep/generator/internal"
 	"code.cloudfoundry.org/rep/generator/internal/fake_internal"
 
@@ -29,6 +35,8 @@ var _ = Describe("OrdinaryLRPProcessor", func() {
 		bbsClient          *fake_bbs.FakeInternalClient
 		containerDelegate  *fake_internal.FakeContainerDelegate
 		evacuationReporter *fake_evacuation_context.FakeEvacuationReporter
+		crashCapturer      *fake_crash_archive.FakeCapturer
+		crashLoopTracker   *fake_crash_loop.FakeTracker
 	)
 
 	BeforeEach(func() {
@@ -36,7 +44,9 @@ var _ = Describe("OrdinaryLRPProcessor", func() {
 		containerDelegate = new(fake_internal.FakeContainerDelegate)
 		evacuationReporter = &fake_evacuation_context.FakeEvacuationReporter{}
 		evacuationReporter.EvacuatingReturns(false)
-		processor = internal.NewLRPProcessor(bbsClient, containerDelegate, expectedCellID, evacuationReporter, 124)
+		crashCapturer = new(fake_crash_archive.FakeCapturer)
+		crashLoopTracker = new(fake_crash_loop.FakeTracker)
+		processor = internal.NewLRPProcessor(bbsClient, containerDelegate, expectedCellID, evacuationReporter, new(fake_evacuation_context.FakeEvacuationStatusReporter), 124, new(fake_evacuation_order.FakeOrderer), new(fake_evacuation_context.FakeEvacuationFailureRecorder), new(fake_evacuation_context.FakeEvacuationDispositionRecorder), false, crashCapturer, crashLoopTracker, fakeclock.NewFakeClock(time.Now()))
 		logger = lagertest.NewTestLogger("test")
 	})
 
@@ -298,6 +308,14 @@ var _ = Describe("OrdinaryLRPProcessor", func() {
 								Expect(delegateLogger.SessionName()).To(Equal(expectedSessionName))
 							})
 						})
+
+						It("does not capture the crash", func() {
+							Expect(crashCapturer.CaptureCallCount()).To(BeZero())
+						})
+
+						It("does not count the crash", func() {
+							Expect(crashLoopTracker.RecordCrashCallCount()).To(BeZero())
+						})
 					})
 
 					Context("and the container was not requested to stop", func() {
@@ -314,6 +332,32 @@ var _ = Describe("OrdinaryLRPProcessor", func() {
 							Expect(reason).To(Equal("crashed"))
 						})
 
+						Context("when capturing the crash", func() {
+							var deletedBeforeCapture bool
+
+							BeforeEach(func() {
+								crashCapturer.CaptureStub = func(lager.Logger, string, int32, executor.Container) {
+									deletedBeforeCapture = containerDelegate.DeleteContainerCallCount() > 0
+								}
+							})
+
+							It("captures the crash before deleting the container", func() {
+								Expect(crashCapturer.CaptureCallCount()).To(Equal(1))
+								_, processGuid, index, capturedContainer := crashCapturer.CaptureArgsForCall(0)
+								Expect(processGuid).To(Equal(expectedLrpKey.ProcessGuid))
+								Expect(index).To(Equal(expectedLrpKey.Index))
+								Expect(capturedContainer.Guid).To(Equal(container.Guid))
+								Expect(deletedBeforeCapture).To(BeFalse())
+							})
+						})
+
+						It("counts the crash of the process", func() {
+							Expect(crashLoopTracker.RecordCrashCallCount()).To(Equal(1))
+							_, processGuid, containerGuid := crashLoopTracker.RecordCrashArgsForCall(0)
+							Expect(processGuid).To(Equal(expectedLrpKey.ProcessGuid))
+							Expect(containerGuid).To(Equal(container.Guid))
+						})
+
 						It("deletes the container", func() {
 							Expect(containerDelegate.DeleteContainerCallCount()).To(Equal(1))
 							delegateLogger, containerGuid := containerDelegate.DeleteContainerArgsForCall(0)
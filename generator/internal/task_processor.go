@@ -4,6 +4,9 @@ import (
 	"code.cloudfoundry.org/bbs/models"
 	"code.cloudfoundry.org/executor"
 	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/evacuation/evacuation_context"
+	"code.cloudfoundry.org/rep/evacuation/task_evacuation"
+	"code.cloudfoundry.org/rep/task_hooks"
 
 	"code.cloudfoundry.org/bbs"
 	"code.cloudfoundry.org/lager"
@@ -13,6 +16,7 @@ const TaskCompletionReasonMissingContainer = "task container does not exist"
 const TaskCompletionReasonFailedToRunContainer = "failed to run container"
 const TaskCompletionReasonInvalidTransition = "invalid state transition"
 const TaskCompletionReasonFailedToFetchResult = "failed to fetch result"
+const TaskCompletionReasonEvacuated = "task was stopped because its cell is evacuating"
 
 //go:generate counterfeiter -o fake_internal/fake_task_processor.go task_processor.go TaskProcessor
 
@@ -21,16 +25,32 @@ type TaskProcessor interface {
 }
 
 type taskProcessor struct {
-	bbsClient         bbs.InternalClient
-	containerDelegate ContainerDelegate
-	cellID            string
+	bbsClient                bbs.InternalClient
+	containerDelegate        ContainerDelegate
+	cellID                   string
+	evacuationStatusReporter evacuation_context.EvacuationStatusReporter
+	evacuationPolicies       task_evacuation.Policies
+	dispositionRecorder      evacuation_context.EvacuationDispositionRecorder
+	hooks                    task_hooks.Dispatcher
 }
 
-func NewTaskProcessor(bbs bbs.InternalClient, containerDelegate ContainerDelegate, cellID string) TaskProcessor {
+func NewTaskProcessor(
+	bbs bbs.InternalClient,
+	containerDelegate ContainerDelegate,
+	cellID string,
+	evacuationStatusReporter evacuation_context.EvacuationStatusReporter,
+	evacuationPolicies task_evacuation.Policies,
+	dispositionRecorder evacuation_context.EvacuationDispositionRecorder,
+	hooks task_hooks.Dispatcher,
+) TaskProcessor {
 	return &taskProcessor{
-		bbsClient:         bbs,
-		containerDelegate: containerDelegate,
-		cellID:            cellID,
+		bbsClient:                bbs,
+		containerDelegate:        containerDelegate,
+		cellID:                   cellID,
+		evacuationStatusReporter: evacuationStatusReporter,
+		evacuationPolicies:       evacuationPolicies,
+		dispositionRecorder:      dispositionRecorder,
+		hooks:                    hooks,
 	}
 }
 
@@ -38,11 +58,17 @@ func (p *taskProcessor) Process(logger lager.Logger, container executor.Containe
 	logger = logger.Session("task-processor", lager.Data{
 		"container-guid":  container.Guid,
 		"container-state": container.State,
+		"task-guid":       rep.TaskGuidFromContainer(container),
 	})
 
 	logger.Debug("starting")
 	defer logger.Debug("finished")
 
+	if container.State != executor.StateCompleted && p.shouldEvacuate(logger, container) {
+		p.evacuateTask(logger, container)
+		return
+	}
+
 	switch container.State {
 	case executor.StateReserved:
 		logger.Debug("processing-reserved-container")
@@ -63,18 +89,18 @@ func (p *taskProcessor) Process(logger lager.Logger, container executor.Containe
 }
 
 func (p *taskProcessor) processActiveContainer(logger lager.Logger, container executor.Container) {
-	ok := p.startTask(logger, container.Guid)
+	ok := p.startTask(logger, container)
 	if !ok {
 		return
 	}
 
-	task, err := p.bbsClient.TaskByGuid(logger, container.Guid)
+	task, err := p.bbsClient.TaskByGuid(logger, rep.TaskGuidFromContainer(container))
 	if err != nil {
 		logger.Error("failed-fetching-task", err)
 		return
 	}
 
-	runReq, err := rep.NewRunRequestFromTask(task)
+	runReq, err := rep.NewRunRequestFromTask(container.Guid, task)
 	if err != nil {
 		logger.Error("failed-to-construct-run-request", err)
 		return
@@ -82,27 +108,71 @@ func (p *taskProcessor) processActiveContainer(logger lager.Logger, container ex
 
 	ok = p.containerDelegate.RunContainer(logger, &runReq)
 	if !ok {
-		p.failTask(logger, container.Guid, TaskCompletionReasonFailedToRunContainer)
+		p.failTask(logger, container, TaskCompletionReasonFailedToRunContainer)
 	}
 }
 
 func (p *taskProcessor) processCompletedContainer(logger lager.Logger, container executor.Container) {
+	if container.RunResult.Failed {
+		p.dispositionRecorder.RecordEvacuationDisposition(logger, container.Guid, rep.EvacuationDispositionCrashed)
+	} else {
+		p.dispositionRecorder.RecordEvacuationDisposition(logger, container.Guid, rep.EvacuationDispositionCompleted)
+	}
 	p.completeTask(logger, container)
 	p.containerDelegate.DeleteContainer(logger, container.Guid)
 }
 
-func (p *taskProcessor) startTask(logger lager.Logger, guid string) bool {
+// shouldEvacuate reports whether the task's evacuation policy calls for it to
+// be failed now so that it can be rescheduled on another cell.
+func (p *taskProcessor) shouldEvacuate(logger lager.Logger, container executor.Container) bool {
+	status := p.evacuationStatusReporter.EvacuationStatus()
+	if !status.Evacuating || status.Complete {
+		return false
+	}
+
+	policy := p.evacuationPolicies.PolicyForContainer(logger, container)
+	return policy.ShouldFail(status.Elapsed)
+}
+
+func (p *taskProcessor) evacuateTask(logger lager.Logger, container executor.Container) {
+	logger = logger.Session("evacuating-task")
+
+	// give the task a chance to shut down before its container is deleted
+	if container.State != executor.StateReserved {
+		p.containerDelegate.StopContainer(logger, container.Guid)
+	}
+
+	logger.Info("failing-task")
+	err := p.bbsClient.FailTask(logger, rep.TaskGuidFromContainer(container), TaskCompletionReasonEvacuated)
+	if err != nil {
+		logger.Error("failed-failing-task", err)
+
+		// the task is gone or already finished, so only the container remains
+		bbsErr := models.ConvertError(err)
+		if bbsErr.Type != models.Error_InvalidStateTransition && bbsErr.Type != models.Error_ResourceNotFound {
+			return
+		}
+	} else {
+		logger.Info("succeeded-failing-task")
+		p.notifyHooks(logger, container, true, TaskCompletionReasonEvacuated, "")
+	}
+
+	p.dispositionRecorder.RecordEvacuationDisposition(logger, container.Guid, rep.EvacuationDispositionStopped)
+	p.containerDelegate.DeleteContainer(logger, container.Guid)
+}
+
+func (p *taskProcessor) startTask(logger lager.Logger, container executor.Container) bool {
 	logger.Info("starting-task")
-	changed, err := p.bbsClient.StartTask(logger, guid, p.cellID)
+	changed, err := p.bbsClient.StartTask(logger, rep.TaskGuidFromContainer(container), p.cellID)
 	if err != nil {
 		logger.Error("failed-starting-task", err)
 
 		bbsErr := models.ConvertError(err)
 		switch bbsErr.Type {
 		case models.Error_InvalidStateTransition:
-			p.containerDelegate.DeleteContainer(logger, guid)
+			p.containerDelegate.DeleteContainer(logger, container.Guid)
 		case models.Error_ResourceNotFound:
-			p.containerDelegate.DeleteContainer(logger, guid)
+			p.containerDelegate.DeleteContainer(logger, container.Guid)
 		}
 		return false
 	}
@@ -117,38 +187,55 @@ func (p *taskProcessor) startTask(logger lager.Logger, guid string) bool {
 }
 
 func (p *taskProcessor) completeTask(logger lager.Logger, container executor.Container) {
+	taskGuid := rep.TaskGuidFromContainer(container)
+
 	var result string
 	var err error
 	if !container.RunResult.Failed {
 		result, err = p.containerDelegate.FetchContainerResultFile(logger, container.Guid, container.Tags[rep.ResultFileTag])
 		if err != nil {
-			p.failTask(logger, container.Guid, TaskCompletionReasonFailedToFetchResult)
+			p.failTask(logger, container, TaskCompletionReasonFailedToFetchResult)
 			return
 		}
 	}
 
 	logger.Info("completing-task")
-	err = p.bbsClient.CompleteTask(logger, container.Guid, p.cellID, container.RunResult.Failed, container.RunResult.FailureReason, result)
+	err = p.bbsClient.CompleteTask(logger, taskGuid, p.cellID, container.RunResult.Failed, container.RunResult.FailureReason, result)
 	if err != nil {
 		logger.Error("failed-completing-task", err)
 
 		bbsErr := models.ConvertError(err)
 		if bbsErr.Type == models.Error_InvalidStateTransition {
-			p.failTask(logger, container.Guid, TaskCompletionReasonInvalidTransition)
+			p.failTask(logger, container, TaskCompletionReasonInvalidTransition)
 		}
 		return
 	}
 
 	logger.Info("succeeded-completing-task")
+	p.notifyHooks(logger, container, container.RunResult.Failed, container.RunResult.FailureReason, result)
 }
 
-func (p *taskProcessor) failTask(logger lager.Logger, guid string, reason string) {
+func (p *taskProcessor) failTask(logger lager.Logger, container executor.Container, reason string) {
 	logger.Info("failing-task")
-	err := p.bbsClient.FailTask(logger, guid, reason)
+	err := p.bbsClient.FailTask(logger, rep.TaskGuidFromContainer(container), reason)
 	if err != nil {
 		logger.Error("failed-failing-task", err)
 		return
 	}
 
 	logger.Info("succeeded-failing-task")
+	p.notifyHooks(logger, container, true, reason, "")
+}
+
+// notifyHooks is called only once the BBS knows of the completion. The hooks
+// run on their own workers, so that they can neither delay nor fail it.
+func (p *taskProcessor) notifyHooks(logger lager.Logger, container executor.Container, failed bool, failureReason, result string) {
+	p.hooks.TaskCompleted(logger, task_hooks.Completion{
+		TaskGuid:      rep.TaskGuidFromContainer(container),
+		Domain:        container.Tags[rep.DomainTag],
+		CellID:        p.cellID,
+		Failed:        failed,
+		FailureReason: failureReason,
+		Result:        result,
+	})
 }
@@ -2,6 +2,7 @@ package internal_test
 
 import (
 	"errors"
+	"time"
 
 	etcddb "code.cloudfoundry.org/bbs/db/etcd"
 	"code.cloudfoundry.org/bbs/models"
@@ -10,8 +11,12 @@ import (
 	"code.cloudfoundry.org/lager"
 	"code.cloudfoundry.org/lager/lagertest"
 	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/evacuation/evacuation_context/fake_evacuation_context"
+	"code.cloudfoundry.org/rep/evacuation/task_evacuation"
 	"code.cloudfoundry.org/rep/generator/internal"
 	"code.cloudfoundry.org/rep/generator/internal/fake_internal"
+	"code.cloudfoundry.org/rep/task_hooks"
+	"code.cloudfoundry.org/rep/task_hooks/fake_task_hooks"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -20,7 +25,13 @@ import (
 var processor internal.TaskProcessor
 
 var _ = Describe("Task <-> Container table", func() {
-	var containerDelegate *fake_internal.FakeContainerDelegate
+	var (
+		containerDelegate        *fake_internal.FakeContainerDelegate
+		evacuationStatusReporter *fake_evacuation_context.FakeEvacuationStatusReporter
+		evacuationPolicies       task_evacuation.Policies
+		dispositionRecorder      *fake_evacuation_context.FakeEvacuationDispositionRecorder
+		taskHooks                *fake_task_hooks.FakeDispatcher
+	)
 
 	const (
 		taskGuid      = "my-guid"
@@ -32,13 +43,20 @@ var _ = Describe("Task <-> Container table", func() {
 	BeforeEach(func() {
 		etcdRunner.ResetAllBut(etcddb.VersionKey)
 		containerDelegate = new(fake_internal.FakeContainerDelegate)
-		processor = internal.NewTaskProcessor(bbsClient, containerDelegate, localCellID)
+		evacuationStatusReporter = new(fake_evacuation_context.FakeEvacuationStatusReporter)
+		evacuationPolicies = task_evacuation.Policies{}
+		dispositionRecorder = new(fake_evacuation_context.FakeEvacuationDispositionRecorder)
+		taskHooks = new(fake_task_hooks.FakeDispatcher)
 
 		containerDelegate.DeleteContainerReturns(true)
 		containerDelegate.StopContainerReturns(true)
 		containerDelegate.RunContainerReturns(true)
 	})
 
+	JustBeforeEach(func() {
+		processor = internal.NewTaskProcessor(bbsClient, containerDelegate, localCellID, evacuationStatusReporter, evacuationPolicies, dispositionRecorder, taskHooks)
+	})
+
 	itDeletesTheContainer := func(logger *lagertest.TestLogger) {
 		It("deletes the container", func() {
 			Expect(containerDelegate.DeleteContainerCallCount()).To(Equal(1))
@@ -57,6 +75,17 @@ var _ = Describe("Task <-> Container table", func() {
 				Expect(task.Failed).To(BeTrue())
 				Expect(task.FailureReason).To(Equal(reason))
 			})
+
+			It("notifies the task completion hooks of the failure", func() {
+				Expect(taskHooks.TaskCompletedCallCount()).To(Equal(1))
+				_, completion := taskHooks.TaskCompletedArgsForCall(0)
+				Expect(completion).To(Equal(task_hooks.Completion{
+					TaskGuid:      taskGuid,
+					CellID:        localCellID,
+					Failed:        true,
+					FailureReason: reason,
+				}))
+			})
 		}
 	}
 
@@ -65,6 +94,12 @@ var _ = Describe("Task <-> Container table", func() {
 	}
 
 	itCompletesTheSuccessfulTaskAndDeletesTheContainer := func(logger *lagertest.TestLogger) {
+		It("records the container as completed for the evacuation report", func() {
+			Expect(dispositionRecorder.RecordEvacuationDispositionCallCount()).To(Equal(1))
+			_, _, disposition := dispositionRecorder.RecordEvacuationDispositionArgsForCall(0)
+			Expect(disposition).To(Equal(rep.EvacuationDispositionCompleted))
+		})
+
 		Context("when fetching the result succeeds", func() {
 			BeforeEach(func() {
 				containerDelegate.FetchContainerResultFileReturns("some-result", nil)
@@ -91,6 +126,16 @@ var _ = Describe("Task <-> Container table", func() {
 				Expect(task.Result).To(Equal("some-result"))
 			})
 
+			It("notifies the task completion hooks", func() {
+				Expect(taskHooks.TaskCompletedCallCount()).To(Equal(1))
+				_, completion := taskHooks.TaskCompletedArgsForCall(0)
+				Expect(completion).To(Equal(task_hooks.Completion{
+					TaskGuid: taskGuid,
+					CellID:   localCellID,
+					Result:   "some-result",
+				}))
+			})
+
 			itDeletesTheContainer(logger)
 		})
 
@@ -117,6 +162,12 @@ var _ = Describe("Task <-> Container table", func() {
 			Expect(containerDelegate.FetchContainerResultFileCallCount()).To(BeZero())
 		})
 
+		It("records the container as crashed for the evacuation report", func() {
+			Expect(dispositionRecorder.RecordEvacuationDispositionCallCount()).To(Equal(1))
+			_, _, disposition := dispositionRecorder.RecordEvacuationDispositionArgsForCall(0)
+			Expect(disposition).To(Equal(rep.EvacuationDispositionCrashed))
+		})
+
 		itCompletesTheTaskWithFailure("because")(logger)
 
 		itDeletesTheContainer(logger)
@@ -140,7 +191,7 @@ var _ = Describe("Task <-> Container table", func() {
 			task, err := bbsClient.TaskByGuid(logger, taskGuid)
 			Expect(err).NotTo(HaveOccurred())
 
-			expectedRunRequest, err := rep.NewRunRequestFromTask(task)
+			expectedRunRequest, err := rep.NewRunRequestFromTask(taskGuid, task)
 			Expect(err).NotTo(HaveOccurred())
 
 			_, runRequest := containerDelegate.RunContainerArgsForCall(0)
@@ -392,6 +443,168 @@ var _ = Describe("Task <-> Container table", func() {
 	}
 
 	table.Test()
+
+	Describe("when the cell is evacuating", func() {
+		var (
+			logger            *lagertest.TestLogger
+			container         executor.Container
+			deletedBeforeStop bool
+		)
+
+		BeforeEach(func() {
+			logger = lagertest.NewTestLogger(sessionPrefix)
+
+			deletedBeforeStop = false
+			containerDelegate.StopContainerStub = func(lager.Logger, string) bool {
+				deletedBeforeStop = containerDelegate.DeleteContainerCallCount() > 0
+				return true
+			}
+
+			walkToState(logger, NewTask(taskGuid, localCellID, models.Task_Running))
+			container = NewContainer(taskGuid, executor.StateRunning)
+			container.Tags[rep.DomainTag] = "some-domain"
+
+			evacuationStatusReporter.EvacuationStatusReturns(rep.EvacuationStatus{
+				Evacuating: true,
+				Elapsed:    time.Minute,
+			})
+		})
+
+		JustBeforeEach(func() {
+			processor.Process(logger, container)
+		})
+
+		itLeavesTheTaskRunning := func() {
+			It("leaves the task running", func() {
+				task, err := bbsClient.TaskByGuid(logger, taskGuid)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(task.State).To(Equal(models.Task_Running))
+			})
+
+			It("does not delete the container", func() {
+				Expect(containerDelegate.DeleteContainerCallCount()).To(Equal(0))
+			})
+		}
+
+		itFailsTheTaskAndDeletesTheContainer := func() {
+			It("fails the task with the evacuation reason", func() {
+				task, err := bbsClient.TaskByGuid(logger, taskGuid)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(task.State).To(Equal(models.Task_Completed))
+				Expect(task.Failed).To(BeTrue())
+				Expect(task.FailureReason).To(Equal(internal.TaskCompletionReasonEvacuated))
+			})
+
+			It("deletes the container", func() {
+				Expect(containerDelegate.DeleteContainerCallCount()).To(Equal(1))
+				_, containerGuid := containerDelegate.DeleteContainerArgsForCall(0)
+				Expect(containerGuid).To(Equal(taskGuid))
+			})
+
+			It("stops the container before deleting it", func() {
+				Expect(containerDelegate.StopContainerCallCount()).To(Equal(1))
+				_, containerGuid := containerDelegate.StopContainerArgsForCall(0)
+				Expect(containerGuid).To(Equal(taskGuid))
+				Expect(deletedBeforeStop).To(BeFalse())
+			})
+
+			It("notifies the task completion hooks of the failure", func() {
+				Expect(taskHooks.TaskCompletedCallCount()).To(Equal(1))
+				_, completion := taskHooks.TaskCompletedArgsForCall(0)
+				Expect(completion.Domain).To(Equal("some-domain"))
+				Expect(completion.Failed).To(BeTrue())
+				Expect(completion.FailureReason).To(Equal(internal.TaskCompletionReasonEvacuated))
+			})
+
+			It("records the container as stopped", func() {
+				Expect(dispositionRecorder.RecordEvacuationDispositionCallCount()).To(Equal(1))
+				_, containerGuid, disposition := dispositionRecorder.RecordEvacuationDispositionArgsForCall(0)
+				Expect(containerGuid).To(Equal(taskGuid))
+				Expect(disposition).To(Equal(rep.EvacuationDispositionStopped))
+			})
+		}
+
+		Context("when the domain has no policy", func() {
+			itLeavesTheTaskRunning()
+		})
+
+		Context("when the domain's policy is wait", func() {
+			BeforeEach(func() {
+				evacuationPolicies = task_evacuation.NewPolicies(
+					task_evacuation.Policy{Name: task_evacuation.PolicyFailFast},
+					map[string]task_evacuation.Policy{"some-domain": {Name: task_evacuation.PolicyWait}},
+				)
+			})
+
+			itLeavesTheTaskRunning()
+		})
+
+		Context("when the domain's policy is fail-fast", func() {
+			BeforeEach(func() {
+				evacuationPolicies = task_evacuation.NewPolicies(
+					task_evacuation.Policy{Name: task_evacuation.PolicyWait},
+					map[string]task_evacuation.Policy{"some-domain": {Name: task_evacuation.PolicyFailFast}},
+				)
+			})
+
+			itFailsTheTaskAndDeletesTheContainer()
+
+			Context("when the evacuation has completed", func() {
+				BeforeEach(func() {
+					evacuationStatusReporter.EvacuationStatusReturns(rep.EvacuationStatus{
+						Evacuating: true,
+						Complete:   true,
+					})
+				})
+
+				itLeavesTheTaskRunning()
+			})
+
+			Context("when the task has already completed", func() {
+				BeforeEach(func() {
+					err := bbsClient.CompleteTask(logger, taskGuid, localCellID, false, "", "some-result")
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				It("deletes the container", func() {
+					Expect(containerDelegate.DeleteContainerCallCount()).To(Equal(1))
+				})
+			})
+		})
+
+		Context("when the domain's policy is drain-after", func() {
+			BeforeEach(func() {
+				evacuationPolicies = task_evacuation.NewPolicies(
+					task_evacuation.Policy{Name: task_evacuation.PolicyWait},
+					map[string]task_evacuation.Policy{"some-domain": {Name: task_evacuation.PolicyDrainAfter, GracePeriod: 2 * time.Minute}},
+				)
+			})
+
+			Context("when the grace period has not elapsed", func() {
+				itLeavesTheTaskRunning()
+			})
+
+			Context("when the grace period has elapsed", func() {
+				BeforeEach(func() {
+					evacuationStatusReporter.EvacuationStatusReturns(rep.EvacuationStatus{
+						Evacuating: true,
+						Elapsed:    3 * time.Minute,
+					})
+				})
+
+				itFailsTheTaskAndDeletesTheContainer()
+			})
+
+			Context("when the container sets a shorter grace period", func() {
+				BeforeEach(func() {
+					container.Tags[rep.EvacuationGracePeriodTag] = "30s"
+				})
+
+				itFailsTheTaskAndDeletesTheContainer()
+			})
+		})
+	})
 })
 
 type TaskTable struct {
@@ -8,6 +8,7 @@ import (
 	"code.cloudfoundry.org/lager"
 	"code.cloudfoundry.org/rep"
 	"code.cloudfoundry.org/rep/generator/internal"
+	"code.cloudfoundry.org/rep/image_cache"
 )
 
 // ResidualInstanceLRPOperation processes an instance ActualLRP with no matching container.
@@ -35,7 +36,7 @@ func NewResidualInstanceLRPOperation(logger lager.Logger,
 }
 
 func (o *ResidualInstanceLRPOperation) Key() string {
-	return o.GetInstanceGuid()
+	return rep.LRPContainerGuid(o.GetProcessGuid(), o.GetInstanceGuid())
 }
 
 func (o *ResidualInstanceLRPOperation) Execute() {
@@ -46,8 +47,7 @@ func (o *ResidualInstanceLRPOperation) Execute() {
 	logger.Info("starting")
 	defer logger.Info("finished")
 
-	_, exists := o.containerDelegate.GetContainer(logger, rep.LRPContainerGuid(o.GetProcessGuid(), o.GetInstanceGuid()))
-	if exists {
+	if containerExists(logger, o.containerDelegate, rep.LRPContainerGuids(o.GetProcessGuid(), o.GetInstanceGuid())) {
 		logger.Info("skipped-because-container-exists")
 		return
 	}
@@ -83,7 +83,7 @@ func NewResidualEvacuatingLRPOperation(logger lager.Logger,
 }
 
 func (o *ResidualEvacuatingLRPOperation) Key() string {
-	return o.GetInstanceGuid()
+	return rep.LRPContainerGuid(o.GetProcessGuid(), o.GetInstanceGuid())
 }
 
 func (o *ResidualEvacuatingLRPOperation) Execute() {
@@ -94,8 +94,7 @@ func (o *ResidualEvacuatingLRPOperation) Execute() {
 	logger.Info("starting")
 	defer logger.Info("finished")
 
-	_, exists := o.containerDelegate.GetContainer(logger, rep.LRPContainerGuid(o.GetProcessGuid(), o.GetInstanceGuid()))
-	if exists {
+	if containerExists(logger, o.containerDelegate, rep.LRPContainerGuids(o.GetProcessGuid(), o.GetInstanceGuid())) {
 		logger.Info("skipped-because-container-exists")
 		return
 	}
@@ -128,7 +127,7 @@ func NewResidualJointLRPOperation(logger lager.Logger,
 }
 
 func (o *ResidualJointLRPOperation) Key() string {
-	return o.GetInstanceGuid()
+	return rep.LRPContainerGuid(o.GetProcessGuid(), o.GetInstanceGuid())
 }
 
 func (o *ResidualJointLRPOperation) Execute() {
@@ -139,8 +138,7 @@ func (o *ResidualJointLRPOperation) Execute() {
 	logger.Info("starting")
 	defer logger.Info("finished")
 
-	_, exists := o.containerDelegate.GetContainer(logger, rep.LRPContainerGuid(o.GetProcessGuid(), o.GetInstanceGuid()))
-	if exists {
+	if containerExists(logger, o.containerDelegate, rep.LRPContainerGuids(o.GetProcessGuid(), o.GetInstanceGuid())) {
 		logger.Info("skipped-because-container-exists")
 		return
 	}
@@ -174,7 +172,7 @@ func NewResidualTaskOperation(
 }
 
 func (o *ResidualTaskOperation) Key() string {
-	return o.TaskGuid
+	return rep.TaskContainerGuid(o.TaskGuid)
 }
 
 func (o *ResidualTaskOperation) Execute() {
@@ -184,8 +182,7 @@ func (o *ResidualTaskOperation) Execute() {
 	logger.Info("starting")
 	defer logger.Info("finished")
 
-	_, exists := o.containerDelegate.GetContainer(logger, o.TaskGuid)
-	if exists {
+	if containerExists(logger, o.containerDelegate, rep.TaskContainerGuids(o.TaskGuid)) {
 		logger.Info("skipped-because-container-exists")
 		return
 	}
@@ -196,6 +193,17 @@ func (o *ResidualTaskOperation) Execute() {
 	}
 }
 
+// containerExists reports whether a container exists under any of the given
+// guids.
+func containerExists(logger lager.Logger, containerDelegate internal.ContainerDelegate, guids []string) bool {
+	for _, guid := range guids {
+		if _, exists := containerDelegate.GetContainer(logger, guid); exists {
+			return true
+		}
+	}
+	return false
+}
+
 // ContainerOperation acquires the current state of a container and performs any
 // bbs or container operations necessary to harmonize the state of the world.
 type ContainerOperation struct {
@@ -203,6 +211,7 @@ type ContainerOperation struct {
 	lrpProcessor      internal.LRPProcessor
 	taskProcessor     internal.TaskProcessor
 	containerDelegate internal.ContainerDelegate
+	prepuller         image_cache.Prepuller
 	Guid              string
 }
 
@@ -211,6 +220,7 @@ func NewContainerOperation(
 	lrpProcessor internal.LRPProcessor,
 	taskProcessor internal.TaskProcessor,
 	containerDelegate internal.ContainerDelegate,
+	prepuller image_cache.Prepuller,
 	guid string,
 ) *ContainerOperation {
 	return &ContainerOperation{
@@ -218,6 +228,7 @@ func NewContainerOperation(
 		lrpProcessor:      lrpProcessor,
 		taskProcessor:     taskProcessor,
 		containerDelegate: containerDelegate,
+		prepuller:         prepuller,
 		Guid:              guid,
 	}
 }
@@ -254,6 +265,18 @@ func (o *ContainerOperation) Execute() {
 		o.taskProcessor.Process(logger, container)
 		return
 
+	case rep.PrepullLifecycle:
+		// A prepull container outlives its pull only when the pull was
+		// interrupted, for example by a restart of the rep. Left alone it
+		// would hold its slot forever.
+		if o.prepuller.Pulling(container.Guid) {
+			logger.Debug("skipped-prepull-container-being-pulled")
+			return
+		}
+		logger.Info("deleting-orphaned-prepull-container")
+		o.containerDelegate.DeleteContainer(logger, container.Guid)
+		return
+
 	default:
 		logger.Error("failed-to-process-container-with-unknown-lifecycle", fmt.Errorf("unknown lifecycle: %s", lifecycle))
 		return
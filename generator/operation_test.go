@@ -9,6 +9,7 @@ import (
 	"code.cloudfoundry.org/rep/generator"
 	"code.cloudfoundry.org/rep/generator/internal"
 	"code.cloudfoundry.org/rep/generator/internal/fake_internal"
+	"code.cloudfoundry.org/rep/image_cache/fake_image_cache"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gbytes"
@@ -35,8 +36,8 @@ var _ = Describe("Operation", func() {
 		})
 
 		Describe("Key", func() {
-			It("returns the InstanceGuid", func() {
-				Expect(residualLRPOperation.Key()).To(Equal("the-instance-guid"))
+			It("returns the container guid", func() {
+				Expect(residualLRPOperation.Key()).To(Equal(expectedContainerGuid))
 			})
 		})
 
@@ -47,11 +48,13 @@ var _ = Describe("Operation", func() {
 				residualLRPOperation.Execute()
 			})
 
-			It("checks whether the container exists", func() {
-				Expect(containerDelegate.GetContainerCallCount()).To(Equal(1))
+			It("checks whether the container exists under the current and legacy guids", func() {
+				Expect(containerDelegate.GetContainerCallCount()).To(Equal(2))
 				containerDelegateLogger, containerGuid := containerDelegate.GetContainerArgsForCall(0)
 				Expect(containerGuid).To(Equal(expectedContainerGuid))
 				Expect(containerDelegateLogger.SessionName()).To(Equal(sessionName))
+				_, containerGuid = containerDelegate.GetContainerArgsForCall(1)
+				Expect(containerGuid).To(Equal(instanceKey.GetInstanceGuid()))
 			})
 
 			It("logs its execution lifecycle", func() {
@@ -112,8 +115,8 @@ var _ = Describe("Operation", func() {
 		})
 
 		Describe("Key", func() {
-			It("returns the InstanceGuid", func() {
-				Expect(residualEvacuatingLRPOperation.Key()).To(Equal(instanceGuid))
+			It("returns the container guid", func() {
+				Expect(residualEvacuatingLRPOperation.Key()).To(Equal(expectedContainerGuid))
 			})
 		})
 
@@ -124,11 +127,13 @@ var _ = Describe("Operation", func() {
 				residualEvacuatingLRPOperation.Execute()
 			})
 
-			It("checks whether the container exists", func() {
-				Expect(containerDelegate.GetContainerCallCount()).To(Equal(1))
+			It("checks whether the container exists under the current and legacy guids", func() {
+				Expect(containerDelegate.GetContainerCallCount()).To(Equal(2))
 				containerDelegateLogger, containerGuid := containerDelegate.GetContainerArgsForCall(0)
 				Expect(containerGuid).To(Equal(expectedContainerGuid))
 				Expect(containerDelegateLogger.SessionName()).To(Equal(sessionName))
+				_, containerGuid = containerDelegate.GetContainerArgsForCall(1)
+				Expect(containerGuid).To(Equal(instanceKey.GetInstanceGuid()))
 			})
 
 			It("logs its execution lifecycle", func() {
@@ -187,8 +192,8 @@ var _ = Describe("Operation", func() {
 		})
 
 		Describe("Key", func() {
-			It("returns the InstanceGuid", func() {
-				Expect(residualJointLRPOperation.Key()).To(Equal(instanceGuid))
+			It("returns the container guid", func() {
+				Expect(residualJointLRPOperation.Key()).To(Equal(expectedContainerGuid))
 			})
 		})
 
@@ -199,11 +204,13 @@ var _ = Describe("Operation", func() {
 				residualJointLRPOperation.Execute()
 			})
 
-			It("checks whether the container exists", func() {
-				Expect(containerDelegate.GetContainerCallCount()).To(Equal(1))
+			It("checks whether the container exists under the current and legacy guids", func() {
+				Expect(containerDelegate.GetContainerCallCount()).To(Equal(2))
 				containerDelegateLogger, containerGuid := containerDelegate.GetContainerArgsForCall(0)
 				Expect(containerGuid).To(Equal(expectedContainerGuid))
 				Expect(containerDelegateLogger.SessionName()).To(Equal(sessionName))
+				_, containerGuid = containerDelegate.GetContainerArgsForCall(1)
+				Expect(containerGuid).To(Equal(instanceKey.GetInstanceGuid()))
 			})
 
 			It("logs its execution lifecycle", func() {
@@ -264,8 +271,8 @@ var _ = Describe("Operation", func() {
 		})
 
 		Describe("Key", func() {
-			It("returns the TaskGuid", func() {
-				Expect(residualTaskOperation.Key()).To(Equal("the-task-guid"))
+			It("returns the container guid", func() {
+				Expect(residualTaskOperation.Key()).To(Equal("task-the-task-guid"))
 			})
 		})
 
@@ -276,11 +283,13 @@ var _ = Describe("Operation", func() {
 				residualTaskOperation.Execute()
 			})
 
-			It("checks whether the container exists", func() {
-				Expect(containerDelegate.GetContainerCallCount()).To(Equal(1))
+			It("checks whether the container exists under the current and legacy guids", func() {
+				Expect(containerDelegate.GetContainerCallCount()).To(Equal(2))
 				containerDelegateLogger, containerGuid := containerDelegate.GetContainerArgsForCall(0)
-				Expect(containerGuid).To(Equal("the-task-guid"))
+				Expect(containerGuid).To(Equal("task-the-task-guid"))
 				Expect(containerDelegateLogger.SessionName()).To(Equal(sessionName))
+				_, containerGuid = containerDelegate.GetContainerArgsForCall(1)
+				Expect(containerGuid).To(Equal("the-task-guid"))
 			})
 
 			It("logs its execution lifecycle", func() {
@@ -332,6 +341,7 @@ var _ = Describe("Operation", func() {
 			containerDelegate  *fake_internal.FakeContainerDelegate
 			lrpProcessor       *fake_internal.FakeLRPProcessor
 			taskProcessor      *fake_internal.FakeTaskProcessor
+			prepuller          *fake_image_cache.FakePrepuller
 			containerOperation *generator.ContainerOperation
 			guid               string
 		)
@@ -340,8 +350,9 @@ var _ = Describe("Operation", func() {
 			containerDelegate = new(fake_internal.FakeContainerDelegate)
 			lrpProcessor = new(fake_internal.FakeLRPProcessor)
 			taskProcessor = new(fake_internal.FakeTaskProcessor)
+			prepuller = new(fake_image_cache.FakePrepuller)
 			guid = "the-guid"
-			containerOperation = generator.NewContainerOperation(logger, lrpProcessor, taskProcessor, containerDelegate, guid)
+			containerOperation = generator.NewContainerOperation(logger, lrpProcessor, taskProcessor, containerDelegate, prepuller, guid)
 		})
 
 		Describe("Key", func() {
@@ -431,6 +442,39 @@ var _ = Describe("Operation", func() {
 					})
 				})
 
+				Context("when the container has a prepull lifecycle tag", func() {
+					BeforeEach(func() {
+						container = executor.Container{
+							Guid: "prepull-guid",
+							Tags: executor.Tags{
+								rep.LifecycleTag: rep.PrepullLifecycle,
+							},
+						}
+						containerDelegate.GetContainerReturns(container, true)
+					})
+
+					It("deletes the container left behind by an interrupted pull", func() {
+						Expect(prepuller.PullingCallCount()).To(Equal(1))
+						Expect(prepuller.PullingArgsForCall(0)).To(Equal("prepull-guid"))
+
+						Expect(containerDelegate.DeleteContainerCallCount()).To(Equal(1))
+						_, deletedGuid := containerDelegate.DeleteContainerArgsForCall(0)
+						Expect(deletedGuid).To(Equal("prepull-guid"))
+						Expect(lrpProcessor.ProcessCallCount()).To(Equal(0))
+						Expect(taskProcessor.ProcessCallCount()).To(Equal(0))
+					})
+
+					Context("when the prepuller is still pulling into it", func() {
+						BeforeEach(func() {
+							prepuller.PullingReturns(true)
+						})
+
+						It("leaves the container to the prepuller", func() {
+							Expect(containerDelegate.DeleteContainerCallCount()).To(Equal(0))
+						})
+					})
+				})
+
 				Context("when the container has an unknown lifecycle tag", func() {
 					BeforeEach(func() {
 						container = executor.Container{
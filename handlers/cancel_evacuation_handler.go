@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep/evacuation/evacuation_context"
+)
+
+type CancelEvacuationHandler struct {
+	evacuatable evacuation_context.Evacuatable
+	logger      lager.Logger
+}
+
+// Cancel Evacuation Handler lets an operator return an evacuating cell to
+// service before the evacuation timeout
+func NewCancelEvacuationHandler(
+	logger lager.Logger,
+	evacuatable evacuation_context.Evacuatable,
+) *CancelEvacuationHandler {
+	return &CancelEvacuationHandler{
+		evacuatable: evacuatable,
+		logger:      logger,
+	}
+}
+
+func (h *CancelEvacuationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := h.logger.Session("handling-cancel-evacuation")
+	logger.Info("starting")
+	defer logger.Info("finished")
+
+	if !h.evacuatable.CancelEvacuation() {
+		logger.Info("no-evacuation-in-progress")
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,63 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/rep/evacuation/evacuation_context/fake_evacuation_context"
+	"code.cloudfoundry.org/rep/handlers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CancelEvacuationHandler", func() {
+	Describe("ServeHTTP", func() {
+		var (
+			logger          *lagertest.TestLogger
+			fakeEvacuatable *fake_evacuation_context.FakeEvacuatable
+			handler         *handlers.CancelEvacuationHandler
+
+			responseRecorder *httptest.ResponseRecorder
+		)
+
+		BeforeEach(func() {
+			logger = lagertest.NewTestLogger("test")
+			fakeEvacuatable = new(fake_evacuation_context.FakeEvacuatable)
+			handler = handlers.NewCancelEvacuationHandler(logger, fakeEvacuatable)
+			responseRecorder = httptest.NewRecorder()
+		})
+
+		JustBeforeEach(func() {
+			request, err := http.NewRequest("DELETE", "/evacuate", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			handler.ServeHTTP(responseRecorder, request)
+		})
+
+		Context("when the cell is evacuating", func() {
+			BeforeEach(func() {
+				fakeEvacuatable.CancelEvacuationReturns(true)
+			})
+
+			It("cancels the evacuation", func() {
+				Expect(fakeEvacuatable.CancelEvacuationCallCount()).To(Equal(1))
+			})
+
+			It("responds with 204 NO CONTENT", func() {
+				Expect(responseRecorder.Code).To(Equal(http.StatusNoContent))
+			})
+		})
+
+		Context("when the cell is not evacuating", func() {
+			BeforeEach(func() {
+				fakeEvacuatable.CancelEvacuationReturns(false)
+			})
+
+			It("responds with 409 CONFLICT", func() {
+				Expect(responseRecorder.Code).To(Equal(http.StatusConflict))
+			})
+		})
+	})
+})
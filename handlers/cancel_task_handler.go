@@ -5,6 +5,7 @@ import (
 
 	"code.cloudfoundry.org/executor"
 	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep"
 )
 
 type CancelTaskHandler struct {
@@ -30,7 +31,13 @@ func (h CancelTaskHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	go func() {
 		logger.Info("deleting-container")
-		err := h.executorClient.DeleteContainer(logger, taskGuid)
+		var err error
+		for _, guid := range rep.TaskContainerGuids(taskGuid) {
+			err = h.executorClient.DeleteContainer(logger, guid)
+			if err != executor.ErrContainerNotFound {
+				break
+			}
+		}
 		if err == executor.ErrContainerNotFound {
 			logger.Info("container-not-found")
 			return
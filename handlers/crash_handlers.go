@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep/crash_archive"
+)
+
+type CrashesHandler struct {
+	archive crash_archive.Archive
+	logger  lager.Logger
+}
+
+// CrashesHandler serves a route that is called by operators to list the
+// crashes captured for an LRP instance
+func NewCrashesHandler(
+	logger lager.Logger,
+	archive crash_archive.Archive,
+) *CrashesHandler {
+	return &CrashesHandler{
+		archive: archive,
+		logger:  logger,
+	}
+}
+
+func (h *CrashesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	processGuid := r.FormValue(":process_guid")
+	logger := h.logger.Session("handling-crashes", lager.Data{
+		"process-guid": processGuid,
+		"index":        r.FormValue(":index"),
+	})
+
+	index, err := strconv.ParseInt(r.FormValue(":index"), 10, 32)
+	if err != nil {
+		logger.Error("invalid-index", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	crashes, err := h.archive.List(processGuid, int32(index))
+	if err == crash_archive.ErrInvalidKey {
+		logger.Error("invalid-process-guid", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		logger.Error("failed-to-list-crashes", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	jsonBytes, err := json.Marshal(crashes)
+	if err != nil {
+		logger.Error("failed-to-marshal-response-payload", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(jsonBytes)))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonBytes)
+}
+
+type CrashFileHandler struct {
+	archive crash_archive.Archive
+	logger  lager.Logger
+}
+
+// CrashFileHandler serves a route that is called by operators to download a
+// file captured from a crashed LRP instance, as a tar stream
+func NewCrashFileHandler(
+	logger lager.Logger,
+	archive crash_archive.Archive,
+) *CrashFileHandler {
+	return &CrashFileHandler{
+		archive: archive,
+		logger:  logger,
+	}
+}
+
+func (h *CrashFileHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	processGuid := r.FormValue(":process_guid")
+	crashID := r.FormValue(":crash_id")
+	file := r.FormValue(":file")
+	logger := h.logger.Session("handling-crash-file", lager.Data{
+		"process-guid": processGuid,
+		"index":        r.FormValue(":index"),
+		"crash-id":     crashID,
+		"file":         file,
+	})
+
+	index, err := strconv.ParseInt(r.FormValue(":index"), 10, 32)
+	if err != nil {
+		logger.Error("invalid-index", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	stream, err := h.archive.Open(processGuid, int32(index), crashID, file)
+	switch err {
+	case nil:
+	case crash_archive.ErrInvalidKey:
+		logger.Error("invalid-crash-key", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	case crash_archive.ErrCrashNotFound:
+		logger.Info("crash-file-not-found")
+		w.WriteHeader(http.StatusNotFound)
+		return
+	default:
+		logger.Error("failed-to-open-crash-file", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer stream.Close()
+
+	w.Header().Set("Content-Type", "application/x-tar")
+	w.WriteHeader(http.StatusOK)
+	_, err = io.Copy(w, stream)
+	if err != nil {
+		logger.Error("failed-to-write-crash-file", err)
+	}
+}
@@ -0,0 +1,130 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/rep/crash_archive"
+	"code.cloudfoundry.org/rep/crash_archive/fake_crash_archive"
+	"code.cloudfoundry.org/rep/handlers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Crash handlers", func() {
+	var (
+		logger      *lagertest.TestLogger
+		fakeArchive *fake_crash_archive.FakeArchive
+		resp        *httptest.ResponseRecorder
+		values      url.Values
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeArchive = new(fake_crash_archive.FakeArchive)
+		resp = httptest.NewRecorder()
+
+		values = url.Values{}
+		values.Set(":process_guid", "process-guid")
+		values.Set(":index", "3")
+	})
+
+	newRequest := func() *http.Request {
+		req, err := http.NewRequest("GET", "", nil)
+		Expect(err).NotTo(HaveOccurred())
+		req.URL.RawQuery = values.Encode()
+		return req
+	}
+
+	Describe("CrashesHandler", func() {
+		serve := func() {
+			handlers.NewCrashesHandler(logger, fakeArchive).ServeHTTP(resp, newRequest())
+		}
+
+		It("lists the crashes of the instance", func() {
+			crashes := []crash_archive.Crash{{ID: "1", ProcessGuid: "process-guid", Index: 3}}
+			fakeArchive.ListReturns(crashes, nil)
+
+			serve()
+
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			processGuid, index := fakeArchive.ListArgsForCall(0)
+			Expect(processGuid).To(Equal("process-guid"))
+			Expect(index).To(Equal(int32(3)))
+
+			var listed []crash_archive.Crash
+			Expect(json.Unmarshal(resp.Body.Bytes(), &listed)).To(Succeed())
+			Expect(listed).To(Equal(crashes))
+		})
+
+		It("rejects an invalid index", func() {
+			values.Set(":index", "three")
+			serve()
+
+			Expect(resp.Code).To(Equal(http.StatusBadRequest))
+			Expect(fakeArchive.ListCallCount()).To(BeZero())
+		})
+
+		It("rejects an invalid process guid", func() {
+			fakeArchive.ListReturns(nil, crash_archive.ErrInvalidKey)
+			serve()
+
+			Expect(resp.Code).To(Equal(http.StatusBadRequest))
+		})
+
+		It("fails when the crashes cannot be listed", func() {
+			fakeArchive.ListReturns(nil, errors.New("boom"))
+			serve()
+
+			Expect(resp.Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+
+	Describe("CrashFileHandler", func() {
+		BeforeEach(func() {
+			values.Set(":crash_id", "1234")
+			values.Set(":file", "0-logs.tar")
+		})
+
+		serve := func() {
+			handlers.NewCrashFileHandler(logger, fakeArchive).ServeHTTP(resp, newRequest())
+		}
+
+		It("streams the file", func() {
+			fakeArchive.OpenReturns(ioutil.NopCloser(strings.NewReader("tar-contents")), nil)
+
+			serve()
+
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			Expect(resp.Header().Get("Content-Type")).To(Equal("application/x-tar"))
+			Expect(resp.Body.String()).To(Equal("tar-contents"))
+
+			processGuid, index, crashID, file := fakeArchive.OpenArgsForCall(0)
+			Expect(processGuid).To(Equal("process-guid"))
+			Expect(index).To(Equal(int32(3)))
+			Expect(crashID).To(Equal("1234"))
+			Expect(file).To(Equal("0-logs.tar"))
+		})
+
+		It("responds with 404 when the file is not in the archive", func() {
+			fakeArchive.OpenReturns(nil, crash_archive.ErrCrashNotFound)
+			serve()
+
+			Expect(resp.Code).To(Equal(http.StatusNotFound))
+		})
+
+		It("rejects an invalid key", func() {
+			fakeArchive.OpenReturns(nil, crash_archive.ErrInvalidKey)
+			serve()
+
+			Expect(resp.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+})
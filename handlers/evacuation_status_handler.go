@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep/evacuation/evacuation_context"
+)
+
+type EvacuationStatusHandler struct {
+	evacuationStatusReporter evacuation_context.EvacuationStatusReporter
+	logger                   lager.Logger
+}
+
+// Evacuation Status Handler reports the progress of an evacuation to operators
+// and the rep drain script
+func NewEvacuationStatusHandler(
+	logger lager.Logger,
+	evacuationStatusReporter evacuation_context.EvacuationStatusReporter,
+) *EvacuationStatusHandler {
+	return &EvacuationStatusHandler{
+		evacuationStatusReporter: evacuationStatusReporter,
+		logger:                   logger,
+	}
+}
+
+func (h *EvacuationStatusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := h.logger.Session("handling-evacuation-status")
+
+	jsonBytes, err := json.Marshal(h.evacuationStatusReporter.EvacuationStatus())
+	if err != nil {
+		logger.Error("failed-to-marshal-response-payload", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(jsonBytes)))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonBytes)
+}
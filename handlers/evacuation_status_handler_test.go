@@ -0,0 +1,38 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"code.cloudfoundry.org/rep"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EvacuationStatusHandler", func() {
+	var expectedStatus rep.EvacuationStatus
+
+	BeforeEach(func() {
+		expectedStatus = rep.EvacuationStatus{
+			Evacuating: true,
+			StartedAt:  time.Unix(1000, 0).UTC(),
+			Timeout:    10 * time.Minute,
+			RemainingContainers: map[string]map[string]int{
+				rep.TaskLifecycle: {"running": 1},
+			},
+			FailedContainers: []rep.EvacuationFailure{},
+		}
+		fakeEvacuationStatusReporter.EvacuationStatusReturns(expectedStatus)
+	})
+
+	It("responds with the evacuation status", func() {
+		status, body := Request(rep.EvacuationStatusRoute, nil, nil)
+		Expect(status).To(Equal(http.StatusOK))
+
+		var evacuationStatus rep.EvacuationStatus
+		Expect(json.Unmarshal(body, &evacuationStatus)).To(Succeed())
+		Expect(evacuationStatus).To(Equal(expectedStatus))
+	})
+})
@@ -4,7 +4,9 @@ import (
 	"code.cloudfoundry.org/executor"
 	"code.cloudfoundry.org/lager"
 	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/crash_archive"
 	"code.cloudfoundry.org/rep/evacuation/evacuation_context"
+	"code.cloudfoundry.org/rep/image_cache"
 	"github.com/tedsuo/rata"
 )
 
@@ -12,6 +14,9 @@ func New(
 	localCellClient rep.AuctionCellClient,
 	executorClient executor.Client,
 	evacuatable evacuation_context.Evacuatable,
+	evacuationStatusReporter evacuation_context.EvacuationStatusReporter,
+	prepuller image_cache.Prepuller,
+	crashArchive crash_archive.Archive,
 	logger lager.Logger,
 ) rata.Handlers {
 	handlers := rata.Handlers{
@@ -19,11 +24,52 @@ func New(
 		rep.PerformRoute:   &perform{rep: localCellClient, logger: logger},
 		rep.Sim_ResetRoute: &reset{rep: localCellClient, logger: logger},
 
+		rep.ReserveRoute:     &reserve{rep: localCellClient, logger: logger},
+		rep.CommitHoldRoute:  &commitHold{rep: localCellClient, logger: logger},
+		rep.ReleaseHoldRoute: &releaseHold{rep: localCellClient, logger: logger},
+
 		rep.StopLRPInstanceRoute: NewStopLRPInstanceHandler(logger, executorClient),
 		rep.CancelTaskRoute:      NewCancelTaskHandler(logger, executorClient),
 
-		rep.PingRoute:     NewPingHandler(),
-		rep.EvacuateRoute: NewEvacuationHandler(logger, evacuatable),
+		rep.PingRoute:             NewPingHandler(),
+		rep.EvacuateRoute:         NewEvacuationHandler(logger, evacuatable),
+		rep.EvacuationStatusRoute: NewEvacuationStatusHandler(logger, evacuationStatusReporter),
+		rep.CancelEvacuationRoute: NewCancelEvacuationHandler(logger, evacuatable),
+		rep.PrepullImagesRoute:    NewPrepullImagesHandler(logger, prepuller),
+		rep.CrashesRoute:          NewCrashesHandler(logger, crashArchive),
+		rep.CrashFileRoute:        NewCrashFileHandler(logger, crashArchive),
+	}
+
+	return handlers
+}
+
+// NewSimulation routes every request to the given simulated cell, which
+// stands in for the executor as well as the auction cell rep.
+func NewSimulation(
+	simClient rep.SimClient,
+	evacuatable evacuation_context.Evacuatable,
+	evacuationStatusReporter evacuation_context.EvacuationStatusReporter,
+	logger lager.Logger,
+) rata.Handlers {
+	handlers := rata.Handlers{
+		rep.StateRoute:     &state{rep: simClient, logger: logger},
+		rep.PerformRoute:   &perform{rep: simClient, logger: logger},
+		rep.Sim_ResetRoute: &reset{rep: simClient, logger: logger},
+
+		rep.ReserveRoute:     &reserve{rep: simClient, logger: logger},
+		rep.CommitHoldRoute:  &commitHold{rep: simClient, logger: logger},
+		rep.ReleaseHoldRoute: &releaseHold{rep: simClient, logger: logger},
+
+		rep.StopLRPInstanceRoute: &simStopLRPInstance{rep: simClient, logger: logger},
+		rep.CancelTaskRoute:      &simCancelTask{rep: simClient, logger: logger},
+
+		rep.PingRoute:             NewPingHandler(),
+		rep.EvacuateRoute:         NewEvacuationHandler(logger, evacuatable),
+		rep.EvacuationStatusRoute: NewEvacuationStatusHandler(logger, evacuationStatusReporter),
+		rep.CancelEvacuationRoute: NewCancelEvacuationHandler(logger, evacuatable),
+		rep.PrepullImagesRoute:    &simUnsupported{logger: logger},
+		rep.CrashesRoute:          &simUnsupported{logger: logger},
+		rep.CrashFileRoute:        &simUnsupported{logger: logger},
 	}
 
 	return handlers
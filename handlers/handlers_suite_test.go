@@ -11,8 +11,10 @@ import (
 	executorfakes "code.cloudfoundry.org/executor/fakes"
 	"code.cloudfoundry.org/lager/lagertest"
 	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/crash_archive/fake_crash_archive"
 	"code.cloudfoundry.org/rep/evacuation/evacuation_context/fake_evacuation_context"
 	"code.cloudfoundry.org/rep/handlers"
+	"code.cloudfoundry.org/rep/image_cache/fake_image_cache"
 	"code.cloudfoundry.org/rep/repfakes"
 
 	. "github.com/onsi/ginkgo"
@@ -31,6 +33,7 @@ var server *httptest.Server
 var requestGenerator *rata.RequestGenerator
 var client *http.Client
 var fakeLocalRep *repfakes.FakeSimClient
+var fakeEvacuationStatusReporter *fake_evacuation_context.FakeEvacuationStatusReporter
 var repGuid string
 
 var _ = BeforeEach(func() {
@@ -39,7 +42,8 @@ var _ = BeforeEach(func() {
 	fakeLocalRep = new(repfakes.FakeSimClient)
 	fakeExecutorClient := new(executorfakes.FakeClient)
 	fakeEvacuatable := new(fake_evacuation_context.FakeEvacuatable)
-	handler, err := rata.NewRouter(rep.Routes, handlers.New(fakeLocalRep, fakeExecutorClient, fakeEvacuatable, logger))
+	fakeEvacuationStatusReporter = new(fake_evacuation_context.FakeEvacuationStatusReporter)
+	handler, err := rata.NewRouter(rep.Routes, handlers.New(fakeLocalRep, fakeExecutorClient, fakeEvacuatable, fakeEvacuationStatusReporter, new(fake_image_cache.FakePrepuller), new(fake_crash_archive.FakeArchive), logger))
 	Expect(err).NotTo(HaveOccurred())
 	server = httptest.NewServer(handler)
 
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep"
+)
+
+type reserve struct {
+	rep    rep.AuctionCellClient
+	logger lager.Logger
+}
+
+func (h *reserve) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := h.logger.Session("auction-reserve")
+	logger.Info("handling")
+
+	var request rep.HoldRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		logger.Error("failed-to-unmarshal", err)
+		return
+	}
+
+	hold, err := h.rep.Reserve(request)
+	switch err {
+	case nil:
+	case rep.ErrorInvalidHoldRequest:
+		w.WriteHeader(http.StatusBadRequest)
+		logger.Error("invalid-hold-request", err)
+		return
+	case rep.ErrorInsufficientResources:
+		w.WriteHeader(http.StatusConflict)
+		logger.Info("insufficient-resources")
+		return
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		logger.Error("failed-to-reserve", err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(hold)
+	logger.Info("success", lager.Data{"hold-id": hold.ID})
+}
+
+type commitHold struct {
+	rep    rep.AuctionCellClient
+	logger lager.Logger
+}
+
+func (h *commitHold) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	holdID := r.FormValue(":hold_id")
+
+	logger := h.logger.Session("auction-commit-hold", lager.Data{"hold-id": holdID})
+	logger.Info("handling")
+
+	var work rep.Work
+	err := json.NewDecoder(r.Body).Decode(&work)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		logger.Error("failed-to-unmarshal", err)
+		return
+	}
+
+	failedWork, err := h.rep.CommitHold(holdID, work)
+	switch err {
+	case nil:
+	case rep.ErrorHoldNotFound:
+		w.WriteHeader(http.StatusNotFound)
+		logger.Info("hold-not-found")
+		return
+	case rep.ErrorWorkExceedsHold:
+		w.WriteHeader(http.StatusBadRequest)
+		logger.Error("work-exceeds-hold", err)
+		return
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		logger.Error("failed-to-commit-hold", err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(failedWork)
+	logger.Info("success")
+}
+
+type releaseHold struct {
+	rep    rep.AuctionCellClient
+	logger lager.Logger
+}
+
+func (h *releaseHold) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	holdID := r.FormValue(":hold_id")
+
+	logger := h.logger.Session("auction-release-hold", lager.Data{"hold-id": holdID})
+	logger.Info("handling")
+
+	err := h.rep.ReleaseHold(holdID)
+	switch err {
+	case nil:
+	case rep.ErrorHoldNotFound:
+		w.WriteHeader(http.StatusNotFound)
+		logger.Info("hold-not-found")
+		return
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		logger.Error("failed-to-release-hold", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	logger.Info("success")
+}
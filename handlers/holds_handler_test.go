@@ -0,0 +1,149 @@
+package handlers_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"time"
+
+	"code.cloudfoundry.org/rep"
+	"github.com/tedsuo/rata"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Holds", func() {
+	var hold rep.Hold
+
+	BeforeEach(func() {
+		hold = rep.NewHold("hold-id", rep.NewResources(512, 1024, 2), time.Unix(1000, 0).UTC())
+	})
+
+	Describe("Reserve", func() {
+		var request rep.HoldRequest
+
+		BeforeEach(func() {
+			request = rep.NewHoldRequest(512, 1024, 2, 30*time.Second)
+		})
+
+		It("succeeds, returning the hold", func() {
+			fakeLocalRep.ReserveReturns(hold, nil)
+
+			status, body := Request(rep.ReserveRoute, nil, JSONReaderFor(request))
+			Expect(status).To(Equal(http.StatusOK))
+			Expect(body).To(MatchJSON(JSONFor(hold)))
+
+			Expect(fakeLocalRep.ReserveCallCount()).To(Equal(1))
+			Expect(fakeLocalRep.ReserveArgsForCall(0)).To(Equal(request))
+		})
+
+		Context("when the cell has insufficient resources", func() {
+			It("responds with a conflict", func() {
+				fakeLocalRep.ReserveReturns(rep.Hold{}, rep.ErrorInsufficientResources)
+
+				status, body := Request(rep.ReserveRoute, nil, JSONReaderFor(request))
+				Expect(status).To(Equal(http.StatusConflict))
+				Expect(body).To(BeEmpty())
+			})
+		})
+
+		Context("when the hold request is invalid", func() {
+			It("responds with a bad request", func() {
+				fakeLocalRep.ReserveReturns(rep.Hold{}, rep.ErrorInvalidHoldRequest)
+
+				status, _ := Request(rep.ReserveRoute, nil, JSONReaderFor(request))
+				Expect(status).To(Equal(http.StatusBadRequest))
+			})
+		})
+
+		Context("when reserving fails", func() {
+			It("responds with an internal server error", func() {
+				fakeLocalRep.ReserveReturns(rep.Hold{}, errors.New("kaboom"))
+
+				status, _ := Request(rep.ReserveRoute, nil, JSONReaderFor(request))
+				Expect(status).To(Equal(http.StatusInternalServerError))
+			})
+		})
+
+		Context("with invalid JSON", func() {
+			It("fails", func() {
+				status, _ := Request(rep.ReserveRoute, nil, bytes.NewBufferString("∆"))
+				Expect(status).To(Equal(http.StatusBadRequest))
+				Expect(fakeLocalRep.ReserveCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	Describe("CommitHold", func() {
+		var work, failedWork rep.Work
+
+		BeforeEach(func() {
+			work = rep.Work{
+				Tasks: []rep.Task{
+					rep.NewTask("a", "domain", rep.NewResource(128, 256, "some-rootfs", nil)),
+					rep.NewTask("b", "domain", rep.NewResource(128, 256, "some-rootfs", nil)),
+				},
+			}
+			failedWork = rep.Work{Tasks: work.Tasks[1:]}
+		})
+
+		It("succeeds, returning any failed work", func() {
+			fakeLocalRep.CommitHoldReturns(failedWork, nil)
+
+			status, body := Request(rep.CommitHoldRoute, rata.Params{"hold_id": "hold-id"}, JSONReaderFor(work))
+			Expect(status).To(Equal(http.StatusOK))
+			Expect(body).To(MatchJSON(JSONFor(failedWork)))
+
+			Expect(fakeLocalRep.CommitHoldCallCount()).To(Equal(1))
+			holdID, committedWork := fakeLocalRep.CommitHoldArgsForCall(0)
+			Expect(holdID).To(Equal("hold-id"))
+			Expect(committedWork).To(Equal(work))
+		})
+
+		Context("when the hold does not exist", func() {
+			It("responds with not found", func() {
+				fakeLocalRep.CommitHoldReturns(rep.Work{}, rep.ErrorHoldNotFound)
+
+				status, _ := Request(rep.CommitHoldRoute, rata.Params{"hold_id": "hold-id"}, JSONReaderFor(work))
+				Expect(status).To(Equal(http.StatusNotFound))
+			})
+		})
+
+		Context("when the work exceeds the hold", func() {
+			It("responds with a bad request", func() {
+				fakeLocalRep.CommitHoldReturns(rep.Work{}, rep.ErrorWorkExceedsHold)
+
+				status, _ := Request(rep.CommitHoldRoute, rata.Params{"hold_id": "hold-id"}, JSONReaderFor(work))
+				Expect(status).To(Equal(http.StatusBadRequest))
+			})
+		})
+
+		Context("with invalid JSON", func() {
+			It("fails", func() {
+				status, _ := Request(rep.CommitHoldRoute, rata.Params{"hold_id": "hold-id"}, bytes.NewBufferString("∆"))
+				Expect(status).To(Equal(http.StatusBadRequest))
+				Expect(fakeLocalRep.CommitHoldCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	Describe("ReleaseHold", func() {
+		It("releases the hold", func() {
+			status, _ := Request(rep.ReleaseHoldRoute, rata.Params{"hold_id": "hold-id"}, nil)
+			Expect(status).To(Equal(http.StatusNoContent))
+
+			Expect(fakeLocalRep.ReleaseHoldCallCount()).To(Equal(1))
+			Expect(fakeLocalRep.ReleaseHoldArgsForCall(0)).To(Equal("hold-id"))
+		})
+
+		Context("when the hold does not exist", func() {
+			It("responds with not found", func() {
+				fakeLocalRep.ReleaseHoldReturns(rep.ErrorHoldNotFound)
+
+				status, _ := Request(rep.ReleaseHoldRoute, rata.Params{"hold_id": "hold-id"}, nil)
+				Expect(status).To(Equal(http.StatusNotFound))
+			})
+		})
+	})
+})
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/image_cache"
+)
+
+type PrepullImagesHandler struct {
+	prepuller image_cache.Prepuller
+	logger    lager.Logger
+}
+
+// PrepullImagesHandler serves a route that is called by operators to
+// download docker images onto the cell ahead of a large deployment
+func NewPrepullImagesHandler(
+	logger lager.Logger,
+	prepuller image_cache.Prepuller,
+) *PrepullImagesHandler {
+	return &PrepullImagesHandler{
+		prepuller: prepuller,
+		logger:    logger,
+	}
+}
+
+func (h *PrepullImagesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := h.logger.Session("handling-prepull-images")
+	logger.Info("starting")
+	defer logger.Info("finished")
+
+	var request rep.PrepullRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		logger.Error("failed-to-unmarshal", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = request.Validate()
+	if err != nil {
+		logger.Error("invalid-prepull-request", err, lager.Data{"images": request.Images})
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	h.prepuller.Prepull(logger, request.Images)
+
+	w.WriteHeader(http.StatusAccepted)
+}
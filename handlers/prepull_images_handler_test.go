@@ -0,0 +1,67 @@
+package handlers_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/handlers"
+	"code.cloudfoundry.org/rep/image_cache/fake_image_cache"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PrepullImagesHandler", func() {
+	var (
+		logger        *lagertest.TestLogger
+		fakePrepuller *fake_image_cache.FakePrepuller
+		handler       *handlers.PrepullImagesHandler
+
+		responseRecorder *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakePrepuller = new(fake_image_cache.FakePrepuller)
+		handler = handlers.NewPrepullImagesHandler(logger, fakePrepuller)
+		responseRecorder = httptest.NewRecorder()
+	})
+
+	serve := func(body string) {
+		request, err := http.NewRequest("POST", "/v1/images/prepull", bytes.NewBufferString(body))
+		Expect(err).NotTo(HaveOccurred())
+		handler.ServeHTTP(responseRecorder, request)
+	}
+
+	It("starts pulling the images and responds with 202 ACCEPTED", func() {
+		serve(JSONFor(rep.PrepullRequest{Images: []string{"docker:///cloudfoundry/cflinuxfs2", "ubuntu:16.04"}}))
+
+		Expect(responseRecorder.Code).To(Equal(http.StatusAccepted))
+		Expect(fakePrepuller.PrepullCallCount()).To(Equal(1))
+		_, images := fakePrepuller.PrepullArgsForCall(0)
+		Expect(images).To(Equal([]string{"docker:///cloudfoundry/cflinuxfs2", "ubuntu:16.04"}))
+	})
+
+	It("rejects requests without images", func() {
+		serve(`{"images": []}`)
+
+		Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+		Expect(fakePrepuller.PrepullCallCount()).To(BeZero())
+	})
+
+	It("rejects invalid images", func() {
+		serve(JSONFor(rep.PrepullRequest{Images: []string{"docker:opaque"}}))
+
+		Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+		Expect(fakePrepuller.PrepullCallCount()).To(BeZero())
+	})
+
+	It("rejects malformed requests", func() {
+		serve(`{"images":`)
+
+		Expect(responseRecorder.Code).To(Equal(http.StatusBadRequest))
+	})
+})
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep"
+)
+
+type simStopLRPInstance struct {
+	rep    rep.SimClient
+	logger lager.Logger
+}
+
+func (h *simStopLRPInstance) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	processGuid := r.FormValue(":process_guid")
+	instanceGuid := r.FormValue(":instance_guid")
+
+	logger := h.logger.Session("sim-stop-lrp-instance", lager.Data{
+		"process-guid":  processGuid,
+		"instance-guid": instanceGuid,
+	})
+
+	if processGuid == "" || instanceGuid == "" {
+		logger.Error("missing-guid", nil)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	key := models.ActualLRPKey{ProcessGuid: processGuid}
+	instanceKey := models.ActualLRPInstanceKey{InstanceGuid: instanceGuid}
+	err := h.rep.StopLRPInstance(key, instanceKey)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logger.Error("failed-to-stop-lrp-instance", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+type simCancelTask struct {
+	rep    rep.SimClient
+	logger lager.Logger
+}
+
+func (h *simCancelTask) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	taskGuid := r.FormValue(":task_guid")
+
+	logger := h.logger.Session("sim-cancel-task", lager.Data{
+		"task-guid": taskGuid,
+	})
+
+	err := h.rep.CancelTask(taskGuid)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logger.Error("failed-to-cancel-task", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// simUnsupported serves routes that make no sense for a simulated cell.
+type simUnsupported struct {
+	logger lager.Logger
+}
+
+func (h *simUnsupported) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("sim-unsupported-route", lager.Data{"path": r.URL.Path})
+	w.WriteHeader(http.StatusNotImplemented)
+}
@@ -0,0 +1,77 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/evacuation/evacuation_context/fake_evacuation_context"
+	"code.cloudfoundry.org/rep/handlers"
+	"code.cloudfoundry.org/rep/repfakes"
+	"github.com/tedsuo/rata"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Simulation Handlers", func() {
+	var (
+		fakeSimRep   *repfakes.FakeSimClient
+		simServer    *httptest.Server
+		simRequests  *rata.RequestGenerator
+		simRequestTo func(name string, params rata.Params) int
+	)
+
+	BeforeEach(func() {
+		fakeSimRep = new(repfakes.FakeSimClient)
+		handler, err := rata.NewRouter(rep.Routes, handlers.NewSimulation(
+			fakeSimRep,
+			new(fake_evacuation_context.FakeEvacuatable),
+			new(fake_evacuation_context.FakeEvacuationStatusReporter),
+			lagertest.NewTestLogger("sim-handlers"),
+		))
+		Expect(err).NotTo(HaveOccurred())
+		simServer = httptest.NewServer(handler)
+		simRequests = rata.NewRequestGenerator(simServer.URL, rep.Routes)
+
+		simRequestTo = func(name string, params rata.Params) int {
+			request, err := simRequests.CreateRequest(name, params, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			response, err := http.DefaultClient.Do(request)
+			Expect(err).NotTo(HaveOccurred())
+			response.Body.Close()
+
+			return response.StatusCode
+		}
+	})
+
+	AfterEach(func() {
+		simServer.Close()
+	})
+
+	It("stops LRP instances on the simulated cell", func() {
+		status := simRequestTo(rep.StopLRPInstanceRoute, rata.Params{"process_guid": "some-process-guid", "instance_guid": "some-instance-guid"})
+		Expect(status).To(Equal(http.StatusAccepted))
+
+		Expect(fakeSimRep.StopLRPInstanceCallCount()).To(Equal(1))
+		key, instanceKey := fakeSimRep.StopLRPInstanceArgsForCall(0)
+		Expect(key.ProcessGuid).To(Equal("some-process-guid"))
+		Expect(instanceKey.InstanceGuid).To(Equal("some-instance-guid"))
+	})
+
+	It("cancels tasks on the simulated cell", func() {
+		status := simRequestTo(rep.CancelTaskRoute, rata.Params{"task_guid": "some-task-guid"})
+		Expect(status).To(Equal(http.StatusAccepted))
+
+		Expect(fakeSimRep.CancelTaskCallCount()).To(Equal(1))
+		Expect(fakeSimRep.CancelTaskArgsForCall(0)).To(Equal("some-task-guid"))
+	})
+
+	It("resets the simulated cell", func() {
+		status := simRequestTo(rep.Sim_ResetRoute, nil)
+		Expect(status).To(Equal(http.StatusOK))
+		Expect(fakeSimRep.ResetCallCount()).To(Equal(1))
+	})
+})
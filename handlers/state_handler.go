@@ -18,6 +18,13 @@ func (h *state) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	logger.Info("handling")
 
 	state, err := h.rep.State()
+	if err == rep.ErrCellUnhealthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(state)
+		logger.Error("cell-unhealthy", err, lager.Data{"health": state.Health})
+		return
+	}
+
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		logger.Error("failed-to-fetch-state", err)
@@ -31,6 +31,22 @@ var _ = Describe("State", func() {
 		})
 	})
 
+	Context("when the cell is unhealthy", func() {
+		It("responds with service unavailable and the state", func() {
+			repState := rep.CellState{
+				Health: rep.CellHealth{
+					State:   rep.CellUnhealthy,
+					Reasons: []string{"garden health check failed"},
+				},
+			}
+			fakeLocalRep.StateReturns(repState, rep.ErrCellUnhealthy)
+
+			status, body := Request(rep.StateRoute, nil, nil)
+			Expect(status).To(Equal(http.StatusServiceUnavailable))
+			Expect(body).To(MatchJSON(JSONFor(repState)))
+		})
+	})
+
 	Context("when the state call fails", func() {
 		It("fails", func() {
 			fakeLocalRep.StateReturns(rep.CellState{}, errors.New("boom"))
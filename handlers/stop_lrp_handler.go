@@ -44,7 +44,13 @@ func (h StopLRPInstanceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	err := h.client.StopContainer(logger, rep.LRPContainerGuid(processGuid, instanceGuid))
+	var err error
+	for _, guid := range rep.LRPContainerGuids(processGuid, instanceGuid) {
+		err = h.client.StopContainer(logger, guid)
+		if err != executor.ErrContainerNotFound {
+			break
+		}
+	}
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		logger.Error("failed-to-stop-container", err)
@@ -8,9 +8,11 @@ import (
 	"net/http/httptest"
 	"net/url"
 
+	"code.cloudfoundry.org/executor"
 	executorfakes "code.cloudfoundry.org/executor/fakes"
 	"code.cloudfoundry.org/lager"
 	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/rep"
 	"code.cloudfoundry.org/rep/handlers"
 
 	. "github.com/onsi/ginkgo"
@@ -70,6 +72,27 @@ var _ = Describe("StopLRPInstanceHandler", func() {
 			})
 		})
 
+		Context("and the container has a legacy guid", func() {
+			BeforeEach(func() {
+				fakeClient.StopContainerStub = func(_ lager.Logger, guid string) error {
+					if guid != instanceGuid {
+						return executor.ErrContainerNotFound
+					}
+					return nil
+				}
+			})
+
+			It("stops the legacy container", func() {
+				Expect(resp.Code).To(Equal(http.StatusAccepted))
+				Expect(fakeClient.StopContainerCallCount()).To(Equal(2))
+
+				_, guid := fakeClient.StopContainerArgsForCall(0)
+				Expect(guid).To(Equal(rep.LRPContainerGuid(processGuid, instanceGuid)))
+				_, guid = fakeClient.StopContainerArgsForCall(1)
+				Expect(guid).To(Equal(instanceGuid))
+			})
+		})
+
 		Context("but StopContainer fails", func() {
 			BeforeEach(func() {
 				fakeClient.StopContainerReturns(errors.New("fail"))
@@ -2,6 +2,7 @@ package harmonizer
 
 import (
 	"os"
+	"sync"
 	"time"
 
 	"code.cloudfoundry.org/clock"
@@ -17,6 +18,7 @@ const repBulkSyncDuration = metric.Duration("RepBulkSyncDuration")
 type Bulker struct {
 	logger lager.Logger
 
+	intervalLock           sync.Mutex
 	pollInterval           time.Duration
 	evacuationPollInterval time.Duration
 	evacuationNotifier     evacuation_context.EvacuationNotifier
@@ -48,16 +50,19 @@ func NewBulker(
 
 func (b *Bulker) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
 	evacuateNotify := b.evacuationNotifier.EvacuateNotify()
+	var cancelNotify <-chan struct{}
 	close(ready)
 
 	logger := b.logger.Session("running-bulker")
 
+	pollInterval, evacuationPollInterval := b.intervals()
 	logger.Info("starting", lager.Data{
-		"interval": b.pollInterval.String(),
+		"interval": pollInterval.String(),
 	})
 	defer logger.Info("finished")
 
-	interval := b.pollInterval
+	evacuating := false
+	interval := pollInterval
 
 	timer := b.clock.NewTimer(interval)
 	defer timer.Stop()
@@ -69,9 +74,18 @@ func (b *Bulker) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
 		case <-evacuateNotify:
 			timer.Stop()
 			evacuateNotify = nil
+			cancelNotify = b.evacuationNotifier.CancelNotify()
 
 			logger.Info("notified-of-evacuation")
-			interval = b.evacuationPollInterval
+			evacuating = true
+
+		case <-cancelNotify:
+			timer.Stop()
+			cancelNotify = nil
+			evacuateNotify = b.evacuationNotifier.EvacuateNotify()
+
+			logger.Info("notified-of-evacuation-cancellation")
+			evacuating = false
 
 		case signal := <-signals:
 			logger.Info("received-signal", lager.Data{"signal": signal.String()})
@@ -79,10 +93,32 @@ func (b *Bulker) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
 		}
 
 		b.sync(logger)
+
+		pollInterval, evacuationPollInterval = b.intervals()
+		interval = pollInterval
+		if evacuating {
+			interval = evacuationPollInterval
+		}
 		timer.Reset(interval)
 	}
 }
 
+// SetPollIntervals changes the intervals used from the next sync onwards.
+func (b *Bulker) SetPollIntervals(pollInterval, evacuationPollInterval time.Duration) {
+	b.intervalLock.Lock()
+	defer b.intervalLock.Unlock()
+
+	b.pollInterval = pollInterval
+	b.evacuationPollInterval = evacuationPollInterval
+}
+
+func (b *Bulker) intervals() (time.Duration, time.Duration) {
+	b.intervalLock.Lock()
+	defer b.intervalLock.Unlock()
+
+	return b.pollInterval, b.evacuationPollInterval
+}
+
 func (b *Bulker) sync(logger lager.Logger) {
 	logger = logger.Session("sync")
 
@@ -130,6 +130,19 @@ var _ = Describe("Bulker", func() {
 		})
 	})
 
+	Context("when the poll intervals are changed", func() {
+		JustBeforeEach(func() {
+			bulker.SetPollIntervals(5*time.Second, evacuationPollInterval)
+			fakeClock.WaitForWatcherAndIncrement(pollInterval + 1)
+			Eventually(fakeGenerator.BatchOperationsCallCount).Should(Equal(1))
+		})
+
+		It("uses the new interval after the next sync", func() {
+			fakeClock.WaitForWatcherAndIncrement(5 * time.Second)
+			Eventually(fakeGenerator.BatchOperationsCallCount).Should(Equal(2))
+		})
+	})
+
 	Context("when the poll interval has not elapsed", func() {
 		JustBeforeEach(func() {
 			fakeClock.WaitForWatcherAndIncrement(pollInterval - 1)
@@ -160,5 +173,26 @@ var _ = Describe("Bulker", func() {
 				Consistently(fakeGenerator.BatchOperationsCallCount).Should(Equal(2))
 			})
 		})
+
+		Context("when the evacuation is cancelled", func() {
+			JustBeforeEach(func() {
+				Eventually(fakeGenerator.BatchOperationsCallCount).Should(Equal(1))
+				evacuatable.CancelEvacuation()
+				Eventually(fakeGenerator.BatchOperationsCallCount).Should(Equal(2))
+			})
+
+			It("reverts to the poll interval", func() {
+				fakeClock.Increment(evacuationPollInterval + time.Second)
+				Consistently(fakeGenerator.BatchOperationsCallCount).Should(Equal(2))
+
+				fakeClock.Increment(pollInterval)
+				Eventually(fakeGenerator.BatchOperationsCallCount).Should(Equal(3))
+			})
+
+			It("is notified when evacuation starts again", func() {
+				evacuatable.Evacuate()
+				Eventually(fakeGenerator.BatchOperationsCallCount).Should(Equal(3))
+			})
+		})
 	})
 })
@@ -0,0 +1,44 @@
+package rep
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrCellUnhealthy = errors.New("internal cell healthcheck failed")
+
+type CellHealthState string
+
+const (
+	// CellHealthy cells pass all of their checks and accept work.
+	CellHealthy CellHealthState = "healthy"
+	// CellDegraded cells fail some of their checks, but can still report
+	// their state. They do not accept work.
+	CellDegraded CellHealthState = "degraded"
+	// CellUnhealthy cells fail their container backend health check.
+	CellUnhealthy CellHealthState = "unhealthy"
+	// CellDraining cells are evacuating and do not accept work.
+	CellDraining CellHealthState = "draining"
+)
+
+// CellHealth describes the health of a cell. Since is when the cell entered
+// its current state and CheckedAt is when the state was last determined.
+type CellHealth struct {
+	State     CellHealthState
+	Reasons   []string
+	Since     time.Time
+	CheckedAt time.Time
+}
+
+func NewCellHealth(state CellHealthState, reasons []string, since, checkedAt time.Time) CellHealth {
+	return CellHealth{
+		State:     state,
+		Reasons:   reasons,
+		Since:     since,
+		CheckedAt: checkedAt,
+	}
+}
+
+func (h CellHealth) Healthy() bool {
+	return h.State == CellHealthy
+}
@@ -0,0 +1,74 @@
+package rep
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrorHoldNotFound = errors.New("hold not found")
+var ErrorWorkExceedsHold = errors.New("work exceeds held resources")
+var ErrorInvalidHoldRequest = errors.New("invalid hold request")
+
+// HoldRequest asks a cell to set aside resources for a limited time, so that
+// a scheduler can reserve capacity on several cells before committing work to
+// one of them.
+type HoldRequest struct {
+	Resources Resources
+	TTL       time.Duration
+}
+
+func NewHoldRequest(memoryMB, diskMB int32, containers int, ttl time.Duration) HoldRequest {
+	return HoldRequest{
+		Resources: NewResources(memoryMB, diskMB, containers),
+		TTL:       ttl,
+	}
+}
+
+func (r *HoldRequest) Validate() error {
+	switch {
+	case r.TTL <= 0:
+		return ErrorInvalidHoldRequest
+	case r.Resources.MemoryMB < 0 || r.Resources.DiskMB < 0 || r.Resources.Containers < 0:
+		return ErrorInvalidHoldRequest
+	default:
+		return nil
+	}
+}
+
+type Hold struct {
+	ID        string
+	Resources Resources
+	ExpiresAt time.Time
+}
+
+func NewHold(id string, resources Resources, expiresAt time.Time) Hold {
+	return Hold{ID: id, Resources: resources, ExpiresAt: expiresAt}
+}
+
+func (h *Hold) Expired(now time.Time) bool {
+	return !now.Before(h.ExpiresAt)
+}
+
+// Covers reports whether the given work fits within the held resources.
+func (h *Hold) Covers(work *Work) bool {
+	required := work.Resources()
+	return required.MemoryMB <= h.Resources.MemoryMB &&
+		required.DiskMB <= h.Resources.DiskMB &&
+		required.Containers <= h.Resources.Containers
+}
+
+// Resources sums the resources required by all LRPs and Tasks of the work.
+func (w *Work) Resources() Resources {
+	resources := Resources{}
+	for i := range w.LRPs {
+		resources.MemoryMB += w.LRPs[i].MemoryMB
+		resources.DiskMB += w.LRPs[i].DiskMB
+		resources.Containers++
+	}
+	for i := range w.Tasks {
+		resources.MemoryMB += w.Tasks[i].MemoryMB
+		resources.DiskMB += w.Tasks[i].DiskMB
+		resources.Containers++
+	}
+	return resources
+}
@@ -0,0 +1,71 @@
+// This file was generated by counterfeiter
+package fake_image_cache
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/rep/image_cache"
+)
+
+type FakeCache struct {
+	RecordStub        func(rootfses ...string)
+	recordMutex       sync.RWMutex
+	recordArgsForCall []struct {
+		rootfses []string
+	}
+	ImagesStub        func() []string
+	imagesMutex       sync.RWMutex
+	imagesArgsForCall []struct{}
+	imagesReturns     struct {
+		result1 []string
+	}
+}
+
+func (fake *FakeCache) Record(rootfses ...string) {
+	fake.recordMutex.Lock()
+	fake.recordArgsForCall = append(fake.recordArgsForCall, struct {
+		rootfses []string
+	}{rootfses})
+	fake.recordMutex.Unlock()
+	if fake.RecordStub != nil {
+		fake.RecordStub(rootfses...)
+	}
+}
+
+func (fake *FakeCache) RecordCallCount() int {
+	fake.recordMutex.RLock()
+	defer fake.recordMutex.RUnlock()
+	return len(fake.recordArgsForCall)
+}
+
+func (fake *FakeCache) RecordArgsForCall(i int) []string {
+	fake.recordMutex.RLock()
+	defer fake.recordMutex.RUnlock()
+	return fake.recordArgsForCall[i].rootfses
+}
+
+func (fake *FakeCache) Images() []string {
+	fake.imagesMutex.Lock()
+	fake.imagesArgsForCall = append(fake.imagesArgsForCall, struct{}{})
+	fake.imagesMutex.Unlock()
+	if fake.ImagesStub != nil {
+		return fake.ImagesStub()
+	} else {
+		return fake.imagesReturns.result1
+	}
+}
+
+func (fake *FakeCache) ImagesCallCount() int {
+	fake.imagesMutex.RLock()
+	defer fake.imagesMutex.RUnlock()
+	return len(fake.imagesArgsForCall)
+}
+
+func (fake *FakeCache) ImagesReturns(result1 []string) {
+	fake.ImagesStub = nil
+	fake.imagesReturns = struct {
+		result1 []string
+	}{result1}
+}
+
+var _ image_cache.Cache = new(FakeCache)
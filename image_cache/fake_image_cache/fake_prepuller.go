@@ -0,0 +1,84 @@
+// This file was generated by counterfeiter
+package fake_image_cache
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep/image_cache"
+)
+
+type FakePrepuller struct {
+	PrepullStub        func(logger lager.Logger, images []string)
+	prepullMutex       sync.RWMutex
+	prepullArgsForCall []struct {
+		logger lager.Logger
+		images []string
+	}
+	PullingStub        func(containerGuid string) bool
+	pullingMutex       sync.RWMutex
+	pullingArgsForCall []struct {
+		containerGuid string
+	}
+	pullingReturns struct {
+		result1 bool
+	}
+}
+
+func (fake *FakePrepuller) Prepull(logger lager.Logger, images []string) {
+	fake.prepullMutex.Lock()
+	fake.prepullArgsForCall = append(fake.prepullArgsForCall, struct {
+		logger lager.Logger
+		images []string
+	}{logger, images})
+	fake.prepullMutex.Unlock()
+	if fake.PrepullStub != nil {
+		fake.PrepullStub(logger, images)
+	}
+}
+
+func (fake *FakePrepuller) PrepullCallCount() int {
+	fake.prepullMutex.RLock()
+	defer fake.prepullMutex.RUnlock()
+	return len(fake.prepullArgsForCall)
+}
+
+func (fake *FakePrepuller) PrepullArgsForCall(i int) (lager.Logger, []string) {
+	fake.prepullMutex.RLock()
+	defer fake.prepullMutex.RUnlock()
+	return fake.prepullArgsForCall[i].logger, fake.prepullArgsForCall[i].images
+}
+
+func (fake *FakePrepuller) Pulling(containerGuid string) bool {
+	fake.pullingMutex.Lock()
+	fake.pullingArgsForCall = append(fake.pullingArgsForCall, struct {
+		containerGuid string
+	}{containerGuid})
+	fake.pullingMutex.Unlock()
+	if fake.PullingStub != nil {
+		return fake.PullingStub(containerGuid)
+	} else {
+		return fake.pullingReturns.result1
+	}
+}
+
+func (fake *FakePrepuller) PullingCallCount() int {
+	fake.pullingMutex.RLock()
+	defer fake.pullingMutex.RUnlock()
+	return len(fake.pullingArgsForCall)
+}
+
+func (fake *FakePrepuller) PullingArgsForCall(i int) string {
+	fake.pullingMutex.RLock()
+	defer fake.pullingMutex.RUnlock()
+	return fake.pullingArgsForCall[i].containerGuid
+}
+
+func (fake *FakePrepuller) PullingReturns(result1 bool) {
+	fake.PullingStub = nil
+	fake.pullingReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+var _ image_cache.Prepuller = new(FakePrepuller)
@@ -0,0 +1,93 @@
+// image_cache tracks the docker images held by the cell and downloads images
+// ahead of the work that needs them
+package image_cache
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/rep"
+)
+
+//go:generate counterfeiter -o fake_image_cache/fake_cache.go . Cache
+
+// Cache is the rep's view of the docker images held by the executor. The
+// executor evicts images on its own, so the view is bounded to the images
+// used most recently.
+type Cache interface {
+	// Record marks the images of the given rootfs URLs as held. Rootfses that
+	// are not docker images are ignored.
+	Record(rootfses ...string)
+	// Images returns the canonical references of the images held.
+	Images() []string
+}
+
+type cache struct {
+	capacity int
+	clock    clock.Clock
+
+	lock     sync.Mutex
+	lastUsed map[string]time.Time
+}
+
+// NewCache returns a cache remembering up to capacity images. A capacity of
+// zero disables the cache.
+func NewCache(capacity int, clock clock.Clock) Cache {
+	return &cache{
+		capacity: capacity,
+		clock:    clock,
+		lastUsed: map[string]time.Time{},
+	}
+}
+
+func (c *cache) Record(rootfses ...string) {
+	if c.capacity <= 0 {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := c.clock.Now()
+	for _, rootfs := range rootfses {
+		if !strings.HasPrefix(rootfs, rep.DockerScheme+":") {
+			continue
+		}
+
+		ref, err := rep.DockerImageRef(rootfs)
+		if err != nil {
+			continue
+		}
+		c.lastUsed[ref] = now
+	}
+
+	for len(c.lastUsed) > c.capacity {
+		c.evictOldest()
+	}
+}
+
+func (c *cache) evictOldest() {
+	var oldest string
+	var oldestTime time.Time
+	for ref, used := range c.lastUsed {
+		if oldest == "" || used.Before(oldestTime) || (used.Equal(oldestTime) && ref < oldest) {
+			oldest, oldestTime = ref, used
+		}
+	}
+	delete(c.lastUsed, oldest)
+}
+
+func (c *cache) Images() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	images := make([]string, 0, len(c.lastUsed))
+	for ref := range c.lastUsed {
+		images = append(images, ref)
+	}
+	sort.Strings(images)
+	return images
+}
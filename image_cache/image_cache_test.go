@@ -0,0 +1,53 @@
+package image_cache_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/rep/image_cache"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cache", func() {
+	var (
+		fakeClock *fakeclock.FakeClock
+		cache     image_cache.Cache
+	)
+
+	BeforeEach(func() {
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		cache = image_cache.NewCache(2, fakeClock)
+	})
+
+	It("records the images of docker rootfses", func() {
+		cache.Record("docker:///cloudfoundry/cflinuxfs2#v1", "preloaded:cflinuxfs2", "/var/vcap/rootfs")
+		Expect(cache.Images()).To(Equal([]string{"docker.io/cloudfoundry/cflinuxfs2:v1"}))
+	})
+
+	It("evicts the least recently used image", func() {
+		cache.Record("docker:///first")
+		fakeClock.Increment(time.Second)
+		cache.Record("docker:///second")
+		fakeClock.Increment(time.Second)
+		cache.Record("docker:///first")
+		fakeClock.Increment(time.Second)
+		cache.Record("docker:///third")
+
+		Expect(cache.Images()).To(Equal([]string{
+			"docker.io/library/first:latest",
+			"docker.io/library/third:latest",
+		}))
+	})
+
+	Context("when the capacity is zero", func() {
+		BeforeEach(func() {
+			cache = image_cache.NewCache(0, fakeClock)
+		})
+
+		It("records nothing", func() {
+			cache.Record("docker:///cloudfoundry/cflinuxfs2")
+			Expect(cache.Images()).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,239 @@
+package image_cache
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/executor"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep"
+)
+
+// PrepullContainerPrefix prefixes the guids of the containers created to
+// download images.
+const PrepullContainerPrefix = "prepull-"
+
+var (
+	ErrPrepullTimedOut = errors.New("timed out waiting for the image to be downloaded")
+	ErrPrepullFailed   = errors.New("failed to download the image")
+)
+
+//go:generate counterfeiter -o fake_image_cache/fake_prepuller.go . Prepuller
+
+// Prepuller downloads docker images into the executor's cache.
+type Prepuller interface {
+	// Prepull queues the given images to be downloaded, one after another,
+	// and returns without waiting for them. Images already held or queued
+	// are skipped.
+	Prepull(logger lager.Logger, images []string)
+	// Pulling reports whether the container is downloading an image for this
+	// prepuller. Any other prepull container has been left behind, for
+	// example by a previous run of the rep.
+	Pulling(containerGuid string) bool
+}
+
+type prepuller struct {
+	client       executor.Client
+	cache        Cache
+	generateGuid func() (string, error)
+	clock        clock.Clock
+	pollInterval time.Duration
+	timeout      time.Duration
+
+	lock        sync.Mutex
+	pending     []string
+	queued      map[string]bool
+	working     bool
+	pullingGuid string
+}
+
+// NewPrepuller returns a prepuller that downloads an image by running a
+// short-lived container with the image as its rootfs. The executor has no way
+// to download an image on its own.
+func NewPrepuller(
+	client executor.Client,
+	cache Cache,
+	generateGuid func() (string, error),
+	clock clock.Clock,
+	pollInterval time.Duration,
+	timeout time.Duration,
+) Prepuller {
+	return &prepuller{
+		client:       client,
+		cache:        cache,
+		generateGuid: generateGuid,
+		clock:        clock,
+		pollInterval: pollInterval,
+		timeout:      timeout,
+		queued:       map[string]bool{},
+	}
+}
+
+func (p *prepuller) Prepull(logger lager.Logger, images []string) {
+	logger = logger.Session("prepull")
+
+	held := map[string]bool{}
+	for _, ref := range p.cache.Images() {
+		held[ref] = true
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, image := range images {
+		ref, err := rep.DockerImageRef(image)
+		if err != nil {
+			logger.Error("invalid-image", err, lager.Data{"image": image})
+			continue
+		}
+		if held[ref] {
+			logger.Info("already-cached", lager.Data{"image": ref})
+			continue
+		}
+		if p.queued[ref] {
+			logger.Info("already-queued", lager.Data{"image": ref})
+			continue
+		}
+		p.queued[ref] = true
+		p.pending = append(p.pending, ref)
+	}
+
+	if !p.working && len(p.pending) > 0 {
+		p.working = true
+		go p.work(logger)
+	}
+}
+
+func (p *prepuller) Pulling(containerGuid string) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.pullingGuid != "" && p.pullingGuid == containerGuid
+}
+
+// work pulls the queued images one at a time and exits once the queue is
+// empty. At most one worker runs at a time.
+func (p *prepuller) work(logger lager.Logger) {
+	for {
+		p.lock.Lock()
+		if len(p.pending) == 0 {
+			p.working = false
+			p.lock.Unlock()
+			return
+		}
+		ref := p.pending[0]
+		p.pending = p.pending[1:]
+		p.lock.Unlock()
+
+		p.pull(logger, ref)
+
+		p.lock.Lock()
+		delete(p.queued, ref)
+		p.lock.Unlock()
+	}
+}
+
+func (p *prepuller) setPulling(guid string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.pullingGuid = guid
+}
+
+func (p *prepuller) pull(logger lager.Logger, ref string) {
+	rootfs := rep.DockerRootFS(ref)
+
+	guid, err := p.generateGuid()
+	if err != nil {
+		logger.Error("failed-to-generate-guid", err)
+		return
+	}
+	guid = PrepullContainerPrefix + guid
+
+	p.setPulling(guid)
+	defer p.setPulling("")
+
+	logger = logger.Session("pull", lager.Data{"image": ref, "container-guid": guid})
+	logger.Info("starting")
+	defer logger.Info("finished")
+
+	resource := executor.NewResource(0, 0, rootfs)
+	tags := executor.Tags{rep.LifecycleTag: rep.PrepullLifecycle}
+	failures, err := p.client.AllocateContainers(logger, []executor.AllocationRequest{
+		executor.NewAllocationRequest(guid, &resource, tags),
+	})
+	if err != nil {
+		logger.Error("failed-to-allocate-container", err)
+		return
+	}
+	if len(failures) > 0 {
+		logger.Error("failed-to-allocate-container", &failures[0])
+		return
+	}
+	defer p.deleteContainer(logger, guid)
+
+	runInfo := executor.RunInfo{
+		Action: models.WrapAction(&models.RunAction{Path: "true", User: "root"}),
+	}
+	runReq := executor.NewRunRequest(guid, &runInfo, tags)
+	err = p.client.RunContainer(logger, &runReq)
+	if err != nil {
+		logger.Error("failed-to-run-container", err)
+		return
+	}
+
+	err = p.waitForImage(logger, guid)
+	if err != nil {
+		logger.Error("failed-to-pull-image", err)
+		return
+	}
+
+	p.cache.Record(rootfs)
+	logger.Info("pulled-image")
+}
+
+// waitForImage waits until the container has been created, which requires
+// the image to have been downloaded. A container that fails before it is
+// seen running is not distinguished from one that failed to download the
+// image, so the image is not recorded.
+func (p *prepuller) waitForImage(logger lager.Logger, guid string) error {
+	ticker := p.clock.NewTicker(p.pollInterval)
+	defer ticker.Stop()
+
+	timer := p.clock.NewTimer(p.timeout)
+	defer timer.Stop()
+
+	for {
+		container, err := p.client.GetContainer(logger, guid)
+		if err != nil {
+			return err
+		}
+
+		switch container.State {
+		case executor.StateCreated, executor.StateRunning:
+			return nil
+		case executor.StateCompleted:
+			if container.RunResult.Failed {
+				logger.Info("container-failed", lager.Data{"failure-reason": container.RunResult.FailureReason})
+				return ErrPrepullFailed
+			}
+			return nil
+		}
+
+		select {
+		case <-ticker.C():
+		case <-timer.C():
+			return ErrPrepullTimedOut
+		}
+	}
+}
+
+func (p *prepuller) deleteContainer(logger lager.Logger, guid string) {
+	err := p.client.DeleteContainer(logger, guid)
+	if err != nil {
+		logger.Error("failed-to-delete-container", err)
+	}
+}
@@ -0,0 +1,171 @@
+package image_cache_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/executor"
+	fake_client "code.cloudfoundry.org/executor/fakes"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/image_cache"
+	"code.cloudfoundry.org/rep/image_cache/fake_image_cache"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Prepuller", func() {
+	var (
+		logger    *lagertest.TestLogger
+		client    *fake_client.FakeClient
+		cache     *fake_image_cache.FakeCache
+		fakeClock *fakeclock.FakeClock
+		prepuller image_cache.Prepuller
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		client = new(fake_client.FakeClient)
+		cache = new(fake_image_cache.FakeCache)
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+
+		generateGuid := func() (string, error) { return "some-guid", nil }
+		prepuller = image_cache.NewPrepuller(client, cache, generateGuid, fakeClock, time.Second, time.Minute)
+
+		client.GetContainerReturns(executor.Container{State: executor.StateCreated}, nil)
+	})
+
+	It("runs a container with the image as its rootfs", func() {
+		prepuller.Prepull(logger, []string{"cloudfoundry/cflinuxfs2:v1"})
+
+		Eventually(cache.RecordCallCount).Should(Equal(1))
+		Expect(cache.RecordArgsForCall(0)).To(Equal([]string{"docker:///cloudfoundry/cflinuxfs2#v1"}))
+
+		Expect(client.AllocateContainersCallCount()).To(Equal(1))
+		_, requests := client.AllocateContainersArgsForCall(0)
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].Guid).To(Equal("prepull-some-guid"))
+		Expect(requests[0].RootFSPath).To(Equal("docker:///cloudfoundry/cflinuxfs2#v1"))
+		Expect(requests[0].Tags).To(Equal(executor.Tags{rep.LifecycleTag: rep.PrepullLifecycle}))
+
+		Expect(client.RunContainerCallCount()).To(Equal(1))
+		Eventually(client.DeleteContainerCallCount).Should(Equal(1))
+		_, guid := client.DeleteContainerArgsForCall(0)
+		Expect(guid).To(Equal("prepull-some-guid"))
+	})
+
+	It("skips images that are already cached", func() {
+		cache.ImagesReturns([]string{"docker.io/cloudfoundry/cflinuxfs2:latest"})
+
+		prepuller.Prepull(logger, []string{"docker:///cloudfoundry/cflinuxfs2", "docker:///cloudfoundry/other"})
+
+		Eventually(client.DeleteContainerCallCount).Should(Equal(1))
+		Consistently(client.AllocateContainersCallCount).Should(Equal(1))
+		_, requests := client.AllocateContainersArgsForCall(0)
+		Expect(requests[0].RootFSPath).To(Equal("docker:///cloudfoundry/other#latest"))
+	})
+
+	Context("when the container is still being created", func() {
+		BeforeEach(func() {
+			client.GetContainerStub = func(lager.Logger, string) (executor.Container, error) {
+				if client.GetContainerCallCount() < 3 {
+					return executor.Container{State: executor.StateInitializing}, nil
+				}
+				return executor.Container{State: executor.StateRunning}, nil
+			}
+		})
+
+		It("polls until the image has been downloaded", func() {
+			prepuller.Prepull(logger, []string{"ubuntu"})
+
+			Eventually(client.GetContainerCallCount).Should(Equal(1))
+			fakeClock.WaitForWatcherAndIncrement(time.Second)
+			Eventually(client.GetContainerCallCount).Should(Equal(2))
+			fakeClock.WaitForWatcherAndIncrement(time.Second)
+
+			Eventually(cache.RecordCallCount).Should(Equal(1))
+		})
+
+		It("gives up after the timeout", func() {
+			client.GetContainerStub = nil
+			client.GetContainerReturns(executor.Container{State: executor.StateInitializing}, nil)
+
+			prepuller.Prepull(logger, []string{"ubuntu"})
+
+			Eventually(client.GetContainerCallCount).Should(Equal(1))
+			fakeClock.WaitForNWatchersAndIncrement(time.Minute, 2)
+
+			Eventually(client.DeleteContainerCallCount).Should(Equal(1))
+			Expect(cache.RecordCallCount()).To(BeZero())
+		})
+
+		It("reports the container as pulling until it is deleted", func() {
+			prepuller.Prepull(logger, []string{"ubuntu"})
+
+			Eventually(client.GetContainerCallCount).Should(Equal(1))
+			Expect(prepuller.Pulling("prepull-some-guid")).To(BeTrue())
+			Expect(prepuller.Pulling("prepull-other-guid")).To(BeFalse())
+
+			fakeClock.WaitForWatcherAndIncrement(time.Second)
+			fakeClock.WaitForWatcherAndIncrement(time.Second)
+			Eventually(client.DeleteContainerCallCount).Should(Equal(1))
+			Eventually(func() bool { return prepuller.Pulling("prepull-some-guid") }).Should(BeFalse())
+		})
+
+		It("pulls one image at a time", func() {
+			prepuller.Prepull(logger, []string{"ubuntu"})
+			prepuller.Prepull(logger, []string{"busybox"})
+
+			Eventually(client.GetContainerCallCount).Should(Equal(1))
+			Consistently(client.AllocateContainersCallCount).Should(Equal(1))
+
+			fakeClock.WaitForWatcherAndIncrement(time.Second)
+			fakeClock.WaitForWatcherAndIncrement(time.Second)
+			Eventually(client.AllocateContainersCallCount).Should(Equal(2))
+		})
+
+		It("does not queue an image that is already being pulled", func() {
+			prepuller.Prepull(logger, []string{"ubuntu"})
+			prepuller.Prepull(logger, []string{"ubuntu"})
+
+			Eventually(client.GetContainerCallCount).Should(Equal(1))
+			fakeClock.WaitForWatcherAndIncrement(time.Second)
+			fakeClock.WaitForWatcherAndIncrement(time.Second)
+
+			Eventually(cache.RecordCallCount).Should(Equal(1))
+			Consistently(client.AllocateContainersCallCount).Should(Equal(1))
+		})
+	})
+
+	Context("when the container fails", func() {
+		BeforeEach(func() {
+			client.GetContainerReturns(executor.Container{
+				State:     executor.StateCompleted,
+				RunResult: executor.ContainerRunResult{Failed: true, FailureReason: "image not found"},
+			}, nil)
+		})
+
+		It("does not record the image", func() {
+			prepuller.Prepull(logger, []string{"ubuntu"})
+
+			Eventually(client.DeleteContainerCallCount).Should(Equal(1))
+			Expect(cache.RecordCallCount()).To(BeZero())
+		})
+	})
+
+	Context("when allocating the container fails", func() {
+		BeforeEach(func() {
+			client.AllocateContainersReturns(nil, errors.New("boom"))
+		})
+
+		It("does not run or delete a container", func() {
+			prepuller.Prepull(logger, []string{"ubuntu"})
+
+			Eventually(client.AllocateContainersCallCount).Should(Equal(1))
+			Consistently(client.RunContainerCallCount).Should(BeZero())
+			Expect(client.DeleteContainerCallCount()).To(BeZero())
+		})
+	})
+})
@@ -0,0 +1,118 @@
+package rep
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// DockerScheme is the scheme of rootfs URLs naming a docker image.
+const DockerScheme = "docker"
+
+// DefaultDockerTag is the tag of docker rootfs URLs without a fragment.
+const DefaultDockerTag = "latest"
+
+// CachedImageBonus is subtracted from the score of a cell that already holds
+// the docker image of the work being placed. Lower scores win, so the bonus
+// outweighs a difference of roughly a tenth of the cell's capacity.
+const CachedImageBonus = 0.1
+
+var ErrInvalidDockerImage = errors.New("invalid docker image")
+
+// DockerImageRef returns the canonical reference of the docker image named by
+// a rootfs URL, as in docker.io/cloudfoundry/cflinuxfs2:latest. The URL may be
+// a docker:// rootfs or a bare image reference.
+func DockerImageRef(rootfs string) (string, error) {
+	if !strings.HasPrefix(rootfs, DockerScheme+":") {
+		rootfs = DockerScheme + "://" + imageRefToURL(rootfs)
+	}
+
+	u, err := url.Parse(rootfs)
+	if err != nil || u.Scheme != DockerScheme || u.Opaque != "" {
+		return "", ErrInvalidDockerImage
+	}
+
+	repository := strings.Trim(u.Path, "/")
+	if repository == "" {
+		return "", ErrInvalidDockerImage
+	}
+
+	host := u.Host
+	if host == "" {
+		host = DefaultDockerRegistry
+	}
+	if host == DefaultDockerRegistry && !strings.Contains(repository, "/") {
+		repository = "library/" + repository
+	}
+
+	tag := u.Fragment
+	if tag == "" {
+		tag = DefaultDockerTag
+	}
+
+	return host + "/" + repository + ":" + tag, nil
+}
+
+// DockerRootFS returns the rootfs URL of a canonical image reference.
+// Images hosted by DefaultDockerRegistry are given without a host.
+func DockerRootFS(ref string) string {
+	u := imageRefToURL(ref)
+	if strings.HasPrefix(u, DefaultDockerRegistry+"/") {
+		u = strings.TrimPrefix(u, DefaultDockerRegistry)
+	}
+	return DockerScheme + "://" + u
+}
+
+// imageRefToURL converts a reference such as cloudfoundry/cflinuxfs2:latest
+// or my.registry:5000/app to the host, path and fragment of a docker URL.
+func imageRefToURL(ref string) string {
+	host := ""
+	parts := strings.SplitN(ref, "/", 2)
+	if len(parts) == 2 && strings.ContainsAny(parts[0], ".:") {
+		host, ref = parts[0], parts[1]
+	}
+
+	if i := strings.LastIndex(ref, ":"); i >= 0 {
+		ref = ref[:i] + "#" + ref[i+1:]
+	}
+
+	return host + "/" + ref
+}
+
+// HasCachedImage reports whether the cell holds the docker image of the
+// given rootfs.
+func (c *CellState) HasCachedImage(rootfs string) bool {
+	if !strings.HasPrefix(rootfs, DockerScheme+":") {
+		return false
+	}
+
+	ref, err := DockerImageRef(rootfs)
+	if err != nil {
+		return false
+	}
+
+	for _, image := range c.CachedImages {
+		if image == ref {
+			return true
+		}
+	}
+	return false
+}
+
+// PrepullRequest asks a cell to download docker images ahead of the work
+// that needs them.
+type PrepullRequest struct {
+	Images []string `json:"images"`
+}
+
+func (r *PrepullRequest) Validate() error {
+	if len(r.Images) == 0 {
+		return ErrInvalidDockerImage
+	}
+	for _, image := range r.Images {
+		if _, err := DockerImageRef(image); err != nil {
+			return err
+		}
+	}
+	return nil
+}
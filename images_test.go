@@ -0,0 +1,59 @@
+package rep_test
+
+import (
+	"code.cloudfoundry.org/rep"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Docker images", func() {
+	Describe("DockerImageRef", func() {
+		It("canonicalizes docker rootfs URLs and image references", func() {
+			for input, expected := range map[string]string{
+				"docker:///cloudfoundry/cflinuxfs2":      "docker.io/cloudfoundry/cflinuxfs2:latest",
+				"docker:///cloudfoundry/cflinuxfs2#v1.2": "docker.io/cloudfoundry/cflinuxfs2:v1.2",
+				"docker://my.registry:5000/app#v3":       "my.registry:5000/app:v3",
+				"docker:///ubuntu":                       "docker.io/library/ubuntu:latest",
+				"ubuntu:16.04":                           "docker.io/library/ubuntu:16.04",
+				"cloudfoundry/cflinuxfs2":                "docker.io/cloudfoundry/cflinuxfs2:latest",
+				"my.registry:5000/app:v3":                "my.registry:5000/app:v3",
+			} {
+				ref, err := rep.DockerImageRef(input)
+				Expect(err).NotTo(HaveOccurred(), input)
+				Expect(ref).To(Equal(expected), input)
+			}
+		})
+
+		It("rejects rootfses that are not docker images", func() {
+			for _, input := range []string{"", "docker://", "docker:opaque"} {
+				_, err := rep.DockerImageRef(input)
+				Expect(err).To(Equal(rep.ErrInvalidDockerImage), input)
+			}
+		})
+
+		It("round-trips through DockerRootFS", func() {
+			for _, ref := range []string{"docker.io/library/ubuntu:latest", "my.registry:5000/app:v3"} {
+				roundTripped, err := rep.DockerImageRef(rep.DockerRootFS(ref))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(roundTripped).To(Equal(ref))
+			}
+		})
+	})
+
+	Describe("ComputeScore", func() {
+		var state rep.CellState
+
+		BeforeEach(func() {
+			total := rep.NewResources(1024, 1024, 4)
+			state = rep.NewCellState(rep.RootFSProviders{"docker": rep.ArbitraryRootFSProvider{}}, total, total, nil, nil, "z1", 0, false, nil)
+			state.CachedImages = []string{"docker.io/cloudfoundry/cached:latest"}
+		})
+
+		It("favors cells holding the docker image", func() {
+			cached := rep.NewResource(256, 256, "docker:///cloudfoundry/cached", nil)
+			uncached := rep.NewResource(256, 256, "docker:///cloudfoundry/uncached", nil)
+
+			Expect(state.ComputeScore(&cached, 0)).To(BeNumerically("~", state.ComputeScore(&uncached, 0)-rep.CachedImageBonus, 1e-9))
+		})
+	})
+})
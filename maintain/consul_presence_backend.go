@@ -0,0 +1,161 @@
+package maintain
+
+import (
+	"bytes"
+	"os"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/consuladapter"
+	"code.cloudfoundry.org/lager"
+	"github.com/hashicorp/consul/api"
+	"github.com/tedsuo/ifrit"
+)
+
+// consulPresence tracks the presence key held by this rep. The session that
+// holds the key is not exposed by the heartbeater, so it is adopted whenever
+// the key is seen holding a value this rep wrote: the one the heartbeater
+// acquires the key with, including after it re-acquires a lost key with a new
+// session, or the one last written by UpdateCellPresence.
+type consulPresence struct {
+	initial   []byte
+	payload   []byte
+	sessionID string
+}
+
+type consulPresenceBackend struct {
+	consulClient  consuladapter.Client
+	serviceClient bbs.ServiceClient
+
+	presencesLock sync.Mutex
+	presences     map[string]*consulPresence
+}
+
+func NewConsulPresenceBackend(consulClient consuladapter.Client, clock clock.Clock) PresenceBackend {
+	return &consulPresenceBackend{
+		consulClient:  consulClient,
+		serviceClient: bbs.NewServiceClient(consulClient, clock),
+		presences:     map[string]*consulPresence{},
+	}
+}
+
+// NewCellPresenceRunner wraps the heartbeater so that the presence is only
+// updated while the heartbeater holds it.
+func (b *consulPresenceBackend) NewCellPresenceRunner(logger lager.Logger, presence *models.CellPresence, retryInterval, lockTTL time.Duration) ifrit.Runner {
+	heartbeater := b.serviceClient.NewCellPresenceRunner(logger, presence, retryInterval, lockTTL)
+	payload, err := models.ToJSON(presence)
+	if err != nil {
+		return ifrit.RunFunc(func(<-chan os.Signal, chan<- struct{}) error {
+			logger.Error("failed-to-marshal-cell-presence", err)
+			return err
+		})
+	}
+
+	return ifrit.RunFunc(func(signals <-chan os.Signal, ready chan<- struct{}) error {
+		process := ifrit.Background(heartbeater)
+
+		select {
+		case <-process.Ready():
+		case err := <-process.Wait():
+			return err
+		case signal := <-signals:
+			process.Signal(signal)
+			return <-process.Wait()
+		}
+
+		b.presencesLock.Lock()
+		b.presences[presence.CellId] = &consulPresence{initial: payload, payload: payload}
+		b.presencesLock.Unlock()
+
+		defer func() {
+			b.presencesLock.Lock()
+			delete(b.presences, presence.CellId)
+			b.presencesLock.Unlock()
+		}()
+
+		close(ready)
+
+		select {
+		case err := <-process.Wait():
+			return err
+		case signal := <-signals:
+			process.Signal(signal)
+			return <-process.Wait()
+		}
+	})
+}
+
+// ownSession returns the session of this rep's heartbeater, adopting the
+// session of the given key if it holds a value this rep wrote. It returns an
+// empty string if the rep is not heartbeating the presence.
+func (b *consulPresenceBackend) ownSession(cellID string, pair *api.KVPair) string {
+	b.presencesLock.Lock()
+	defer b.presencesLock.Unlock()
+
+	held, ok := b.presences[cellID]
+	if !ok {
+		return ""
+	}
+
+	if bytes.Equal(pair.Value, held.initial) || bytes.Equal(pair.Value, held.payload) {
+		held.sessionID = pair.Session
+	}
+	return held.sessionID
+}
+
+func (b *consulPresenceBackend) recordPayload(cellID string, payload []byte) {
+	b.presencesLock.Lock()
+	defer b.presencesLock.Unlock()
+
+	if held, ok := b.presences[cellID]; ok {
+		held.payload = payload
+	}
+}
+
+// UpdateCellPresence re-acquires the presence key with the session of this
+// rep's heartbeater, which replaces the value while keeping the lock. The key
+// is not touched if it is held by any other session.
+func (b *consulPresenceBackend) UpdateCellPresence(logger lager.Logger, presence *models.CellPresence) error {
+	key := bbs.CellSchemaPath(presence.CellId)
+	logger = logger.Session("update-cell-presence", lager.Data{"key": key})
+
+	payload, err := models.ToJSON(presence)
+	if err != nil {
+		logger.Error("failed-to-marshal-cell-presence", err)
+		return err
+	}
+
+	pair, _, err := b.consulClient.KV().Get(key, nil)
+	if err != nil {
+		logger.Error("failed-to-get-cell-presence", err)
+		return err
+	}
+
+	if pair == nil || pair.Session == "" {
+		logger.Error("cell-presence-not-held", ErrCellPresenceNotHeld)
+		return ErrCellPresenceNotHeld
+	}
+
+	sessionID := b.ownSession(presence.CellId, pair)
+	if sessionID == "" || pair.Session != sessionID {
+		logger.Error("cell-presence-held-by-another-session", ErrCellPresenceNotHeld, lager.Data{"session": pair.Session})
+		return ErrCellPresenceNotHeld
+	}
+
+	acquired, _, err := b.consulClient.KV().Acquire(&api.KVPair{Key: key, Value: payload, Session: sessionID}, nil)
+	if err != nil {
+		logger.Error("failed-to-update-cell-presence", err)
+		return err
+	}
+
+	if !acquired {
+		logger.Error("cell-presence-not-held", ErrCellPresenceNotHeld)
+		return ErrCellPresenceNotHeld
+	}
+
+	b.recordPayload(presence.CellId, payload)
+	return nil
+}
@@ -0,0 +1,105 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep/maintain"
+	"github.com/tedsuo/ifrit"
+)
+
+type FakePresenceBackend struct {
+	NewCellPresenceRunnerStub        func(logger lager.Logger, presence *models.CellPresence, retryInterval time.Duration, lockTTL time.Duration) ifrit.Runner
+	newCellPresenceRunnerMutex       sync.RWMutex
+	newCellPresenceRunnerArgsForCall []struct {
+		logger        lager.Logger
+		presence      *models.CellPresence
+		retryInterval time.Duration
+		lockTTL       time.Duration
+	}
+	newCellPresenceRunnerReturns struct {
+		result1 ifrit.Runner
+	}
+	UpdateCellPresenceStub        func(logger lager.Logger, presence *models.CellPresence) error
+	updateCellPresenceMutex       sync.RWMutex
+	updateCellPresenceArgsForCall []struct {
+		logger   lager.Logger
+		presence *models.CellPresence
+	}
+	updateCellPresenceReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakePresenceBackend) NewCellPresenceRunner(logger lager.Logger, presence *models.CellPresence, retryInterval time.Duration, lockTTL time.Duration) ifrit.Runner {
+	fake.newCellPresenceRunnerMutex.Lock()
+	fake.newCellPresenceRunnerArgsForCall = append(fake.newCellPresenceRunnerArgsForCall, struct {
+		logger        lager.Logger
+		presence      *models.CellPresence
+		retryInterval time.Duration
+		lockTTL       time.Duration
+	}{logger, presence, retryInterval, lockTTL})
+	fake.newCellPresenceRunnerMutex.Unlock()
+	if fake.NewCellPresenceRunnerStub != nil {
+		return fake.NewCellPresenceRunnerStub(logger, presence, retryInterval, lockTTL)
+	} else {
+		return fake.newCellPresenceRunnerReturns.result1
+	}
+}
+
+func (fake *FakePresenceBackend) NewCellPresenceRunnerCallCount() int {
+	fake.newCellPresenceRunnerMutex.RLock()
+	defer fake.newCellPresenceRunnerMutex.RUnlock()
+	return len(fake.newCellPresenceRunnerArgsForCall)
+}
+
+func (fake *FakePresenceBackend) NewCellPresenceRunnerArgsForCall(i int) (lager.Logger, *models.CellPresence, time.Duration, time.Duration) {
+	fake.newCellPresenceRunnerMutex.RLock()
+	defer fake.newCellPresenceRunnerMutex.RUnlock()
+	return fake.newCellPresenceRunnerArgsForCall[i].logger, fake.newCellPresenceRunnerArgsForCall[i].presence, fake.newCellPresenceRunnerArgsForCall[i].retryInterval, fake.newCellPresenceRunnerArgsForCall[i].lockTTL
+}
+
+func (fake *FakePresenceBackend) NewCellPresenceRunnerReturns(result1 ifrit.Runner) {
+	fake.NewCellPresenceRunnerStub = nil
+	fake.newCellPresenceRunnerReturns = struct {
+		result1 ifrit.Runner
+	}{result1}
+}
+
+func (fake *FakePresenceBackend) UpdateCellPresence(logger lager.Logger, presence *models.CellPresence) error {
+	fake.updateCellPresenceMutex.Lock()
+	fake.updateCellPresenceArgsForCall = append(fake.updateCellPresenceArgsForCall, struct {
+		logger   lager.Logger
+		presence *models.CellPresence
+	}{logger, presence})
+	fake.updateCellPresenceMutex.Unlock()
+	if fake.UpdateCellPresenceStub != nil {
+		return fake.UpdateCellPresenceStub(logger, presence)
+	} else {
+		return fake.updateCellPresenceReturns.result1
+	}
+}
+
+func (fake *FakePresenceBackend) UpdateCellPresenceCallCount() int {
+	fake.updateCellPresenceMutex.RLock()
+	defer fake.updateCellPresenceMutex.RUnlock()
+	return len(fake.updateCellPresenceArgsForCall)
+}
+
+func (fake *FakePresenceBackend) UpdateCellPresenceArgsForCall(i int) (lager.Logger, *models.CellPresence) {
+	fake.updateCellPresenceMutex.RLock()
+	defer fake.updateCellPresenceMutex.RUnlock()
+	return fake.updateCellPresenceArgsForCall[i].logger, fake.updateCellPresenceArgsForCall[i].presence
+}
+
+func (fake *FakePresenceBackend) UpdateCellPresenceReturns(result1 error) {
+	fake.UpdateCellPresenceStub = nil
+	fake.updateCellPresenceReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ maintain.PresenceBackend = new(FakePresenceBackend)
@@ -0,0 +1,13 @@
+package local_locket_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestLocalLocket(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Local Locket Suite")
+}
@@ -0,0 +1,128 @@
+// Package local_locket provides an in-memory locket lock server. It keeps no
+// state beyond the life of the process and is intended for tests and local
+// development.
+package local_locket
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/locket/models"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+type lock struct {
+	resource  models.Resource
+	expiresAt time.Time
+}
+
+type Server struct {
+	clock      clock.Clock
+	grpcServer *grpc.Server
+
+	locksLock sync.Mutex
+	locks     map[string]*lock
+}
+
+func NewServer(clock clock.Clock) *Server {
+	server := &Server{
+		clock:      clock,
+		grpcServer: grpc.NewServer(),
+		locks:      map[string]*lock{},
+	}
+	models.RegisterLocketServer(server.grpcServer, server)
+	return server
+}
+
+// Serve accepts gRPC connections on the listener until Stop is called.
+func (s *Server) Serve(listener net.Listener) error {
+	return s.grpcServer.Serve(listener)
+}
+
+func (s *Server) Stop() {
+	s.grpcServer.Stop()
+}
+
+// Lock acquires the resource, or refreshes it and replaces its value if it is
+// already held by the same owner.
+func (s *Server) Lock(ctx context.Context, req *models.LockRequest) (*models.LockResponse, error) {
+	s.locksLock.Lock()
+	defer s.locksLock.Unlock()
+
+	existing, ok := s.unexpiredLock(req.Resource.Key)
+	if ok && existing.resource.Owner != req.Resource.Owner {
+		return nil, models.ErrLockCollision
+	}
+
+	s.locks[req.Resource.Key] = &lock{
+		resource:  *req.Resource,
+		expiresAt: s.clock.Now().Add(time.Duration(req.TtlInSeconds) * time.Second),
+	}
+	return &models.LockResponse{}, nil
+}
+
+func (s *Server) Release(ctx context.Context, req *models.ReleaseRequest) (*models.ReleaseResponse, error) {
+	s.locksLock.Lock()
+	defer s.locksLock.Unlock()
+
+	existing, ok := s.unexpiredLock(req.Resource.Key)
+	if !ok {
+		return nil, models.ErrResourceNotFound
+	}
+
+	if existing.resource.Owner != req.Resource.Owner {
+		return nil, models.ErrLockCollision
+	}
+
+	delete(s.locks, req.Resource.Key)
+	return &models.ReleaseResponse{}, nil
+}
+
+func (s *Server) Fetch(ctx context.Context, req *models.FetchRequest) (*models.FetchResponse, error) {
+	s.locksLock.Lock()
+	defer s.locksLock.Unlock()
+
+	existing, ok := s.unexpiredLock(req.Key)
+	if !ok {
+		return nil, models.ErrResourceNotFound
+	}
+
+	resource := existing.resource
+	return &models.FetchResponse{Resource: &resource}, nil
+}
+
+func (s *Server) FetchAll(ctx context.Context, req *models.FetchAllRequest) (*models.FetchAllResponse, error) {
+	s.locksLock.Lock()
+	defer s.locksLock.Unlock()
+
+	resources := []*models.Resource{}
+	for key := range s.locks {
+		existing, ok := s.unexpiredLock(key)
+		if !ok || (req.Type != "" && existing.resource.Type != req.Type) {
+			continue
+		}
+		resource := existing.resource
+		resources = append(resources, &resource)
+	}
+
+	return &models.FetchAllResponse{Resources: resources}, nil
+}
+
+// unexpiredLock returns the lock for the key, discarding it if its TTL has
+// elapsed. Callers must hold locksLock.
+func (s *Server) unexpiredLock(key string) (*lock, bool) {
+	existing, ok := s.locks[key]
+	if !ok {
+		return nil, false
+	}
+
+	if !s.clock.Now().Before(existing.expiresAt) {
+		delete(s.locks, key)
+		return nil, false
+	}
+
+	return existing, true
+}
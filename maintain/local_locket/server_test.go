@@ -0,0 +1,97 @@
+package local_locket_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/locket/models"
+	"code.cloudfoundry.org/rep/maintain/local_locket"
+	"golang.org/x/net/context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Server", func() {
+	var (
+		server    *local_locket.Server
+		fakeClock *fakeclock.FakeClock
+		ctx       context.Context
+		resource  *models.Resource
+	)
+
+	BeforeEach(func() {
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		server = local_locket.NewServer(fakeClock)
+		ctx = context.Background()
+		resource = &models.Resource{Key: "cell-id", Owner: "owner", Value: "value", Type: models.PresenceType}
+
+		_, err := server.Lock(ctx, &models.LockRequest{Resource: resource, TtlInSeconds: 10})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("returns the locked resource", func() {
+		response, err := server.Fetch(ctx, &models.FetchRequest{Key: "cell-id"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(response.Resource).To(Equal(resource))
+	})
+
+	It("lets the owner replace the value", func() {
+		updated := &models.Resource{Key: "cell-id", Owner: "owner", Value: "new-value", Type: models.PresenceType}
+		_, err := server.Lock(ctx, &models.LockRequest{Resource: updated, TtlInSeconds: 10})
+		Expect(err).NotTo(HaveOccurred())
+
+		response, err := server.Fetch(ctx, &models.FetchRequest{Key: "cell-id"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(response.Resource.Value).To(Equal("new-value"))
+	})
+
+	It("refuses the lock to another owner", func() {
+		other := &models.Resource{Key: "cell-id", Owner: "other-owner", Value: "value", Type: models.PresenceType}
+		_, err := server.Lock(ctx, &models.LockRequest{Resource: other, TtlInSeconds: 10})
+		Expect(err).To(Equal(models.ErrLockCollision))
+	})
+
+	It("lists resources by type", func() {
+		lock := &models.Resource{Key: "some-lock", Owner: "owner", Type: models.LockType}
+		_, err := server.Lock(ctx, &models.LockRequest{Resource: lock, TtlInSeconds: 10})
+		Expect(err).NotTo(HaveOccurred())
+
+		response, err := server.FetchAll(ctx, &models.FetchAllRequest{Type: models.PresenceType})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(response.Resources).To(ConsistOf(resource))
+	})
+
+	Context("when the TTL elapses", func() {
+		BeforeEach(func() {
+			fakeClock.Increment(10 * time.Second)
+		})
+
+		It("expires the resource", func() {
+			_, err := server.Fetch(ctx, &models.FetchRequest{Key: "cell-id"})
+			Expect(err).To(Equal(models.ErrResourceNotFound))
+		})
+
+		It("lets another owner acquire it", func() {
+			other := &models.Resource{Key: "cell-id", Owner: "other-owner", Value: "value", Type: models.PresenceType}
+			_, err := server.Lock(ctx, &models.LockRequest{Resource: other, TtlInSeconds: 10})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Describe("Release", func() {
+		It("releases the resource", func() {
+			_, err := server.Release(ctx, &models.ReleaseRequest{Resource: resource})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = server.Fetch(ctx, &models.FetchRequest{Key: "cell-id"})
+			Expect(err).To(Equal(models.ErrResourceNotFound))
+		})
+
+		It("refuses to release a resource held by another owner", func() {
+			other := &models.Resource{Key: "cell-id", Owner: "other-owner"}
+			_, err := server.Release(ctx, &models.ReleaseRequest{Resource: other})
+			Expect(err).To(Equal(models.ErrLockCollision))
+		})
+	})
+})
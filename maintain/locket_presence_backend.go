@@ -0,0 +1,207 @@
+package maintain
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/lager"
+	locketmodels "code.cloudfoundry.org/locket/models"
+	"github.com/tedsuo/ifrit"
+	"golang.org/x/net/context"
+)
+
+const LocketRequestTimeout = 5 * time.Second
+
+type locketPresence struct {
+	request *locketmodels.LockRequest
+	held    bool
+}
+
+type locketPresenceBackend struct {
+	locketClient locketmodels.LocketClient
+	owner        string
+	clock        clock.Clock
+
+	presencesLock sync.Mutex
+	presences     map[string]*locketPresence
+}
+
+// NewLocketPresenceBackend maintains the cell presence as a presence resource
+// in a locket lock server, keyed by the cell ID and owned by the given owner.
+func NewLocketPresenceBackend(locketClient locketmodels.LocketClient, owner string, clock clock.Clock) PresenceBackend {
+	return &locketPresenceBackend{
+		locketClient: locketClient,
+		owner:        owner,
+		clock:        clock,
+		presences:    map[string]*locketPresence{},
+	}
+}
+
+func (b *locketPresenceBackend) NewCellPresenceRunner(logger lager.Logger, presence *models.CellPresence, retryInterval, lockTTL time.Duration) ifrit.Runner {
+	payload, err := models.ToJSON(presence)
+	if err != nil {
+		return ifrit.RunFunc(func(<-chan os.Signal, chan<- struct{}) error {
+			logger.Error("failed-to-marshal-cell-presence", err)
+			return err
+		})
+	}
+
+	b.presencesLock.Lock()
+	b.presences[presence.CellId] = &locketPresence{
+		request: &locketmodels.LockRequest{
+			Resource: &locketmodels.Resource{
+				Key:   presence.CellId,
+				Owner: b.owner,
+				Value: string(payload),
+				Type:  locketmodels.PresenceType,
+			},
+			TtlInSeconds: int64(lockTTL / time.Second),
+		},
+	}
+	b.presencesLock.Unlock()
+
+	return &locketPresenceRunner{
+		logger:        logger.Session("locket-presence", lager.Data{"key": presence.CellId, "owner": b.owner}),
+		backend:       b,
+		key:           presence.CellId,
+		retryInterval: retryInterval,
+	}
+}
+
+// UpdateCellPresence replaces the value of the presence resource. Locket lets
+// the owner of a resource lock it again with a new value, so the presence is
+// not released.
+func (b *locketPresenceBackend) UpdateCellPresence(logger lager.Logger, presence *models.CellPresence) error {
+	logger = logger.Session("update-cell-presence", lager.Data{"key": presence.CellId})
+
+	payload, err := models.ToJSON(presence)
+	if err != nil {
+		logger.Error("failed-to-marshal-cell-presence", err)
+		return err
+	}
+
+	b.presencesLock.Lock()
+	current, ok := b.presences[presence.CellId]
+	if !ok || !current.held {
+		b.presencesLock.Unlock()
+		logger.Error("cell-presence-not-held", ErrCellPresenceNotHeld)
+		return ErrCellPresenceNotHeld
+	}
+
+	resource := *current.request.Resource
+	resource.Value = string(payload)
+	current.request = &locketmodels.LockRequest{Resource: &resource, TtlInSeconds: current.request.TtlInSeconds}
+	request := current.request
+	b.presencesLock.Unlock()
+
+	err = b.lock(request)
+	if err != nil {
+		logger.Error("failed-to-update-cell-presence", err)
+		return err
+	}
+
+	return nil
+}
+
+func (b *locketPresenceBackend) lock(request *locketmodels.LockRequest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), LocketRequestTimeout)
+	defer cancel()
+
+	_, err := b.locketClient.Lock(ctx, request)
+	return err
+}
+
+func (b *locketPresenceBackend) release(key string) error {
+	b.presencesLock.Lock()
+	current, ok := b.presences[key]
+	delete(b.presences, key)
+	b.presencesLock.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), LocketRequestTimeout)
+	defer cancel()
+
+	_, err := b.locketClient.Release(ctx, &locketmodels.ReleaseRequest{Resource: current.request.Resource})
+	return err
+}
+
+// currentRequest returns the lock request carrying the latest value of the
+// presence, or nil if the presence has been released.
+func (b *locketPresenceBackend) currentRequest(key string) *locketmodels.LockRequest {
+	b.presencesLock.Lock()
+	defer b.presencesLock.Unlock()
+
+	current, ok := b.presences[key]
+	if !ok {
+		return nil
+	}
+	return current.request
+}
+
+func (b *locketPresenceBackend) setHeld(key string, held bool) {
+	b.presencesLock.Lock()
+	defer b.presencesLock.Unlock()
+
+	if current, ok := b.presences[key]; ok {
+		current.held = held
+	}
+}
+
+type locketPresenceRunner struct {
+	logger        lager.Logger
+	backend       *locketPresenceBackend
+	key           string
+	retryInterval time.Duration
+}
+
+// Run locks the presence on every retry interval, which refreshes its TTL.
+// Until the presence is first acquired, failures are retried; once it has been
+// acquired, a failure to refresh it means the presence is lost.
+func (r *locketPresenceRunner) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	r.logger.Info("starting")
+	defer r.logger.Info("complete")
+
+	ticker := r.backend.clock.NewTicker(r.retryInterval)
+	defer ticker.Stop()
+
+	acquired := false
+	for {
+		request := r.backend.currentRequest(r.key)
+		if request == nil {
+			r.logger.Error("presence-released", ErrCellPresenceNotHeld)
+			return ErrCellPresenceNotHeld
+		}
+
+		err := r.backend.lock(request)
+		switch {
+		case err != nil && acquired:
+			r.logger.Error("lost-presence", err)
+			r.backend.setHeld(r.key, false)
+			return err
+		case err != nil:
+			r.logger.Error("failed-to-acquire-presence", err)
+		case !acquired:
+			r.logger.Info("acquired-presence")
+			acquired = true
+			r.backend.setHeld(r.key, true)
+			close(ready)
+		}
+
+		select {
+		case <-signals:
+			r.logger.Info("releasing-presence")
+			err := r.backend.release(r.key)
+			if err != nil {
+				r.logger.Error("failed-to-release-presence", err)
+			}
+			return nil
+		case <-ticker.C():
+		}
+	}
+}
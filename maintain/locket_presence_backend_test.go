@@ -0,0 +1,155 @@
+package maintain_test
+
+import (
+	"encoding/json"
+	"net"
+	"time"
+
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/lagertest"
+	locketmodels "code.cloudfoundry.org/locket/models"
+	"code.cloudfoundry.org/rep/maintain"
+	"code.cloudfoundry.org/rep/maintain/local_locket"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LocketPresenceBackend", func() {
+	var (
+		logger       *lagertest.TestLogger
+		fakeClock    *fakeclock.FakeClock
+		server       *local_locket.Server
+		conn         *grpc.ClientConn
+		locketClient locketmodels.LocketClient
+		backend      maintain.PresenceBackend
+		presence     models.CellPresence
+		process      ifrit.Process
+	)
+
+	fetchPresence := func() (*models.CellPresence, error) {
+		response, err := locketClient.Fetch(context.Background(), &locketmodels.FetchRequest{Key: "cell-id"})
+		if err != nil {
+			return nil, err
+		}
+
+		var fetched models.CellPresence
+		err = json.Unmarshal([]byte(response.Resource.Value), &fetched)
+		return &fetched, err
+	}
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+
+		server = local_locket.NewServer(fakeClock)
+		go server.Serve(listener)
+
+		conn, err = grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+		Expect(err).NotTo(HaveOccurred())
+		locketClient = locketmodels.NewLocketClient(conn)
+
+		backend = maintain.NewLocketPresenceBackend(locketClient, "rep-owner", fakeClock)
+		presence = models.NewCellPresence("cell-id", "1.2.3.4", "az1", models.NewCellCapacity(128, 1024, 6), nil, nil)
+	})
+
+	AfterEach(func() {
+		ginkgomon.Interrupt(process)
+		conn.Close()
+		server.Stop()
+	})
+
+	It("acquires the presence and becomes ready", func() {
+		process = ginkgomon.Invoke(backend.NewCellPresenceRunner(logger, &presence, time.Second, 10*time.Second))
+
+		fetched, err := fetchPresence()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fetched.CellId).To(Equal("cell-id"))
+		Expect(*fetched.Capacity).To(Equal(models.NewCellCapacity(128, 1024, 6)))
+	})
+
+	It("releases the presence when signaled", func() {
+		process = ginkgomon.Invoke(backend.NewCellPresenceRunner(logger, &presence, time.Second, 10*time.Second))
+		ginkgomon.Interrupt(process)
+
+		_, err := fetchPresence()
+		Expect(err).To(HaveOccurred())
+	})
+
+	Describe("UpdateCellPresence", func() {
+		It("replaces the value while the presence is held", func() {
+			process = ginkgomon.Invoke(backend.NewCellPresenceRunner(logger, &presence, time.Second, 10*time.Second))
+
+			updated := models.NewCellPresence("cell-id", "1.2.3.4", "az1", models.NewCellCapacity(256, 2048, 8), nil, nil)
+			Expect(backend.UpdateCellPresence(logger, &updated)).To(Succeed())
+
+			fetched, err := fetchPresence()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*fetched.Capacity).To(Equal(models.NewCellCapacity(256, 2048, 8)))
+
+			By("refreshing with the updated value")
+			fakeClock.WaitForWatcherAndIncrement(time.Second)
+			Consistently(func() int32 {
+				fetched, err := fetchPresence()
+				Expect(err).NotTo(HaveOccurred())
+				return fetched.Capacity.MemoryMb
+			}).Should(Equal(int32(256)))
+
+			Consistently(process.Wait()).ShouldNot(Receive())
+		})
+
+		It("fails when the presence is not held", func() {
+			Expect(backend.UpdateCellPresence(logger, &presence)).To(Equal(maintain.ErrCellPresenceNotHeld))
+		})
+	})
+
+	Context("when another owner holds the presence", func() {
+		BeforeEach(func() {
+			_, err := locketClient.Lock(context.Background(), &locketmodels.LockRequest{
+				Resource: &locketmodels.Resource{
+					Key:   "cell-id",
+					Owner: "other-owner",
+					Type:  locketmodels.PresenceType,
+				},
+				TtlInSeconds: 10,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("waits for the presence to become available", func() {
+			process = ifrit.Background(backend.NewCellPresenceRunner(logger, &presence, time.Second, 10*time.Second))
+			Consistently(process.Ready()).ShouldNot(BeClosed())
+
+			fakeClock.WaitForWatcherAndIncrement(10 * time.Second)
+			Eventually(process.Ready()).Should(BeClosed())
+		})
+	})
+
+	Context("when the presence is lost", func() {
+		It("exits with an error", func() {
+			process = ginkgomon.Invoke(backend.NewCellPresenceRunner(logger, &presence, time.Second, 2*time.Second))
+
+			_, err := locketClient.Release(context.Background(), &locketmodels.ReleaseRequest{
+				Resource: &locketmodels.Resource{Key: "cell-id", Owner: "rep-owner"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = locketClient.Lock(context.Background(), &locketmodels.LockRequest{
+				Resource:     &locketmodels.Resource{Key: "cell-id", Owner: "other-owner", Type: locketmodels.PresenceType},
+				TtlInSeconds: 10,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			fakeClock.WaitForWatcherAndIncrement(time.Second)
+			Eventually(process.Wait()).Should(Receive(HaveOccurred()))
+		})
+	})
+})
@@ -3,23 +3,27 @@ package maintain
 import (
 	"errors"
 	"os"
+	"sync"
 	"time"
 
-	"code.cloudfoundry.org/bbs"
 	"code.cloudfoundry.org/bbs/models"
 	"code.cloudfoundry.org/clock"
 	"code.cloudfoundry.org/executor"
 	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep"
 	"github.com/tedsuo/ifrit"
 )
 
 type Maintainer struct {
 	Config
-	executorClient executor.Client
-	serviceClient  bbs.ServiceClient
-	logger         lager.Logger
-	lockTTL        time.Duration
-	clock          clock.Clock
+	executorClient  executor.Client
+	presenceBackend PresenceBackend
+	logger          lager.Logger
+	lockTTL         time.Duration
+	clock           clock.Clock
+
+	configLock sync.Mutex
+	published  presenceState
 }
 
 type Config struct {
@@ -29,26 +33,94 @@ type Config struct {
 	RetryInterval     time.Duration
 	RootFSProviders   []string
 	PreloadedRootFSes []string
+	Overcommit        rep.OvercommitFactors
 }
 
 func New(
 	logger lager.Logger,
 	config Config,
 	executorClient executor.Client,
-	serviceClient bbs.ServiceClient,
+	presenceBackend PresenceBackend,
 	lockTTL time.Duration,
 	clock clock.Clock,
 ) *Maintainer {
 	return &Maintainer{
-		Config:         config,
-		executorClient: executorClient,
-		serviceClient:  serviceClient,
-		logger:         logger.Session("maintainer"),
-		lockTTL:        lockTTL,
-		clock:          clock,
+		Config:          config,
+		executorClient:  executorClient,
+		presenceBackend: presenceBackend,
+		logger:          logger.Session("maintainer"),
+		lockTTL:         lockTTL,
+		clock:           clock,
+	}
+}
+
+// presenceState is the part of the cell presence that can change while the
+// rep is running. The presence is republished whenever it changes, so it
+// holds only what the cell presence record carries.
+//
+// The volume drivers and the evacuation state are not republished: the BBS
+// cell presence model has no field for either, so a republished record would
+// be identical to the one already held. The auctioneer reads both from the
+// cell state served by the rep on every auction instead (see rep.CellState),
+// so changes to them reach it without touching the presence.
+type presenceState struct {
+	capacity          rep.Resources
+	realCapacity      rep.Resources
+	rootFSProviders   []string
+	preloadedRootFSes []string
+}
+
+func (s presenceState) Equal(other presenceState) bool {
+	if s.capacity != other.capacity {
+		return false
+	}
+
+	return equalStrings(s.rootFSProviders, other.rootFSProviders) &&
+		equalStrings(s.preloadedRootFSes, other.preloadedRootFSes)
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s presenceState) logData() lager.Data {
+	return lager.Data{
+		"capacity":         s.capacity,
+		"real-capacity":    s.realCapacity,
+		"rootfs-providers": s.rootFSProviders,
+		"preloaded-rootfs": s.preloadedRootFSes,
 	}
 }
 
+// Reconfigure changes the rootfs providers and overcommit factors in the cell
+// presence. The presence is republished on the next heartbeat without
+// releasing the lock.
+func (m *Maintainer) Reconfigure(rootFSProviders []string, overcommit rep.OvercommitFactors) {
+	m.configLock.Lock()
+	defer m.configLock.Unlock()
+
+	m.RootFSProviders = rootFSProviders
+	m.Overcommit = overcommit
+}
+
+// SetPreloadedRootFSes changes the preloaded rootfses in the cell presence.
+// The presence is republished on the next heartbeat without releasing the
+// lock.
+func (m *Maintainer) SetPreloadedRootFSes(preloadedRootFSes []string) {
+	m.configLock.Lock()
+	defer m.configLock.Unlock()
+
+	m.PreloadedRootFSes = preloadedRootFSes
+}
+
 const ExecutorPollInterval = time.Second
 
 var ErrSignaledWhileWaiting = errors.New("signaled while waiting for executor")
@@ -98,14 +170,69 @@ func (m *Maintainer) waitForExecutor(sigChan <-chan os.Signal) (ifrit.Runner, er
 }
 
 func (m *Maintainer) createHeartbeater() (ifrit.Runner, error) {
-	resources, err := m.executorClient.TotalResources(m.logger)
+	state, err := m.currentPresenceState()
 	if err != nil {
 		return nil, err
 	}
 
-	cellCapacity := models.NewCellCapacity(int32(resources.MemoryMB), int32(resources.DiskMB), int32(resources.Containers))
-	cellPresence := models.NewCellPresence(m.CellID, m.RepAddress, m.Zone, cellCapacity, m.RootFSProviders, m.PreloadedRootFSes)
-	return m.serviceClient.NewCellPresenceRunner(m.logger, &cellPresence, m.RetryInterval, m.lockTTL), nil
+	m.logger.Info("cell-presence-state", state.logData())
+	m.published = state
+
+	cellPresence := m.cellPresence(state)
+	return m.presenceBackend.NewCellPresenceRunner(m.logger, &cellPresence, m.RetryInterval, m.lockTTL), nil
+}
+
+func (m *Maintainer) currentPresenceState() (presenceState, error) {
+	resources, err := m.executorClient.TotalResources(m.logger)
+	if err != nil {
+		return presenceState{}, err
+	}
+
+	m.configLock.Lock()
+	overcommit := m.Overcommit
+	rootFSProviders := m.RootFSProviders
+	preloadedRootFSes := m.PreloadedRootFSes
+	m.configLock.Unlock()
+
+	realCapacity := rep.NewResources(int32(resources.MemoryMB), int32(resources.DiskMB), resources.Containers)
+	return presenceState{
+		capacity:          overcommit.ScaleTotal(realCapacity),
+		realCapacity:      realCapacity,
+		rootFSProviders:   rootFSProviders,
+		preloadedRootFSes: preloadedRootFSes,
+	}, nil
+}
+
+func (m *Maintainer) cellPresence(state presenceState) models.CellPresence {
+	cellCapacity := models.NewCellCapacity(state.capacity.MemoryMB, state.capacity.DiskMB, int32(state.capacity.Containers))
+	return models.NewCellPresence(m.CellID, m.RepAddress, m.Zone, cellCapacity, state.rootFSProviders, state.preloadedRootFSes)
+}
+
+// refreshPresence republishes the cell presence when its state has changed
+// since it was last published. The lock held by the heartbeater is kept.
+func (m *Maintainer) refreshPresence() {
+	state, err := m.currentPresenceState()
+	if err != nil {
+		m.logger.Error("failed-to-fetch-cell-presence-state", err)
+		return
+	}
+
+	if state.Equal(m.published) {
+		return
+	}
+
+	logger := m.logger.Session("refresh-cell-presence")
+	logger.Info("cell-presence-state-changed", lager.Data{"previous": m.published.logData(), "current": state.logData()})
+
+	cellPresence := m.cellPresence(state)
+	err = m.presenceBackend.UpdateCellPresence(logger, &cellPresence)
+	if err != nil {
+		logger.Error("failed-to-refresh-cell-presence", err)
+		return
+	}
+
+	m.published = state
+	logger.Info("refreshed-cell-presence")
 }
 
 func (m *Maintainer) heartbeat(sigChan <-chan os.Signal, ready chan<- struct{}, heartbeater ifrit.Runner) error {
@@ -151,6 +278,7 @@ func (m *Maintainer) heartbeat(sigChan <-chan os.Signal, ready chan<- struct{},
 			m.logger.Debug("heartbeat-pinging-executor")
 			err := m.executorClient.Ping(m.logger)
 			if err == nil {
+				m.refreshPresence()
 				continue
 			}
 
@@ -5,12 +5,13 @@ import (
 	"os"
 	"time"
 
-	"code.cloudfoundry.org/bbs/fake_bbs"
+	"code.cloudfoundry.org/bbs/models"
 	"code.cloudfoundry.org/clock/fakeclock"
 	"code.cloudfoundry.org/executor"
 	fake_client "code.cloudfoundry.org/executor/fakes"
 	"code.cloudfoundry.org/lager"
 	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/rep"
 	"code.cloudfoundry.org/rep/maintain"
 	maintain_fakes "code.cloudfoundry.org/rep/maintain/fakes"
 	"github.com/tedsuo/ifrit"
@@ -26,7 +27,7 @@ var _ = Describe("Maintain Presence", func() {
 		config          maintain.Config
 		fakeHeartbeater *maintain_fakes.FakeRunner
 		fakeClient      *fake_client.FakeClient
-		serviceClient   *fake_bbs.FakeServiceClient
+		presenceBackend *maintain_fakes.FakePresenceBackend
 		logger          *lagertest.TestLogger
 
 		maintainer        ifrit.Runner
@@ -71,8 +72,8 @@ var _ = Describe("Maintain Presence", func() {
 			},
 		}
 
-		serviceClient = &fake_bbs.FakeServiceClient{}
-		serviceClient.NewCellPresenceRunnerReturns(fakeHeartbeater)
+		presenceBackend = &maintain_fakes.FakePresenceBackend{}
+		presenceBackend.NewCellPresenceRunnerReturns(fakeHeartbeater)
 
 		config = maintain.Config{
 			CellID:          "cell-id",
@@ -81,7 +82,7 @@ var _ = Describe("Maintain Presence", func() {
 			RetryInterval:   1 * time.Second,
 			RootFSProviders: []string{"provider-1", "provider-2"},
 		}
-		maintainer = maintain.New(logger, config, fakeClient, serviceClient, 10*time.Second, clock)
+		maintainer = maintain.New(logger, config, fakeClient, presenceBackend, 10*time.Second, clock)
 	})
 
 	AfterEach(func() {
@@ -137,7 +138,7 @@ var _ = Describe("Maintain Presence", func() {
 					},
 				}
 
-				serviceClient.NewCellPresenceRunnerReturns(fakeHeartbeater)
+				presenceBackend.NewCellPresenceRunnerReturns(fakeHeartbeater)
 
 				pingErrors <- nil
 				maintainProcess = ifrit.Background(maintainer)
@@ -162,12 +163,28 @@ var _ = Describe("Maintain Presence", func() {
 				})
 
 				It("retries to heartbeat", func() {
-					Eventually(serviceClient.NewCellPresenceRunnerCallCount).Should(Equal(2))
+					Eventually(presenceBackend.NewCellPresenceRunnerCallCount).Should(Equal(2))
 					Eventually(fakeHeartbeater.RunCallCount).Should(Equal(2))
 				})
 			})
 		})
 
+		Context("when overcommit factors are configured", func() {
+			BeforeEach(func() {
+				config.Overcommit = rep.NewOvercommitFactors(1.5, 2.0)
+				maintainer = maintain.New(logger, config, fakeClient, presenceBackend, 10*time.Second, clock)
+
+				pingErrors <- nil
+				maintainProcess = ginkgomon.Invoke(maintainer)
+			})
+
+			It("presents the overcommitted capacity", func() {
+				Expect(presenceBackend.NewCellPresenceRunnerCallCount()).To(Equal(1))
+				_, cellPresence, _, _ := presenceBackend.NewCellPresenceRunnerArgsForCall(0)
+				Expect(*cellPresence.Capacity).To(Equal(models.NewCellCapacity(192, 2048, 6)))
+			})
+		})
+
 		Context("when the heartbeater is ready", func() {
 			BeforeEach(func() {
 				pingErrors <- nil
@@ -177,10 +194,15 @@ var _ = Describe("Maintain Presence", func() {
 			})
 
 			It("starts maintaining presence", func() {
-				Expect(serviceClient.NewCellPresenceRunnerCallCount()).To(Equal(1))
+				Expect(presenceBackend.NewCellPresenceRunnerCallCount()).To(Equal(1))
 				Eventually(fakeHeartbeater.RunCallCount).Should(Equal(1))
 			})
 
+			It("presents the executor's total resources as the cell capacity", func() {
+				_, cellPresence, _, _ := presenceBackend.NewCellPresenceRunnerArgsForCall(0)
+				Expect(*cellPresence.Capacity).To(Equal(models.NewCellCapacity(128, 1024, 6)))
+			})
+
 			It("continues pings the executor on an interval", func() {
 				for i := 2; i < 6; i++ {
 					pingErrors <- nil
@@ -189,6 +211,94 @@ var _ = Describe("Maintain Presence", func() {
 				}
 			})
 
+			It("does not refresh the presence while nothing changes", func() {
+				pingErrors <- nil
+				clock.Increment(1 * time.Second)
+				Eventually(fakeClient.PingCallCount).Should(Equal(2))
+				Eventually(fakeClient.TotalResourcesCallCount).Should(Equal(2))
+
+				Consistently(presenceBackend.UpdateCellPresenceCallCount).Should(Equal(0))
+			})
+
+			Context("when the capacity changes", func() {
+				BeforeEach(func() {
+					fakeClient.TotalResourcesReturns(executor.ExecutorResources{MemoryMB: 256, DiskMB: 2048, Containers: 8}, nil)
+					pingErrors <- nil
+					clock.Increment(1 * time.Second)
+				})
+
+				It("refreshes the presence without restarting the heartbeater", func() {
+					Eventually(presenceBackend.UpdateCellPresenceCallCount).Should(Equal(1))
+					_, cellPresence := presenceBackend.UpdateCellPresenceArgsForCall(0)
+					Expect(cellPresence.CellId).To(Equal("cell-id"))
+					Expect(*cellPresence.Capacity).To(Equal(models.NewCellCapacity(256, 2048, 8)))
+
+					Expect(presenceBackend.NewCellPresenceRunnerCallCount()).To(Equal(1))
+					Consistently(observedSignals).ShouldNot(Receive())
+				})
+
+				It("does not refresh the same state twice", func() {
+					Eventually(presenceBackend.UpdateCellPresenceCallCount).Should(Equal(1))
+
+					pingErrors <- nil
+					clock.Increment(1 * time.Second)
+					Eventually(fakeClient.PingCallCount).Should(Equal(3))
+					Consistently(presenceBackend.UpdateCellPresenceCallCount).Should(Equal(1))
+				})
+
+				Context("when refreshing the presence fails", func() {
+					BeforeEach(func() {
+						presenceBackend.UpdateCellPresenceReturns(errors.New("boom"))
+					})
+
+					It("retries on the next interval", func() {
+						Eventually(presenceBackend.UpdateCellPresenceCallCount).Should(Equal(1))
+
+						pingErrors <- nil
+						clock.Increment(1 * time.Second)
+						Eventually(presenceBackend.UpdateCellPresenceCallCount).Should(Equal(2))
+					})
+				})
+			})
+
+			Context("when the volume drivers change", func() {
+				BeforeEach(func() {
+					fakeClient.VolumeDriversReturns([]string{"driver-1"}, nil)
+					pingErrors <- nil
+					clock.Increment(1 * time.Second)
+				})
+
+				// the cell presence model has no volume drivers; the auctioneer
+				// reads them from the cell state instead
+				It("does not refresh the presence, which does not carry them", func() {
+					Eventually(fakeClient.PingCallCount).Should(Equal(2))
+					Consistently(presenceBackend.UpdateCellPresenceCallCount).Should(Equal(0))
+				})
+			})
+
+			Context("when the maintainer is reconfigured", func() {
+				BeforeEach(func() {
+					maintainer.(*maintain.Maintainer).Reconfigure([]string{"provider-3"}, rep.NewOvercommitFactors(2.0, 1.0))
+					pingErrors <- nil
+					clock.Increment(1 * time.Second)
+				})
+
+				It("refreshes the presence with the new providers and capacity", func() {
+					Eventually(presenceBackend.UpdateCellPresenceCallCount).Should(Equal(1))
+					_, cellPresence := presenceBackend.UpdateCellPresenceArgsForCall(0)
+					Expect(*cellPresence.Capacity).To(Equal(models.NewCellCapacity(256, 1024, 6)))
+
+					providerNames := []string{}
+					for _, provider := range cellPresence.RootfsProviders {
+						providerNames = append(providerNames, provider.Name)
+					}
+					Expect(providerNames).To(ContainElement("provider-3"))
+					Expect(providerNames).NotTo(ContainElement("provider-1"))
+
+					Expect(presenceBackend.NewCellPresenceRunnerCallCount()).To(Equal(1))
+				})
+			})
+
 			Context("when the executor ping fails", func() {
 				BeforeEach(func() {
 					pingErrors <- errors.New("failed to ping")
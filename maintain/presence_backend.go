@@ -0,0 +1,25 @@
+package maintain
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/lager"
+	"github.com/tedsuo/ifrit"
+)
+
+var ErrCellPresenceNotHeld = errors.New("cell presence is not held")
+
+//go:generate counterfeiter -o fakes/fake_presence_backend.go . PresenceBackend
+
+// PresenceBackend maintains the cell presence record in a lock service.
+type PresenceBackend interface {
+	// NewCellPresenceRunner returns a runner that acquires the presence and
+	// holds it until signaled. It exits with an error if the presence is lost.
+	NewCellPresenceRunner(logger lager.Logger, presence *models.CellPresence, retryInterval, lockTTL time.Duration) ifrit.Runner
+
+	// UpdateCellPresence replaces the value of a presence that is currently
+	// held, without releasing the lock that backs it.
+	UpdateCellPresence(logger lager.Logger, presence *models.CellPresence) error
+}
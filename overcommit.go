@@ -0,0 +1,52 @@
+package rep
+
+import "errors"
+
+var ErrorInvalidOvercommitFactor = errors.New("overcommit factors must be at least 1.0")
+
+// OvercommitFactors scale the memory and disk a cell advertises beyond what
+// the executor actually has. Container slots are never overcommitted. A zero
+// factor is treated as 1.0, so the zero value does not overcommit.
+type OvercommitFactors struct {
+	Memory float64
+	Disk   float64
+}
+
+func NewOvercommitFactors(memory, disk float64) OvercommitFactors {
+	return OvercommitFactors{Memory: memory, Disk: disk}
+}
+
+func (o OvercommitFactors) Validate() error {
+	if o.Memory < 1.0 || o.Disk < 1.0 {
+		return ErrorInvalidOvercommitFactor
+	}
+	return nil
+}
+
+// ScaleTotal returns the overcommitted total resources of a cell.
+func (o OvercommitFactors) ScaleTotal(total Resources) Resources {
+	return Resources{
+		MemoryMB:   scale(total.MemoryMB, o.Memory),
+		DiskMB:     scale(total.DiskMB, o.Disk),
+		Containers: total.Containers,
+	}
+}
+
+// ScaleAvailable returns the overcommitted available resources of a cell. The
+// extra capacity granted by overcommitting the total is added to the real
+// available resources, so that resources in use are accounted one-for-one.
+func (o OvercommitFactors) ScaleAvailable(available, total Resources) Resources {
+	scaledTotal := o.ScaleTotal(total)
+	return Resources{
+		MemoryMB:   available.MemoryMB + scaledTotal.MemoryMB - total.MemoryMB,
+		DiskMB:     available.DiskMB + scaledTotal.DiskMB - total.DiskMB,
+		Containers: available.Containers,
+	}
+}
+
+func scale(value int32, factor float64) int32 {
+	if factor <= 0 {
+		return value
+	}
+	return int32(float64(value) * factor)
+}
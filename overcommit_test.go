@@ -0,0 +1,53 @@
+package rep_test
+
+import (
+	"code.cloudfoundry.org/rep"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OvercommitFactors", func() {
+	var (
+		overcommit rep.OvercommitFactors
+		total      rep.Resources
+		available  rep.Resources
+	)
+
+	BeforeEach(func() {
+		overcommit = rep.NewOvercommitFactors(1.5, 2.0)
+		total = rep.NewResources(1024, 2048, 10)
+		available = rep.NewResources(256, 1024, 4)
+	})
+
+	Describe("Validate", func() {
+		It("accepts factors of at least 1.0", func() {
+			Expect(overcommit.Validate()).To(Succeed())
+			Expect(rep.NewOvercommitFactors(1.0, 1.0).Validate()).To(Succeed())
+		})
+
+		It("rejects factors below 1.0", func() {
+			Expect(rep.NewOvercommitFactors(0.5, 1.0).Validate()).To(Equal(rep.ErrorInvalidOvercommitFactor))
+			Expect(rep.NewOvercommitFactors(1.0, 0).Validate()).To(Equal(rep.ErrorInvalidOvercommitFactor))
+		})
+	})
+
+	Describe("ScaleTotal", func() {
+		It("scales memory and disk but not containers", func() {
+			Expect(overcommit.ScaleTotal(total)).To(Equal(rep.NewResources(1536, 4096, 10)))
+		})
+
+		It("leaves the resources unchanged for the zero value", func() {
+			Expect(rep.OvercommitFactors{}.ScaleTotal(total)).To(Equal(total))
+		})
+	})
+
+	Describe("ScaleAvailable", func() {
+		It("adds the overcommitted capacity to the available resources", func() {
+			Expect(overcommit.ScaleAvailable(available, total)).To(Equal(rep.NewResources(768, 3072, 4)))
+		})
+
+		It("leaves the resources unchanged for the zero value", func() {
+			Expect(rep.OvercommitFactors{}.ScaleAvailable(available, total)).To(Equal(available))
+		})
+	})
+})
@@ -0,0 +1,42 @@
+// This file was generated by counterfeiter
+package fake_preloaded_rootfs
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/preloaded_rootfs"
+)
+
+type FakeStackListener struct {
+	SetPreloadedStacksStub        func(stacks rep.StackPathMap)
+	setPreloadedStacksMutex       sync.RWMutex
+	setPreloadedStacksArgsForCall []struct {
+		stacks rep.StackPathMap
+	}
+}
+
+func (fake *FakeStackListener) SetPreloadedStacks(stacks rep.StackPathMap) {
+	fake.setPreloadedStacksMutex.Lock()
+	fake.setPreloadedStacksArgsForCall = append(fake.setPreloadedStacksArgsForCall, struct {
+		stacks rep.StackPathMap
+	}{stacks})
+	fake.setPreloadedStacksMutex.Unlock()
+	if fake.SetPreloadedStacksStub != nil {
+		fake.SetPreloadedStacksStub(stacks)
+	}
+}
+
+func (fake *FakeStackListener) SetPreloadedStacksCallCount() int {
+	fake.setPreloadedStacksMutex.RLock()
+	defer fake.setPreloadedStacksMutex.RUnlock()
+	return len(fake.setPreloadedStacksArgsForCall)
+}
+
+func (fake *FakeStackListener) SetPreloadedStacksArgsForCall(i int) rep.StackPathMap {
+	fake.setPreloadedStacksMutex.RLock()
+	defer fake.setPreloadedStacksMutex.RUnlock()
+	return fake.setPreloadedStacksArgsForCall[i].stacks
+}
+
+var _ preloaded_rootfs.StackListener = new(FakeStackListener)
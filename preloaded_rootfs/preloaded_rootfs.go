@@ -0,0 +1,246 @@
+// preloaded_rootfs discovers the preloaded stacks of a cell from a directory
+package preloaded_rootfs
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep"
+)
+
+// MetadataFile describes a stack image. Every subdirectory of the scanned
+// directory holding one is a candidate stack.
+const MetadataFile = "metadata.json"
+
+// DefaultRootFS is the path of the stack image, relative to its metadata
+// file, when the metadata does not name one.
+const DefaultRootFS = "rootfs"
+
+var (
+	ErrMissingName  = errors.New("metadata has no stack name")
+	ErrInvalidName  = errors.New("stack names and versions may not contain '" + rep.StackVersionSeparator + "' or ':'")
+	ErrNotDirectory = errors.New("preloaded rootfs path is not a directory")
+)
+
+type Metadata struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	RootFS  string `json:"rootfs,omitempty"`
+}
+
+// Scan returns the stacks described by the metadata files in the
+// subdirectories of dir. Stacks whose metadata is invalid or whose image
+// cannot be read are logged and skipped. It fails only when dir itself cannot
+// be read.
+func Scan(logger lager.Logger, dir string) (rep.StackPathMap, error) {
+	logger = logger.Session("scan", lager.Data{"dir": dir})
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		logger.Error("failed-to-read-dir", err)
+		return nil, err
+	}
+
+	stacks := rep.StackPathMap{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		stackDir := filepath.Join(dir, entry.Name())
+		metadata, err := readMetadata(stackDir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			logger.Error("invalid-metadata", err, lager.Data{"stack-dir": stackDir})
+			continue
+		}
+
+		path := metadata.RootFS
+		if path == "" {
+			path = DefaultRootFS
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(stackDir, path)
+		}
+
+		err = checkReadable(path)
+		if err != nil {
+			logger.Error("unreadable-rootfs", err, lager.Data{"stack-dir": stackDir, "path": path})
+			continue
+		}
+
+		key := rep.StackKey(metadata.Name, metadata.Version)
+		if existing, ok := stacks[key]; ok {
+			logger.Info("ignoring-duplicate-stack", lager.Data{"stack": key, "path": path, "existing-path": existing})
+			continue
+		}
+		stacks[key] = path
+	}
+
+	return stacks, nil
+}
+
+func readMetadata(stackDir string) (Metadata, error) {
+	var metadata Metadata
+
+	payload, err := ioutil.ReadFile(filepath.Join(stackDir, MetadataFile))
+	if err != nil {
+		return metadata, err
+	}
+
+	err = json.Unmarshal(payload, &metadata)
+	if err != nil {
+		return metadata, err
+	}
+
+	switch {
+	case metadata.Name == "":
+		return metadata, ErrMissingName
+	case strings.ContainsAny(metadata.Name+metadata.Version, rep.StackVersionSeparator+":"):
+		return metadata, ErrInvalidName
+	}
+
+	return metadata, nil
+}
+
+func checkReadable(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return ErrNotDirectory
+	}
+
+	_, err = f.Readdirnames(1)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+//go:generate counterfeiter -o fake_preloaded_rootfs/fake_stack_listener.go . StackListener
+
+// StackListener is told about the preloaded stacks whenever they change.
+type StackListener interface {
+	SetPreloadedStacks(stacks rep.StackPathMap)
+}
+
+// The StackListenerFunc type is an adapter to allow the use of ordinary
+// functions as stack listeners.
+type StackListenerFunc func(stacks rep.StackPathMap)
+
+func (f StackListenerFunc) SetPreloadedStacks(stacks rep.StackPathMap) {
+	f(stacks)
+}
+
+// Scanner rescans a directory of preloaded stacks periodically and notifies
+// its listeners when stacks appear or disappear.
+type Scanner struct {
+	logger    lager.Logger
+	dir       string
+	interval  time.Duration
+	clock     clock.Clock
+	static    rep.StackPathMap
+	listeners []StackListener
+
+	current rep.StackPathMap
+}
+
+// NewScanner returns a scanner of dir. The static stacks, usually given on
+// the command line, are always offered and take precedence over scanned
+// stacks with the same name and version. current is the set of stacks the
+// listeners were last told about.
+func NewScanner(
+	logger lager.Logger,
+	dir string,
+	interval time.Duration,
+	clock clock.Clock,
+	static rep.StackPathMap,
+	current rep.StackPathMap,
+	listeners ...StackListener,
+) *Scanner {
+	return &Scanner{
+		logger:    logger.Session("preloaded-rootfs-scanner"),
+		dir:       dir,
+		interval:  interval,
+		clock:     clock,
+		static:    static,
+		listeners: listeners,
+		current:   current,
+	}
+}
+
+// Merge returns the scanned stacks together with the static ones.
+func Merge(static, scanned rep.StackPathMap) rep.StackPathMap {
+	merged := rep.StackPathMap{}
+	for key, path := range scanned {
+		merged[key] = path
+	}
+	for key, path := range static {
+		merged[key] = path
+	}
+	return merged
+}
+
+func (s *Scanner) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	logger := s.logger
+	logger.Info("starting", lager.Data{"dir": s.dir, "interval": s.interval.String()})
+	defer logger.Info("finished")
+
+	timer := s.clock.NewTimer(s.interval)
+	defer timer.Stop()
+
+	close(ready)
+
+	for {
+		select {
+		case <-timer.C():
+			s.rescan()
+			timer.Reset(s.interval)
+		case <-signals:
+			return nil
+		}
+	}
+}
+
+func (s *Scanner) rescan() {
+	logger := s.logger.Session("rescan")
+
+	scanned, err := Scan(logger, s.dir)
+	if err != nil {
+		return
+	}
+
+	stacks := Merge(s.static, scanned)
+	if reflect.DeepEqual(stacks, s.current) {
+		return
+	}
+
+	logger.Info("preloaded-stacks-changed", lager.Data{
+		"previous": s.current.PreloadedRootFSes(),
+		"current":  stacks.PreloadedRootFSes(),
+	})
+
+	s.current = stacks
+	for _, listener := range s.listeners {
+		listener.SetPreloadedStacks(stacks)
+	}
+}
@@ -0,0 +1,13 @@
+package preloaded_rootfs_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestPreloadedRootFS(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Preloaded RootFS Suite")
+}
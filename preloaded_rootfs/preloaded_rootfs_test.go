@@ -0,0 +1,162 @@
+package preloaded_rootfs_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/preloaded_rootfs"
+	"code.cloudfoundry.org/rep/preloaded_rootfs/fake_preloaded_rootfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/tedsuo/ifrit"
+)
+
+var _ = Describe("PreloadedRootFS", func() {
+	var (
+		logger *lagertest.TestLogger
+		dir    string
+	)
+
+	addStack := func(subdir, metadata string) string {
+		stackDir := filepath.Join(dir, subdir)
+		Expect(os.MkdirAll(filepath.Join(stackDir, preloaded_rootfs.DefaultRootFS), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(stackDir, preloaded_rootfs.MetadataFile), []byte(metadata), 0644)).To(Succeed())
+		return filepath.Join(stackDir, preloaded_rootfs.DefaultRootFS)
+	}
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+
+		var err error
+		dir, err = ioutil.TempDir("", "preloaded-rootfs")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Describe("Scan", func() {
+		It("finds stacks described by metadata files", func() {
+			linux := addStack("linux", `{"name": "cflinuxfs2"}`)
+			linuxOld := addStack("linux-1.9", `{"name": "cflinuxfs2", "version": "1.9"}`)
+
+			stacks, err := preloaded_rootfs.Scan(logger, dir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stacks).To(Equal(rep.StackPathMap{
+				"cflinuxfs2":     linux,
+				"cflinuxfs2@1.9": linuxOld,
+			}))
+		})
+
+		It("resolves the rootfs named in the metadata", func() {
+			addStack("windows", `{"name": "windows2012R2", "rootfs": "image"}`)
+			image := filepath.Join(dir, "windows", "image")
+			Expect(os.Mkdir(image, 0755)).To(Succeed())
+
+			stacks, err := preloaded_rootfs.Scan(logger, dir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stacks).To(Equal(rep.StackPathMap{"windows2012R2": image}))
+		})
+
+		It("ignores directories without metadata", func() {
+			Expect(os.Mkdir(filepath.Join(dir, "scratch"), 0755)).To(Succeed())
+
+			stacks, err := preloaded_rootfs.Scan(logger, dir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stacks).To(BeEmpty())
+		})
+
+		It("skips stacks with invalid metadata", func() {
+			addStack("garbage", `{"name":`)
+			addStack("nameless", `{"version": "1.0"}`)
+			addStack("sneaky", `{"name": "cflinuxfs2@1.0"}`)
+
+			stacks, err := preloaded_rootfs.Scan(logger, dir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stacks).To(BeEmpty())
+			Expect(logger).To(gbytes.Say("invalid-metadata"))
+		})
+
+		It("skips stacks whose rootfs is missing", func() {
+			addStack("linux", `{"name": "cflinuxfs2", "rootfs": "missing"}`)
+
+			stacks, err := preloaded_rootfs.Scan(logger, dir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stacks).To(BeEmpty())
+			Expect(logger).To(gbytes.Say("unreadable-rootfs"))
+		})
+
+		It("fails when the directory cannot be read", func() {
+			_, err := preloaded_rootfs.Scan(logger, filepath.Join(dir, "missing"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Scanner", func() {
+		var (
+			fakeClock *fakeclock.FakeClock
+			listener  *fake_preloaded_rootfs.FakeStackListener
+			static    rep.StackPathMap
+			linux     string
+			process   ifrit.Process
+		)
+
+		BeforeEach(func() {
+			fakeClock = fakeclock.NewFakeClock(time.Now())
+			listener = new(fake_preloaded_rootfs.FakeStackListener)
+			static = rep.StackPathMap{"static": "/rootfs/static"}
+			linux = addStack("linux", `{"name": "cflinuxfs2"}`)
+
+			current := preloaded_rootfs.Merge(static, rep.StackPathMap{"cflinuxfs2": linux})
+			scanner := preloaded_rootfs.NewScanner(logger, dir, time.Minute, fakeClock, static, current, listener)
+			process = ifrit.Invoke(scanner)
+		})
+
+		AfterEach(func() {
+			ifrit.Interrupt(process)
+			Eventually(process.Wait()).Should(Receive())
+		})
+
+		It("does not notify the listeners while the stacks are unchanged", func() {
+			fakeClock.WaitForWatcherAndIncrement(time.Minute)
+			Consistently(listener.SetPreloadedStacksCallCount).Should(BeZero())
+		})
+
+		It("notifies the listeners when stacks appear", func() {
+			linuxOld := addStack("linux-1.9", `{"name": "cflinuxfs2", "version": "1.9"}`)
+
+			fakeClock.WaitForWatcherAndIncrement(time.Minute)
+			Eventually(listener.SetPreloadedStacksCallCount).Should(Equal(1))
+			Expect(listener.SetPreloadedStacksArgsForCall(0)).To(Equal(rep.StackPathMap{
+				"static":         "/rootfs/static",
+				"cflinuxfs2":     linux,
+				"cflinuxfs2@1.9": linuxOld,
+			}))
+		})
+
+		It("notifies the listeners when stacks disappear", func() {
+			Expect(os.RemoveAll(filepath.Join(dir, "linux"))).To(Succeed())
+
+			fakeClock.WaitForWatcherAndIncrement(time.Minute)
+			Eventually(listener.SetPreloadedStacksCallCount).Should(Equal(1))
+			Expect(listener.SetPreloadedStacksArgsForCall(0)).To(Equal(rep.StackPathMap{"static": "/rootfs/static"}))
+		})
+
+		It("rescans periodically", func() {
+			Expect(os.RemoveAll(filepath.Join(dir, "linux"))).To(Succeed())
+			fakeClock.WaitForWatcherAndIncrement(time.Minute)
+			Eventually(listener.SetPreloadedStacksCallCount).Should(Equal(1))
+
+			addStack("linux", `{"name": "cflinuxfs2"}`)
+			fakeClock.WaitForWatcherAndIncrement(time.Minute)
+			Eventually(listener.SetPreloadedStacksCallCount).Should(Equal(2))
+		})
+	})
+})
@@ -0,0 +1,174 @@
+// This file was generated by counterfeiter
+package fake_quarantine
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep/quarantine"
+)
+
+type FakeTracker struct {
+	AdmitStub        func(logger lager.Logger, requested int) int
+	admitMutex       sync.RWMutex
+	admitArgsForCall []struct {
+		logger    lager.Logger
+		requested int
+	}
+	admitReturns struct {
+		result1 int
+	}
+	RecordSuccessStub        func(logger lager.Logger)
+	recordSuccessMutex       sync.RWMutex
+	recordSuccessArgsForCall []struct {
+		logger lager.Logger
+	}
+	RecordFailureStub        func(logger lager.Logger, reason string)
+	recordFailureMutex       sync.RWMutex
+	recordFailureArgsForCall []struct {
+		logger lager.Logger
+		reason string
+	}
+	QuarantinedStub        func() bool
+	quarantinedMutex       sync.RWMutex
+	quarantinedArgsForCall []struct{}
+	quarantinedReturns     struct {
+		result1 bool
+	}
+	RecordRunFailureStub        func(logger lager.Logger, reason string)
+	recordRunFailureMutex       sync.RWMutex
+	recordRunFailureArgsForCall []struct {
+		logger lager.Logger
+		reason string
+	}
+}
+
+func (fake *FakeTracker) Admit(logger lager.Logger, requested int) int {
+	fake.admitMutex.Lock()
+	fake.admitArgsForCall = append(fake.admitArgsForCall, struct {
+		logger    lager.Logger
+		requested int
+	}{logger, requested})
+	fake.admitMutex.Unlock()
+	if fake.AdmitStub != nil {
+		return fake.AdmitStub(logger, requested)
+	} else {
+		return fake.admitReturns.result1
+	}
+}
+
+func (fake *FakeTracker) AdmitCallCount() int {
+	fake.admitMutex.RLock()
+	defer fake.admitMutex.RUnlock()
+	return len(fake.admitArgsForCall)
+}
+
+func (fake *FakeTracker) AdmitArgsForCall(i int) (lager.Logger, int) {
+	fake.admitMutex.RLock()
+	defer fake.admitMutex.RUnlock()
+	return fake.admitArgsForCall[i].logger, fake.admitArgsForCall[i].requested
+}
+
+func (fake *FakeTracker) AdmitReturns(result1 int) {
+	fake.AdmitStub = nil
+	fake.admitReturns = struct {
+		result1 int
+	}{result1}
+}
+
+func (fake *FakeTracker) RecordSuccess(logger lager.Logger) {
+	fake.recordSuccessMutex.Lock()
+	fake.recordSuccessArgsForCall = append(fake.recordSuccessArgsForCall, struct {
+		logger lager.Logger
+	}{logger})
+	fake.recordSuccessMutex.Unlock()
+	if fake.RecordSuccessStub != nil {
+		fake.RecordSuccessStub(logger)
+	}
+}
+
+func (fake *FakeTracker) RecordSuccessCallCount() int {
+	fake.recordSuccessMutex.RLock()
+	defer fake.recordSuccessMutex.RUnlock()
+	return len(fake.recordSuccessArgsForCall)
+}
+
+func (fake *FakeTracker) RecordSuccessArgsForCall(i int) lager.Logger {
+	fake.recordSuccessMutex.RLock()
+	defer fake.recordSuccessMutex.RUnlock()
+	return fake.recordSuccessArgsForCall[i].logger
+}
+
+func (fake *FakeTracker) RecordFailure(logger lager.Logger, reason string) {
+	fake.recordFailureMutex.Lock()
+	fake.recordFailureArgsForCall = append(fake.recordFailureArgsForCall, struct {
+		logger lager.Logger
+		reason string
+	}{logger, reason})
+	fake.recordFailureMutex.Unlock()
+	if fake.RecordFailureStub != nil {
+		fake.RecordFailureStub(logger, reason)
+	}
+}
+
+func (fake *FakeTracker) RecordFailureCallCount() int {
+	fake.recordFailureMutex.RLock()
+	defer fake.recordFailureMutex.RUnlock()
+	return len(fake.recordFailureArgsForCall)
+}
+
+func (fake *FakeTracker) RecordFailureArgsForCall(i int) (lager.Logger, string) {
+	fake.recordFailureMutex.RLock()
+	defer fake.recordFailureMutex.RUnlock()
+	return fake.recordFailureArgsForCall[i].logger, fake.recordFailureArgsForCall[i].reason
+}
+
+func (fake *FakeTracker) Quarantined() bool {
+	fake.quarantinedMutex.Lock()
+	fake.quarantinedArgsForCall = append(fake.quarantinedArgsForCall, struct{}{})
+	fake.quarantinedMutex.Unlock()
+	if fake.QuarantinedStub != nil {
+		return fake.QuarantinedStub()
+	} else {
+		return fake.quarantinedReturns.result1
+	}
+}
+
+func (fake *FakeTracker) QuarantinedCallCount() int {
+	fake.quarantinedMutex.RLock()
+	defer fake.quarantinedMutex.RUnlock()
+	return len(fake.quarantinedArgsForCall)
+}
+
+func (fake *FakeTracker) QuarantinedReturns(result1 bool) {
+	fake.QuarantinedStub = nil
+	fake.quarantinedReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeTracker) RecordRunFailure(logger lager.Logger, reason string) {
+	fake.recordRunFailureMutex.Lock()
+	fake.recordRunFailureArgsForCall = append(fake.recordRunFailureArgsForCall, struct {
+		logger lager.Logger
+		reason string
+	}{logger, reason})
+	fake.recordRunFailureMutex.Unlock()
+	if fake.RecordRunFailureStub != nil {
+		fake.RecordRunFailureStub(logger, reason)
+	}
+}
+
+func (fake *FakeTracker) RecordRunFailureCallCount() int {
+	fake.recordRunFailureMutex.RLock()
+	defer fake.recordRunFailureMutex.RUnlock()
+	return len(fake.recordRunFailureArgsForCall)
+}
+
+func (fake *FakeTracker) RecordRunFailureArgsForCall(i int) (lager.Logger, string) {
+	fake.recordRunFailureMutex.RLock()
+	defer fake.recordRunFailureMutex.RUnlock()
+	return fake.recordRunFailureArgsForCall[i].logger, fake.recordRunFailureArgsForCall[i].reason
+}
+
+var _ quarantine.Tracker = new(FakeTracker)
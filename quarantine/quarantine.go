@@ -0,0 +1,190 @@
+// quarantine takes a cell out of rotation when its containers keep failing
+package quarantine
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/runtimeschema/metric"
+)
+
+const cellQuarantined = metric.Metric("CellQuarantined")
+
+var ErrInvalidProbes = errors.New("quarantine probes must be at least 1 when the failure threshold is set")
+
+type Config struct {
+	// FailureThreshold is the number of failures within FailureWindow that
+	// quarantines the cell. Zero disables quarantine.
+	FailureThreshold int
+	FailureWindow    time.Duration
+	// CoolDown is how long the cell stays quarantined before probing.
+	CoolDown time.Duration
+	// Probes is the number of work items admitted after the cool-down. A
+	// successful probe lifts the quarantine and a failed one restarts it.
+	Probes int
+}
+
+// Validate rejects a config that would quarantine the cell without ever
+// admitting a probe to lift the quarantine.
+func (c Config) Validate() error {
+	if c.FailureThreshold > 0 && c.Probes < 1 {
+		return ErrInvalidProbes
+	}
+	return nil
+}
+
+//go:generate counterfeiter -o fake_quarantine/fake_tracker.go . Tracker
+
+// Tracker is a circuit breaker over allocation and run failures.
+type Tracker interface {
+	// Admit returns how many of the requested work items may be attempted.
+	Admit(logger lager.Logger, requested int) int
+	// RecordSuccess and RecordFailure record the outcome of an allocation.
+	// While probing, the allocations are the probes, so their outcome lifts
+	// or restarts the quarantine.
+	RecordSuccess(logger lager.Logger)
+	RecordFailure(logger lager.Logger, reason string)
+	// RecordRunFailure records a failure to run an allocated container. It
+	// counts towards the threshold but never ends probing, since the
+	// container may have been admitted before the cell was quarantined.
+	RecordRunFailure(logger lager.Logger, reason string)
+	Quarantined() bool
+}
+
+type state int
+
+const (
+	closed state = iota
+	open
+	probing
+)
+
+type tracker struct {
+	config Config
+	clock  clock.Clock
+
+	lock            sync.Mutex
+	state           state
+	failures        []time.Time
+	openedAt        time.Time
+	probesRemaining int
+}
+
+func NewTracker(config Config, clock clock.Clock) Tracker {
+	return &tracker{
+		config: config,
+		clock:  clock,
+	}
+}
+
+func (t *tracker) Admit(logger lager.Logger, requested int) int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.state == closed {
+		return requested
+	}
+
+	// A probe whose outcome is never recorded must not leave the cell
+	// quarantined forever, so the probes are reissued after each cool-down.
+	now := t.clock.Now()
+	if t.probesRemaining == 0 && now.Sub(t.openedAt) >= t.config.CoolDown {
+		logger.Info("quarantine-cool-down-elapsed", lager.Data{"probes": t.config.Probes})
+		t.state = probing
+		t.openedAt = now
+		t.probesRemaining = t.config.Probes
+	}
+
+	admitted := requested
+	if admitted > t.probesRemaining {
+		admitted = t.probesRemaining
+	}
+	t.probesRemaining -= admitted
+	return admitted
+}
+
+func (t *tracker) RecordSuccess(logger lager.Logger) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.state != probing {
+		return
+	}
+
+	logger.Info("quarantine-lifted")
+	t.state = closed
+	t.failures = nil
+	cellQuarantined.Send(0)
+}
+
+func (t *tracker) RecordFailure(logger lager.Logger, reason string) {
+	if t.config.FailureThreshold <= 0 {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := t.clock.Now()
+
+	switch t.state {
+	case open:
+		return
+	case probing:
+		logger.Info("quarantine-probe-failed", lager.Data{"reason": reason})
+		t.quarantine(now)
+		return
+	}
+
+	t.countFailure(logger, now, reason)
+}
+
+func (t *tracker) RecordRunFailure(logger lager.Logger, reason string) {
+	if t.config.FailureThreshold <= 0 {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.state != closed {
+		return
+	}
+
+	t.countFailure(logger, t.clock.Now(), reason)
+}
+
+// countFailure must be called with the lock held while the tracker is closed.
+func (t *tracker) countFailure(logger lager.Logger, now time.Time, reason string) {
+	t.failures = append(t.failures, now)
+	cutoff := now.Add(-t.config.FailureWindow)
+	for len(t.failures) > 0 && !t.failures[0].After(cutoff) {
+		t.failures = t.failures[1:]
+	}
+
+	if len(t.failures) >= t.config.FailureThreshold {
+		logger.Error("quarantining-cell", nil, lager.Data{
+			"reason":   reason,
+			"failures": len(t.failures),
+			"window":   t.config.FailureWindow.String(),
+		})
+		t.quarantine(now)
+	}
+}
+
+func (t *tracker) Quarantined() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.state != closed
+}
+
+func (t *tracker) quarantine(now time.Time) {
+	t.state = open
+	t.openedAt = now
+	t.probesRemaining = 0
+	cellQuarantined.Send(1)
+}
@@ -0,0 +1,153 @@
+package quarantine_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/rep/quarantine"
+	fake_metrics_sender "github.com/cloudfoundry/dropsonde/metric_sender/fake"
+	"github.com/cloudfoundry/dropsonde/metrics"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tracker", func() {
+	var (
+		logger            *lagertest.TestLogger
+		fakeClock         *fakeclock.FakeClock
+		fakeMetricsSender *fake_metrics_sender.FakeMetricSender
+		config            quarantine.Config
+		tracker           quarantine.Tracker
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		fakeMetricsSender = fake_metrics_sender.NewFakeMetricSender()
+		metrics.Initialize(fakeMetricsSender, nil)
+
+		config = quarantine.Config{
+			FailureThreshold: 3,
+			FailureWindow:    time.Minute,
+			CoolDown:         5 * time.Minute,
+			Probes:           1,
+		}
+	})
+
+	JustBeforeEach(func() {
+		tracker = quarantine.NewTracker(config, fakeClock)
+	})
+
+	quarantineCell := func() {
+		for i := 0; i < config.FailureThreshold; i++ {
+			tracker.RecordFailure(logger, "boom")
+		}
+		Expect(tracker.Quarantined()).To(BeTrue())
+	}
+
+	It("admits all work while the cell is healthy", func() {
+		Expect(tracker.Quarantined()).To(BeFalse())
+		Expect(tracker.Admit(logger, 5)).To(Equal(5))
+	})
+
+	It("quarantines the cell once the failure threshold is reached", func() {
+		tracker.RecordFailure(logger, "boom")
+		tracker.RecordFailure(logger, "boom")
+		Expect(tracker.Quarantined()).To(BeFalse())
+
+		tracker.RecordFailure(logger, "boom")
+		Expect(tracker.Quarantined()).To(BeTrue())
+		Expect(tracker.Admit(logger, 5)).To(Equal(0))
+		Expect(fakeMetricsSender.GetValue("CellQuarantined").Value).To(BeEquivalentTo(1))
+	})
+
+	It("only counts failures within the window", func() {
+		tracker.RecordFailure(logger, "boom")
+		tracker.RecordFailure(logger, "boom")
+		fakeClock.Increment(time.Minute)
+
+		tracker.RecordFailure(logger, "boom")
+		Expect(tracker.Quarantined()).To(BeFalse())
+	})
+
+	Context("after the cool-down", func() {
+		JustBeforeEach(func() {
+			quarantineCell()
+			fakeClock.Increment(config.CoolDown)
+		})
+
+		It("admits only the probes", func() {
+			Expect(tracker.Admit(logger, 5)).To(Equal(1))
+			Expect(tracker.Admit(logger, 5)).To(Equal(0))
+			Expect(tracker.Quarantined()).To(BeTrue())
+		})
+
+		It("lifts the quarantine when a probe succeeds", func() {
+			Expect(tracker.Admit(logger, 1)).To(Equal(1))
+			tracker.RecordSuccess(logger)
+
+			Expect(tracker.Quarantined()).To(BeFalse())
+			Expect(tracker.Admit(logger, 5)).To(Equal(5))
+			Expect(fakeMetricsSender.GetValue("CellQuarantined").Value).To(BeEquivalentTo(0))
+		})
+
+		It("restarts the cool-down when a probe fails", func() {
+			Expect(tracker.Admit(logger, 1)).To(Equal(1))
+			tracker.RecordFailure(logger, "boom")
+
+			Expect(tracker.Quarantined()).To(BeTrue())
+			Expect(tracker.Admit(logger, 1)).To(Equal(0))
+
+			fakeClock.Increment(config.CoolDown)
+			Expect(tracker.Admit(logger, 1)).To(Equal(1))
+		})
+
+		It("does not end probing on a run failure", func() {
+			Expect(tracker.Admit(logger, 1)).To(Equal(1))
+			tracker.RecordRunFailure(logger, "boom")
+
+			Expect(tracker.Quarantined()).To(BeTrue())
+			tracker.RecordSuccess(logger)
+			Expect(tracker.Quarantined()).To(BeFalse())
+		})
+	})
+
+	It("counts run failures towards the threshold", func() {
+		tracker.RecordRunFailure(logger, "boom")
+		tracker.RecordRunFailure(logger, "boom")
+		Expect(tracker.Quarantined()).To(BeFalse())
+
+		tracker.RecordRunFailure(logger, "boom")
+		Expect(tracker.Quarantined()).To(BeTrue())
+	})
+
+	Context("when the threshold is zero", func() {
+		BeforeEach(func() {
+			config.FailureThreshold = 0
+		})
+
+		It("never quarantines the cell", func() {
+			for i := 0; i < 10; i++ {
+				tracker.RecordFailure(logger, "boom")
+			}
+			Expect(tracker.Quarantined()).To(BeFalse())
+			Expect(tracker.Admit(logger, 5)).To(Equal(5))
+		})
+	})
+
+	Describe("Config.Validate", func() {
+		It("accepts a threshold with at least one probe", func() {
+			Expect(quarantine.Config{FailureThreshold: 3, Probes: 1}.Validate()).To(Succeed())
+		})
+
+		It("rejects a threshold without probes", func() {
+			Expect(quarantine.Config{FailureThreshold: 3}.Validate()).To(MatchError(quarantine.ErrInvalidProbes))
+		})
+
+		It("accepts no probes when quarantine is disabled", func() {
+			Expect(quarantine.Config{}.Validate()).To(Succeed())
+		})
+	})
+})
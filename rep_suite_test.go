@@ -9,8 +9,10 @@ import (
 	executorfakes "code.cloudfoundry.org/executor/fakes"
 	"code.cloudfoundry.org/lager/lagertest"
 	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/crash_archive/fake_crash_archive"
 	"code.cloudfoundry.org/rep/evacuation/evacuation_context/fake_evacuation_context"
 	"code.cloudfoundry.org/rep/handlers"
+	"code.cloudfoundry.org/rep/image_cache/fake_image_cache"
 	"code.cloudfoundry.org/rep/repfakes"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -48,8 +50,9 @@ var _ = BeforeEach(func() {
 	auctionRep = &repfakes.FakeClient{}
 	fakeExecutorClient = &executorfakes.FakeClient{}
 	fakeEvacuatable = &fake_evacuation_context.FakeEvacuatable{}
+	fakeEvacuationStatusReporter := &fake_evacuation_context.FakeEvacuationStatusReporter{}
 
-	handler, err := rata.NewRouter(rep.Routes, handlers.New(auctionRep, fakeExecutorClient, fakeEvacuatable, logger))
+	handler, err := rata.NewRouter(rep.Routes, handlers.New(auctionRep, fakeExecutorClient, fakeEvacuatable, fakeEvacuationStatusReporter, new(fake_image_cache.FakePrepuller), new(fake_crash_archive.FakeArchive), logger))
 	Expect(err).NotTo(HaveOccurred())
 	server = httptest.NewServer(handler)
 
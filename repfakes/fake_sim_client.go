@@ -61,6 +61,46 @@ type FakeSimClient struct {
 	resetReturns     struct {
 		result1 error
 	}
+	ReserveStub        func(request rep.HoldRequest) (rep.Hold, error)
+	reserveMutex       sync.RWMutex
+	reserveArgsForCall []struct {
+		request rep.HoldRequest
+	}
+	reserveReturns struct {
+		result1 rep.Hold
+		result2 error
+	}
+	CommitHoldStub        func(holdID string, work rep.Work) (rep.Work, error)
+	commitHoldMutex       sync.RWMutex
+	commitHoldArgsForCall []struct {
+		holdID string
+		work   rep.Work
+	}
+	commitHoldReturns struct {
+		result1 rep.Work
+		result2 error
+	}
+	ReleaseHoldStub        func(holdID string) error
+	releaseHoldMutex       sync.RWMutex
+	releaseHoldArgsForCall []struct {
+		holdID string
+	}
+	releaseHoldReturns struct {
+		result1 error
+	}
+	EvacuationStatusStub        func() (rep.EvacuationStatus, error)
+	evacuationStatusMutex       sync.RWMutex
+	evacuationStatusArgsForCall []struct{}
+	evacuationStatusReturns     struct {
+		result1 rep.EvacuationStatus
+		result2 error
+	}
+	CancelEvacuationStub        func() error
+	cancelEvacuationMutex       sync.RWMutex
+	cancelEvacuationArgsForCall []struct{}
+	cancelEvacuationReturns     struct {
+		result1 error
+	}
 }
 
 func (fake *FakeSimClient) State() (rep.CellState, error) {
@@ -257,4 +297,152 @@ func (fake *FakeSimClient) ResetReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeSimClient) Reserve(request rep.HoldRequest) (rep.Hold, error) {
+	fake.reserveMutex.Lock()
+	fake.reserveArgsForCall = append(fake.reserveArgsForCall, struct {
+		request rep.HoldRequest
+	}{request})
+	fake.reserveMutex.Unlock()
+	if fake.ReserveStub != nil {
+		return fake.ReserveStub(request)
+	} else {
+		return fake.reserveReturns.result1, fake.reserveReturns.result2
+	}
+}
+
+func (fake *FakeSimClient) ReserveCallCount() int {
+	fake.reserveMutex.RLock()
+	defer fake.reserveMutex.RUnlock()
+	return len(fake.reserveArgsForCall)
+}
+
+func (fake *FakeSimClient) ReserveArgsForCall(i int) rep.HoldRequest {
+	fake.reserveMutex.RLock()
+	defer fake.reserveMutex.RUnlock()
+	return fake.reserveArgsForCall[i].request
+}
+
+func (fake *FakeSimClient) ReserveReturns(result1 rep.Hold, result2 error) {
+	fake.ReserveStub = nil
+	fake.reserveReturns = struct {
+		result1 rep.Hold
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSimClient) CommitHold(holdID string, work rep.Work) (rep.Work, error) {
+	fake.commitHoldMutex.Lock()
+	fake.commitHoldArgsForCall = append(fake.commitHoldArgsForCall, struct {
+		holdID string
+		work   rep.Work
+	}{holdID, work})
+	fake.commitHoldMutex.Unlock()
+	if fake.CommitHoldStub != nil {
+		return fake.CommitHoldStub(holdID, work)
+	} else {
+		return fake.commitHoldReturns.result1, fake.commitHoldReturns.result2
+	}
+}
+
+func (fake *FakeSimClient) CommitHoldCallCount() int {
+	fake.commitHoldMutex.RLock()
+	defer fake.commitHoldMutex.RUnlock()
+	return len(fake.commitHoldArgsForCall)
+}
+
+func (fake *FakeSimClient) CommitHoldArgsForCall(i int) (string, rep.Work) {
+	fake.commitHoldMutex.RLock()
+	defer fake.commitHoldMutex.RUnlock()
+	return fake.commitHoldArgsForCall[i].holdID, fake.commitHoldArgsForCall[i].work
+}
+
+func (fake *FakeSimClient) CommitHoldReturns(result1 rep.Work, result2 error) {
+	fake.CommitHoldStub = nil
+	fake.commitHoldReturns = struct {
+		result1 rep.Work
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSimClient) ReleaseHold(holdID string) error {
+	fake.releaseHoldMutex.Lock()
+	fake.releaseHoldArgsForCall = append(fake.releaseHoldArgsForCall, struct {
+		holdID string
+	}{holdID})
+	fake.releaseHoldMutex.Unlock()
+	if fake.ReleaseHoldStub != nil {
+		return fake.ReleaseHoldStub(holdID)
+	} else {
+		return fake.releaseHoldReturns.result1
+	}
+}
+
+func (fake *FakeSimClient) ReleaseHoldCallCount() int {
+	fake.releaseHoldMutex.RLock()
+	defer fake.releaseHoldMutex.RUnlock()
+	return len(fake.releaseHoldArgsForCall)
+}
+
+func (fake *FakeSimClient) ReleaseHoldArgsForCall(i int) string {
+	fake.releaseHoldMutex.RLock()
+	defer fake.releaseHoldMutex.RUnlock()
+	return fake.releaseHoldArgsForCall[i].holdID
+}
+
+func (fake *FakeSimClient) ReleaseHoldReturns(result1 error) {
+	fake.ReleaseHoldStub = nil
+	fake.releaseHoldReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSimClient) EvacuationStatus() (rep.EvacuationStatus, error) {
+	fake.evacuationStatusMutex.Lock()
+	fake.evacuationStatusArgsForCall = append(fake.evacuationStatusArgsForCall, struct{}{})
+	fake.evacuationStatusMutex.Unlock()
+	if fake.EvacuationStatusStub != nil {
+		return fake.EvacuationStatusStub()
+	} else {
+		return fake.evacuationStatusReturns.result1, fake.evacuationStatusReturns.result2
+	}
+}
+
+func (fake *FakeSimClient) EvacuationStatusCallCount() int {
+	fake.evacuationStatusMutex.RLock()
+	defer fake.evacuationStatusMutex.RUnlock()
+	return len(fake.evacuationStatusArgsForCall)
+}
+
+func (fake *FakeSimClient) EvacuationStatusReturns(result1 rep.EvacuationStatus, result2 error) {
+	fake.EvacuationStatusStub = nil
+	fake.evacuationStatusReturns = struct {
+		result1 rep.EvacuationStatus
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSimClient) CancelEvacuation() error {
+	fake.cancelEvacuationMutex.Lock()
+	fake.cancelEvacuationArgsForCall = append(fake.cancelEvacuationArgsForCall, struct{}{})
+	fake.cancelEvacuationMutex.Unlock()
+	if fake.CancelEvacuationStub != nil {
+		return fake.CancelEvacuationStub()
+	} else {
+		return fake.cancelEvacuationReturns.result1
+	}
+}
+
+func (fake *FakeSimClient) CancelEvacuationCallCount() int {
+	fake.cancelEvacuationMutex.RLock()
+	defer fake.cancelEvacuationMutex.RUnlock()
+	return len(fake.cancelEvacuationArgsForCall)
+}
+
+func (fake *FakeSimClient) CancelEvacuationReturns(result1 error) {
+	fake.CancelEvacuationStub = nil
+	fake.cancelEvacuationReturns = struct {
+		result1 error
+	}{result1}
+}
+
 var _ rep.SimClient = new(FakeSimClient)
@@ -22,6 +22,23 @@ type CellState struct {
 	Zone                   string
 	Evacuating             bool
 	VolumeDrivers          []string
+	ProcessInstanceCounts  map[string]int
+	Holds                  []Hold
+	Health                 CellHealth
+	Quarantined            bool
+
+	// CachedImages are the canonical references of the docker images the cell
+	// holds, as returned by DockerImageRef.
+	CachedImages []string
+
+	// CrashLoopingProcesses are the guids of the processes whose instances
+	// keep crashing on the cell.
+	CrashLoopingProcesses []string
+
+	// RealAvailableResources and RealTotalResources are the resources reported
+	// by the executor, before any overcommit factors are applied.
+	RealAvailableResources Resources
+	RealTotalResources     Resources
 }
 
 func NewCellState(
@@ -45,13 +62,43 @@ func NewCellState(
 		StartingContainerCount: startingContainerCount,
 		Evacuating:             isEvac,
 		VolumeDrivers:          volumeDrivers,
+		ProcessInstanceCounts:  processInstanceCounts(lrps),
 	}
 }
 
+func processInstanceCounts(lrps []LRP) map[string]int {
+	counts := map[string]int{}
+	for i := range lrps {
+		counts[lrps[i].ProcessGuid]++
+	}
+	return counts
+}
+
 func (c *CellState) AddLRP(lrp *LRP) {
 	c.AvailableResources.Subtract(&lrp.Resource)
 	c.StartingContainerCount += 1
 	c.LRPs = append(c.LRPs, *lrp)
+	if c.ProcessInstanceCounts == nil {
+		c.ProcessInstanceCounts = map[string]int{}
+	}
+	c.ProcessInstanceCounts[lrp.ProcessGuid]++
+}
+
+// InstanceCount returns the number of instances of the given process guid
+// that are placed on the cell.
+func (c *CellState) InstanceCount(processGuid string) int {
+	return c.ProcessInstanceCounts[processGuid]
+}
+
+// IsCrashLooping reports whether the instances of the given process guid keep
+// crashing on the cell.
+func (c *CellState) IsCrashLooping(processGuid string) bool {
+	for _, guid := range c.CrashLoopingProcesses {
+		if guid == processGuid {
+			return true
+		}
+	}
+	return false
 }
 
 func (c *CellState) AddTask(task *Task) {
@@ -79,7 +126,11 @@ func (c CellState) ComputeScore(res *Resource, startingContainerWeight float64)
 	remainingResources := c.AvailableResources.Copy()
 	remainingResources.Subtract(res)
 	startingContainerScore := float64(c.StartingContainerCount) * startingContainerWeight
-	return remainingResources.ComputeScore(&c.TotalResources) + startingContainerScore
+	score := remainingResources.ComputeScore(&c.TotalResources) + startingContainerScore
+	if c.HasCachedImage(res.RootFs) {
+		score -= CachedImageBonus
+	}
+	return score
 }
 
 func (c *CellState) MatchRootFS(rootfs string) bool {
@@ -130,6 +181,12 @@ func (r *Resources) Subtract(res *Resource) {
 	r.Containers -= 1
 }
 
+func (r *Resources) SubtractHold(hold *Hold) {
+	r.MemoryMB -= hold.Resources.MemoryMB
+	r.DiskMB -= hold.Resources.DiskMB
+	r.Containers -= hold.Resources.Containers
+}
+
 func (r *Resources) ComputeScore(total *Resources) float64 {
 	fractionUsedMemory := 1.0 - float64(r.MemoryMB)/float64(total.MemoryMB)
 	fractionUsedDisk := 1.0 - float64(r.DiskMB)/float64(total.DiskMB)
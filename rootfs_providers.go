@@ -2,7 +2,12 @@ package rep
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/url"
+	"path"
+	"regexp"
+	"strings"
 )
 
 type RootFSProvider interface {
@@ -15,8 +20,17 @@ type RootFSProviderType string
 const (
 	RootFSProviderTypeArbitrary RootFSProviderType = "arbitrary"
 	RootFSProviderTypeFixedSet  RootFSProviderType = "fixed_set"
+	RootFSProviderTypeGlob      RootFSProviderType = "glob"
+	RootFSProviderTypeRegex     RootFSProviderType = "regex"
+	RootFSProviderTypeRegistry  RootFSProviderType = "registry"
 )
 
+// DefaultDockerRegistry is the registry of docker rootfs URLs without a host,
+// such as docker:///cloudfoundry/cflinuxfs2.
+const DefaultDockerRegistry = "docker.io"
+
+var ErrInvalidRootFSProviderSpec = errors.New("invalid rootfs provider: not of the form 'scheme' or 'scheme=type:value'")
+
 type RootFSProviders map[string]RootFSProvider
 
 func (p RootFSProviders) Copy() RootFSProviders {
@@ -74,10 +88,118 @@ func unmarshalRootFSProvider(payload []byte) (RootFSProvider, error) {
 		var provider FixedSetRootFSProvider
 		err := provider.UnmarshalJSON(payload)
 		return provider, err
+	case RootFSProviderTypeGlob:
+		var provider GlobRootFSProvider
+		err := json.Unmarshal(payload, &provider)
+		return provider, err
+	case RootFSProviderTypeRegex:
+		var provider RegexRootFSProvider
+		err := provider.UnmarshalJSON(payload)
+		return provider, err
+	case RootFSProviderTypeRegistry:
+		var provider RegistryRootFSProvider
+		err := json.Unmarshal(payload, &provider)
+		return provider, err
+	}
+
+	return nil, fmt.Errorf("unknown rootfs provider type %q", envelope.Type)
+}
+
+// ParseRootFSProviderSpecs builds providers from specifications of the form
+// 'scheme', which accepts any rootfs with that scheme, or 'scheme=type:value'
+// where type is glob, regex or registry. Specifications of the same scheme and
+// type are combined, so that a rootfs matching any of them is accepted.
+func ParseRootFSProviderSpecs(specs []string) (RootFSProviders, error) {
+	providers := RootFSProviders{}
+
+	for _, spec := range specs {
+		scheme, provider, err := ParseRootFSProviderSpec(spec)
+		if err != nil {
+			return nil, err
+		}
+
+		existing, ok := providers[scheme]
+		if !ok {
+			providers[scheme] = provider
+			continue
+		}
+
+		merged, err := mergeRootFSProviders(existing, provider)
+		if err != nil {
+			return nil, fmt.Errorf("rootfs provider %q: %s", scheme, err)
+		}
+		providers[scheme] = merged
+	}
+
+	return providers, nil
+}
+
+// ParseRootFSProviderSpec parses a single provider specification. See
+// ParseRootFSProviderSpecs.
+func ParseRootFSProviderSpec(spec string) (string, RootFSProvider, error) {
+	parts := strings.SplitN(spec, "=", 2)
+	scheme := parts[0]
+	if scheme == "" {
+		return "", nil, ErrInvalidRootFSProviderSpec
+	}
+
+	if len(parts) == 1 {
+		return scheme, ArbitraryRootFSProvider{}, nil
+	}
+
+	matcher := strings.SplitN(parts[1], ":", 2)
+	if len(matcher) != 2 || matcher[1] == "" {
+		return "", nil, ErrInvalidRootFSProviderSpec
 	}
 
-	return nil, nil
+	switch RootFSProviderType(matcher[0]) {
+	case RootFSProviderTypeGlob:
+		provider, err := NewGlobRootFSProvider(matcher[1])
+		return scheme, provider, err
+	case RootFSProviderTypeRegex:
+		provider, err := NewRegexRootFSProvider(matcher[1])
+		return scheme, provider, err
+	case RootFSProviderTypeRegistry:
+		return scheme, NewRegistryRootFSProvider(matcher[1]), nil
+	}
 
+	return "", nil, fmt.Errorf("unknown rootfs provider type %q", matcher[0])
+}
+
+func mergeRootFSProviders(a, b RootFSProvider) (RootFSProvider, error) {
+	if a.Type() != b.Type() {
+		return nil, fmt.Errorf("cannot combine %s and %s matchers", a.Type(), b.Type())
+	}
+
+	switch provider := a.(type) {
+	case ArbitraryRootFSProvider:
+		return provider, nil
+	case GlobRootFSProvider:
+		return NewGlobRootFSProvider(append(append([]string{}, provider.Patterns...), b.(GlobRootFSProvider).Patterns...)...)
+	case RegexRootFSProvider:
+		return NewRegexRootFSProvider(append(append([]string{}, provider.Patterns...), b.(RegexRootFSProvider).Patterns...)...)
+	case RegistryRootFSProvider:
+		registries := []string{}
+		for registry := range provider.Registries {
+			registries = append(registries, registry)
+		}
+		for registry := range b.(RegistryRootFSProvider).Registries {
+			registries = append(registries, registry)
+		}
+		return NewRegistryRootFSProvider(registries...), nil
+	}
+
+	return nil, fmt.Errorf("cannot combine %s matchers", a.Type())
+}
+
+// matchTarget is the part of a rootfs URL that glob and regex providers match:
+// the host followed by the path, or the opaque part of URLs such as
+// preloaded:cflinuxfs2.
+func matchTarget(rootfs url.URL) string {
+	if rootfs.Opaque != "" {
+		return rootfs.Opaque
+	}
+	return rootfs.Host + rootfs.Path
 }
 
 type ArbitraryRootFSProvider struct{}
@@ -90,8 +212,12 @@ func (provider ArbitraryRootFSProvider) MarshalJSON() ([]byte, error) {
 	return json.Marshal(map[string]string{"type": string(provider.Type())})
 }
 
+// FixedSetRootFSProvider matches rootfses whose opaque part is in its set,
+// such as preloaded:cflinuxfs2. When a rootfs asks for a version, as in
+// preloaded:cflinuxfs2?version=1.2, that version must be in Versions.
 type FixedSetRootFSProvider struct {
 	FixedSet StringSet
+	Versions map[string]StringSet
 }
 
 func NewFixedSetRootFSProvider(rootfses ...string) FixedSetRootFSProvider {
@@ -103,7 +229,16 @@ func NewFixedSetRootFSProvider(rootfses ...string) FixedSetRootFSProvider {
 func (FixedSetRootFSProvider) Type() RootFSProviderType { return RootFSProviderTypeFixedSet }
 
 func (provider FixedSetRootFSProvider) Match(rootfs url.URL) bool {
-	return provider.FixedSet.Contains(rootfs.Opaque)
+	if !provider.FixedSet.Contains(rootfs.Opaque) {
+		return false
+	}
+
+	version := rootfs.Query().Get(StackVersionParam)
+	if version == "" {
+		return true
+	}
+
+	return provider.Versions[rootfs.Opaque].Contains(version)
 }
 
 func (provider FixedSetRootFSProvider) MarshalJSON() ([]byte, error) {
@@ -120,15 +255,27 @@ func (provider FixedSetRootFSProvider) MarshalJSON() ([]byte, error) {
 	setValue := json.RawMessage(setPayload)
 	typeValue := json.RawMessage(typePayload)
 
-	return json.Marshal(map[string]*json.RawMessage{
+	fields := map[string]*json.RawMessage{
 		"type": &typeValue,
 		"set":  &setValue,
-	})
+	}
+
+	if len(provider.Versions) > 0 {
+		versionsPayload, err := json.Marshal(provider.Versions)
+		if err != nil {
+			return nil, err
+		}
+		versionsValue := json.RawMessage(versionsPayload)
+		fields["versions"] = &versionsValue
+	}
+
+	return json.Marshal(fields)
 }
 
 func (provider *FixedSetRootFSProvider) UnmarshalJSON(payload []byte) error {
 	type fixed struct {
-		Set StringSet `json"set"`
+		Set      StringSet            `json"set"`
+		Versions map[string]StringSet `json:"versions"`
 	}
 
 	var f fixed
@@ -139,11 +286,131 @@ func (provider *FixedSetRootFSProvider) UnmarshalJSON(payload []byte) error {
 	}
 
 	provider.FixedSet = f.Set
+	provider.Versions = f.Versions
 
 	return nil
 
 }
 
+// GlobRootFSProvider matches rootfses whose host and path match any of its
+// patterns, using the syntax of path.Match.
+type GlobRootFSProvider struct {
+	Patterns []string `json:"patterns"`
+}
+
+func NewGlobRootFSProvider(patterns ...string) (GlobRootFSProvider, error) {
+	for _, pattern := range patterns {
+		_, err := path.Match(pattern, "")
+		if err != nil {
+			return GlobRootFSProvider{}, fmt.Errorf("invalid glob %q: %s", pattern, err)
+		}
+	}
+	return GlobRootFSProvider{Patterns: patterns}, nil
+}
+
+func (GlobRootFSProvider) Type() RootFSProviderType { return RootFSProviderTypeGlob }
+
+func (provider GlobRootFSProvider) Match(rootfs url.URL) bool {
+	target := matchTarget(rootfs)
+	for _, pattern := range provider.Patterns {
+		if matched, _ := path.Match(pattern, target); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (provider GlobRootFSProvider) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"type":     provider.Type(),
+		"patterns": provider.Patterns,
+	})
+}
+
+// RegexRootFSProvider matches rootfses whose host and path match the whole of
+// any of its regular expressions.
+type RegexRootFSProvider struct {
+	Patterns []string
+
+	regexps []*regexp.Regexp
+}
+
+func NewRegexRootFSProvider(patterns ...string) (RegexRootFSProvider, error) {
+	regexps := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return RegexRootFSProvider{}, fmt.Errorf("invalid regex %q: %s", pattern, err)
+		}
+		regexps = append(regexps, re)
+	}
+	return RegexRootFSProvider{Patterns: patterns, regexps: regexps}, nil
+}
+
+func (RegexRootFSProvider) Type() RootFSProviderType { return RootFSProviderTypeRegex }
+
+func (provider RegexRootFSProvider) Match(rootfs url.URL) bool {
+	target := matchTarget(rootfs)
+	for _, re := range provider.regexps {
+		if re.MatchString(target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (provider RegexRootFSProvider) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"type":     provider.Type(),
+		"patterns": provider.Patterns,
+	})
+}
+
+func (provider *RegexRootFSProvider) UnmarshalJSON(payload []byte) error {
+	var r struct {
+		Patterns []string `json:"patterns"`
+	}
+
+	err := json.Unmarshal(payload, &r)
+	if err != nil {
+		return err
+	}
+
+	*provider, err = NewRegexRootFSProvider(r.Patterns...)
+	return err
+}
+
+// RegistryRootFSProvider matches docker rootfses hosted by any of its
+// registries. Rootfses without a host are hosted by DefaultDockerRegistry.
+type RegistryRootFSProvider struct {
+	Registries StringSet `json:"registries"`
+}
+
+func NewRegistryRootFSProvider(registries ...string) RegistryRootFSProvider {
+	return RegistryRootFSProvider{Registries: NewStringSet(registries...)}
+}
+
+func (RegistryRootFSProvider) Type() RootFSProviderType { return RootFSProviderTypeRegistry }
+
+func (provider RegistryRootFSProvider) Match(rootfs url.URL) bool {
+	if rootfs.Scheme != DockerScheme {
+		return false
+	}
+
+	registry := rootfs.Host
+	if registry == "" {
+		registry = DefaultDockerRegistry
+	}
+	return provider.Registries.Contains(registry)
+}
+
+func (provider RegistryRootFSProvider) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"type":       provider.Type(),
+		"registries": provider.Registries,
+	})
+}
+
 type StringSet map[string]struct{}
 
 func NewStringSet(entries ...string) StringSet {
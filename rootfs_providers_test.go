@@ -53,6 +53,71 @@ var _ = Describe("RootFSProviders", func() {
 		Expect(providersResult).To(Equal(providers))
 	})
 
+	It("round-trips pattern and registry providers", func() {
+		glob, err := rep.NewGlobRootFSProvider("registry.example.com/*")
+		Expect(err).NotTo(HaveOccurred())
+		regex, err := rep.NewRegexRootFSProvider("/cloudfoundry/.+")
+		Expect(err).NotTo(HaveOccurred())
+
+		original := rep.RootFSProviders{
+			"glob":      glob,
+			"regex":     regex,
+			"docker":    rep.NewRegistryRootFSProvider("docker.io"),
+			"preloaded": rep.StackPathMap{"linux@1.0": "/rootfs/linux-1.0"}.RootFSProvider(),
+		}
+
+		payload, err := json.Marshal(original)
+		Expect(err).NotTo(HaveOccurred())
+
+		var providersResult rep.RootFSProviders
+		err = json.Unmarshal(payload, &providersResult)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(providersResult).To(Equal(original))
+	})
+
+	It("fails to deserialize unknown provider types", func() {
+		var providersResult rep.RootFSProviders
+		err := json.Unmarshal([]byte(`{"foo": {"type": "telepathy"}}`), &providersResult)
+		Expect(err).To(MatchError(`unknown rootfs provider type "telepathy"`))
+	})
+
+	Describe("ParseRootFSProviderSpecs", func() {
+		It("parses bare schemes as arbitrary providers", func() {
+			parsed, err := rep.ParseRootFSProviderSpecs([]string{"docker"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parsed).To(Equal(rep.RootFSProviders{"docker": rep.ArbitraryRootFSProvider{}}))
+		})
+
+		It("combines matchers of the same scheme and type", func() {
+			parsed, err := rep.ParseRootFSProviderSpecs([]string{
+				"docker=registry:docker.io",
+				"docker=registry:my.registry:5000",
+				"http=glob:example.com/*.tgz",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			glob, err := rep.NewGlobRootFSProvider("example.com/*.tgz")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parsed).To(Equal(rep.RootFSProviders{
+				"docker": rep.NewRegistryRootFSProvider("docker.io", "my.registry:5000"),
+				"http":   glob,
+			}))
+		})
+
+		It("rejects matchers of different types for the same scheme", func() {
+			_, err := rep.ParseRootFSProviderSpecs([]string{"docker", "docker=registry:docker.io"})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("rejects malformed specifications", func() {
+			for _, spec := range []string{"", "=glob:*", "docker=registry", "docker=telepathy:x", "http=regex:(", "http=glob:["} {
+				_, err := rep.ParseRootFSProviderSpecs([]string{spec})
+				Expect(err).To(HaveOccurred(), spec)
+			}
+		})
+	})
+
 	Describe("Match", func() {
 		Describe("ArbitraryRootFSProvider", func() {
 			It("matches any URL", func() {
@@ -79,6 +144,66 @@ var _ = Describe("RootFSProviders", func() {
 			})
 		})
 
+		Describe("GlobRootFSProvider", func() {
+			It("matches the host and path", func() {
+				glob, err := rep.NewGlobRootFSProvider("example.com/rootfses/*")
+				Expect(err).NotTo(HaveOccurred())
+
+				rootFS, err := url.Parse("http://example.com/rootfses/linux.tgz")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(glob.Match(*rootFS)).To(BeTrue())
+
+				rootFS, err = url.Parse("http://example.com/other/linux.tgz")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(glob.Match(*rootFS)).To(BeFalse())
+			})
+		})
+
+		Describe("RegexRootFSProvider", func() {
+			It("matches the whole of the host and path", func() {
+				regex, err := rep.NewRegexRootFSProvider(`/cloudfoundry/cflinuxfs\d`)
+				Expect(err).NotTo(HaveOccurred())
+
+				rootFS, err := url.Parse("docker:///cloudfoundry/cflinuxfs2")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(regex.Match(*rootFS)).To(BeTrue())
+
+				rootFS, err = url.Parse("docker:///cloudfoundry/cflinuxfs2-evil")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(regex.Match(*rootFS)).To(BeFalse())
+			})
+		})
+
+		Describe("RegistryRootFSProvider", func() {
+			var registry rep.RegistryRootFSProvider
+
+			BeforeEach(func() {
+				registry = rep.NewRegistryRootFSProvider(rep.DefaultDockerRegistry, "my.registry:5000")
+			})
+
+			It("matches docker URLs hosted by an allowed registry", func() {
+				rootFS, err := url.Parse("docker://my.registry:5000/app")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(registry.Match(*rootFS)).To(BeTrue())
+			})
+
+			It("treats docker URLs without a host as hosted by the default registry", func() {
+				rootFS, err := url.Parse("docker:///cloudfoundry/cflinuxfs2")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(registry.Match(*rootFS)).To(BeTrue())
+			})
+
+			It("does not match other registries or schemes", func() {
+				rootFS, err := url.Parse("docker://evil.registry/app")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(registry.Match(*rootFS)).To(BeFalse())
+
+				rootFS, err = url.Parse("http://my.registry:5000/app")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(registry.Match(*rootFS)).To(BeFalse())
+			})
+		})
+
 		Describe("RootFSProviders", func() {
 			Context("for a scheme with an arbitrary provider", func() {
 				It("matches any url", func() {
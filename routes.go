@@ -9,10 +9,19 @@ const (
 	StopLRPInstanceRoute = "StopLRPInstance"
 	CancelTaskRoute      = "CancelTask"
 
+	ReserveRoute     = "Reserve"
+	CommitHoldRoute  = "CommitHold"
+	ReleaseHoldRoute = "ReleaseHold"
+
 	Sim_ResetRoute = "RESET"
 
-	PingRoute     = "Ping"
-	EvacuateRoute = "Evacuate"
+	PingRoute             = "Ping"
+	EvacuateRoute         = "Evacuate"
+	EvacuationStatusRoute = "EvacuationStatus"
+	CancelEvacuationRoute = "CancelEvacuation"
+	PrepullImagesRoute    = "PrepullImages"
+	CrashesRoute          = "Crashes"
+	CrashFileRoute        = "CrashFile"
 )
 
 var Routes = rata.Routes{
@@ -22,9 +31,22 @@ var Routes = rata.Routes{
 	{Path: "/v1/lrps/:process_guid/instances/:instance_guid/stop", Method: "POST", Name: StopLRPInstanceRoute},
 	{Path: "/v1/tasks/:task_guid/cancel", Method: "POST", Name: CancelTaskRoute},
 
+	{Path: "/v1/holds", Method: "POST", Name: ReserveRoute},
+	{Path: "/v1/holds/:hold_id/commit", Method: "POST", Name: CommitHoldRoute},
+	{Path: "/v1/holds/:hold_id", Method: "DELETE", Name: ReleaseHoldRoute},
+
 	{Path: "/sim/reset", Method: "POST", Name: Sim_ResetRoute},
 
 	// These routes are called by the rep ctl and drain scripts
 	{Path: "/ping", Method: "GET", Name: PingRoute},
 	{Path: "/evacuate", Method: "POST", Name: EvacuateRoute},
+	{Path: "/evacuate", Method: "GET", Name: EvacuationStatusRoute},
+	{Path: "/evacuate", Method: "DELETE", Name: CancelEvacuationRoute},
+
+	// Called by operators ahead of large deployments
+	{Path: "/v1/images/prepull", Method: "POST", Name: PrepullImagesRoute},
+
+	// Called by operators investigating crashed LRP instances
+	{Path: "/v1/crashes/:process_guid/:index", Method: "GET", Name: CrashesRoute},
+	{Path: "/v1/crashes/:process_guid/:index/:crash_id/:file", Method: "GET", Name: CrashFileRoute},
 }
@@ -0,0 +1,375 @@
+// simulation provides an in-memory cell for load testing auction algorithms
+// without an executor, Garden or the BBS.
+package simulation
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/executor"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/evacuation/evacuation_context"
+)
+
+type simulatedLRP struct {
+	rep.LRP
+	instanceGuid string
+}
+
+// Cell implements rep.SimClient entirely in memory. Work that fits the
+// remaining resources is placed immediately and stays until it is stopped,
+// cancelled or the cell is reset.
+type Cell struct {
+	cellID               string
+	zone                 string
+	totalResources       rep.Resources
+	rootFSProviders      rep.RootFSProviders
+	evacuatable          evacuation_context.Evacuatable
+	evacuationReporter   evacuation_context.EvacuationReporter
+	generateInstanceGuid func() (string, error)
+	clock                clock.Clock
+	logger               lager.Logger
+
+	lock  sync.Mutex
+	lrps  []simulatedLRP
+	tasks []rep.Task
+	holds map[string]rep.Hold
+}
+
+func NewCell(
+	cellID string,
+	zone string,
+	totalResources rep.Resources,
+	rootFSProviders rep.RootFSProviders,
+	evacuatable evacuation_context.Evacuatable,
+	evacuationReporter evacuation_context.EvacuationReporter,
+	generateInstanceGuid func() (string, error),
+	clock clock.Clock,
+	logger lager.Logger,
+) *Cell {
+	return &Cell{
+		cellID:               cellID,
+		zone:                 zone,
+		totalResources:       totalResources,
+		rootFSProviders:      rootFSProviders,
+		evacuatable:          evacuatable,
+		evacuationReporter:   evacuationReporter,
+		generateInstanceGuid: generateInstanceGuid,
+		clock:                clock,
+		logger:               logger.Session("simulated-cell", lager.Data{"cell-id": cellID}),
+		holds:                map[string]rep.Hold{},
+	}
+}
+
+func (c *Cell) State() (rep.CellState, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.pruneExpiredHolds()
+
+	lrps := make([]rep.LRP, 0, len(c.lrps))
+	for i := range c.lrps {
+		lrps = append(lrps, c.lrps[i].LRP)
+	}
+	tasks := make([]rep.Task, len(c.tasks))
+	copy(tasks, c.tasks)
+
+	holds := make([]rep.Hold, 0, len(c.holds))
+	for _, hold := range c.holds {
+		holds = append(holds, hold)
+	}
+
+	available := c.unheldResources()
+	evacuating := c.evacuationReporter.Evacuating()
+
+	state := rep.NewCellState(
+		c.rootFSProviders.Copy(),
+		available,
+		c.totalResources,
+		lrps,
+		tasks,
+		c.zone,
+		0,
+		evacuating,
+		nil,
+	)
+	state.Holds = holds
+	state.RealAvailableResources = available
+	state.RealTotalResources = c.totalResources
+
+	now := c.clock.Now()
+	if evacuating {
+		state.Health = rep.NewCellHealth(rep.CellDraining, nil, now, now)
+	} else {
+		state.Health = rep.NewCellHealth(rep.CellHealthy, nil, now, now)
+	}
+
+	return state, nil
+}
+
+func (c *Cell) Perform(work rep.Work) (rep.Work, error) {
+	logger := c.logger.Session("perform", lager.Data{
+		"lrp-starts": len(work.LRPs),
+		"tasks":      len(work.Tasks),
+	})
+
+	if c.evacuationReporter.Evacuating() {
+		logger.Info("cell-is-evacuating")
+		return work, nil
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.pruneExpiredHolds()
+	available := c.unheldResources()
+
+	return c.place(logger, &available, work), nil
+}
+
+func (c *Cell) Reserve(request rep.HoldRequest) (rep.Hold, error) {
+	err := request.Validate()
+	if err != nil {
+		return rep.Hold{}, err
+	}
+
+	if c.evacuationReporter.Evacuating() {
+		return rep.Hold{}, rep.ErrorInsufficientResources
+	}
+
+	holdID, err := c.generateInstanceGuid()
+	if err != nil {
+		return rep.Hold{}, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.pruneExpiredHolds()
+	available := c.unheldResources()
+	if request.Resources.MemoryMB > available.MemoryMB ||
+		request.Resources.DiskMB > available.DiskMB ||
+		request.Resources.Containers > available.Containers {
+		return rep.Hold{}, rep.ErrorInsufficientResources
+	}
+
+	hold := rep.NewHold(holdID, request.Resources, c.clock.Now().Add(request.TTL))
+	c.holds[holdID] = hold
+	return hold, nil
+}
+
+func (c *Cell) CommitHold(holdID string, work rep.Work) (rep.Work, error) {
+	logger := c.logger.Session("commit-hold", lager.Data{"hold-id": holdID})
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.pruneExpiredHolds()
+	hold, ok := c.holds[holdID]
+	if !ok {
+		return rep.Work{}, rep.ErrorHoldNotFound
+	}
+
+	if !hold.Covers(&work) {
+		return rep.Work{}, rep.ErrorWorkExceedsHold
+	}
+
+	delete(c.holds, holdID)
+	available := c.unheldResources()
+
+	return c.place(logger, &available, work), nil
+}
+
+func (c *Cell) ReleaseHold(holdID string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.pruneExpiredHolds()
+	if _, ok := c.holds[holdID]; !ok {
+		return rep.ErrorHoldNotFound
+	}
+
+	delete(c.holds, holdID)
+	return nil
+}
+
+// StopLRPInstance removes the LRP with the given instance guid, or with the
+// given process guid and index when the instance guid is not known.
+func (c *Cell) StopLRPInstance(key models.ActualLRPKey, instanceKey models.ActualLRPInstanceKey) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for i := range c.lrps {
+		lrp := &c.lrps[i]
+		if lrp.ProcessGuid != key.ProcessGuid {
+			continue
+		}
+
+		if lrp.instanceGuid == instanceKey.InstanceGuid || (instanceKey.InstanceGuid == "" && lrp.Index == key.Index) {
+			c.lrps = append(c.lrps[:i], c.lrps[i+1:]...)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (c *Cell) CancelTask(taskGuid string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for i := range c.tasks {
+		if c.tasks[i].TaskGuid == taskGuid {
+			c.tasks = append(c.tasks[:i], c.tasks[i+1:]...)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// EvacuationStatus counts every simulated container as running. Simulated
+// containers stay until they are stopped, cancelled or the cell is reset, so an
+// evacuation completes once the auctioneer has moved all of the work away.
+func (c *Cell) EvacuationStatus() (rep.EvacuationStatus, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	evacuating := c.evacuationReporter.Evacuating()
+	remaining := map[string]map[string]int{}
+	if len(c.lrps) > 0 {
+		remaining[rep.LRPLifecycle] = map[string]int{string(executor.StateRunning): len(c.lrps)}
+	}
+	if len(c.tasks) > 0 {
+		remaining[rep.TaskLifecycle] = map[string]int{string(executor.StateRunning): len(c.tasks)}
+	}
+
+	return rep.EvacuationStatus{
+		Evacuating:          evacuating,
+		Complete:            evacuating && len(remaining) == 0,
+		RemainingContainers: remaining,
+		FailedContainers:    []rep.EvacuationFailure{},
+	}, nil
+}
+
+func (c *Cell) CancelEvacuation() error {
+	if !c.evacuatable.CancelEvacuation() {
+		return rep.ErrNoEvacuationInProgress
+	}
+	return nil
+}
+
+func (c *Cell) SetStateClient(stateClient *http.Client) {}
+
+func (c *Cell) StateClientTimeout() time.Duration {
+	return 0
+}
+
+// Reset removes all LRPs, tasks and holds from the cell.
+func (c *Cell) Reset() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.lrps = nil
+	c.tasks = nil
+	c.holds = map[string]rep.Hold{}
+
+	c.logger.Info("reset")
+	return nil
+}
+
+// EvacuationStatusReporter adapts the cell to the evacuation status route.
+func (c *Cell) EvacuationStatusReporter() evacuation_context.EvacuationStatusReporter {
+	return evacuationStatusReporter{cell: c}
+}
+
+type evacuationStatusReporter struct {
+	cell *Cell
+}
+
+func (r evacuationStatusReporter) EvacuationStatus() rep.EvacuationStatus {
+	status, _ := r.cell.EvacuationStatus()
+	return status
+}
+
+// place must be called with the lock held.
+func (c *Cell) place(logger lager.Logger, available *rep.Resources, work rep.Work) rep.Work {
+	var failedWork rep.Work
+
+	for i := range work.LRPs {
+		lrp := &work.LRPs[i]
+		if !c.fits(available, &lrp.Resource) {
+			failedWork.LRPs = append(failedWork.LRPs, *lrp)
+			continue
+		}
+
+		instanceGuid, err := c.generateInstanceGuid()
+		if err != nil {
+			logger.Error("failed-to-generate-instance-guid", err)
+			failedWork.LRPs = append(failedWork.LRPs, *lrp)
+			continue
+		}
+
+		available.Subtract(&lrp.Resource)
+		c.lrps = append(c.lrps, simulatedLRP{LRP: *lrp, instanceGuid: instanceGuid})
+	}
+
+	for i := range work.Tasks {
+		task := &work.Tasks[i]
+		if !c.fits(available, &task.Resource) {
+			failedWork.Tasks = append(failedWork.Tasks, *task)
+			continue
+		}
+
+		available.Subtract(&task.Resource)
+		c.tasks = append(c.tasks, *task)
+	}
+
+	logger.Info("performed", lager.Data{
+		"failed-lrp-starts": len(failedWork.LRPs),
+		"failed-tasks":      len(failedWork.Tasks),
+	})
+
+	return failedWork
+}
+
+func (c *Cell) fits(available *rep.Resources, res *rep.Resource) bool {
+	rootFSURL, err := url.Parse(res.RootFs)
+	if err != nil || !c.rootFSProviders.Match(*rootFSURL) {
+		return false
+	}
+
+	return available.MemoryMB >= res.MemoryMB &&
+		available.DiskMB >= res.DiskMB &&
+		available.Containers >= 1
+}
+
+// unheldResources must be called with the lock held.
+func (c *Cell) unheldResources() rep.Resources {
+	available := c.totalResources.Copy()
+	for i := range c.lrps {
+		available.Subtract(&c.lrps[i].Resource)
+	}
+	for i := range c.tasks {
+		available.Subtract(&c.tasks[i].Resource)
+	}
+	for _, hold := range c.holds {
+		available.SubtractHold(&hold)
+	}
+	return available
+}
+
+// pruneExpiredHolds must be called with the lock held.
+func (c *Cell) pruneExpiredHolds() {
+	now := c.clock.Now()
+	for id, hold := range c.holds {
+		if hold.Expired(now) {
+			delete(c.holds, id)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package simulation_test
+
+import (
+	"fmt"
+	"time"
+
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/evacuation/evacuation_context"
+	"code.cloudfoundry.org/rep/simulation"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cell", func() {
+	const linuxRootFS = "preloaded:cflinuxfs2"
+
+	var (
+		fakeClock   *fakeclock.FakeClock
+		evacuatable evacuation_context.Evacuatable
+		cell        *simulation.Cell
+		guidCount   int
+	)
+
+	lrp := func(processGuid string, index int32, memoryMB int32) rep.LRP {
+		return rep.NewLRP(
+			models.NewActualLRPKey(processGuid, index, "domain"),
+			rep.NewResource(memoryMB, 10, linuxRootFS, nil),
+		)
+	}
+
+	task := func(taskGuid string, memoryMB int32) rep.Task {
+		return rep.NewTask(taskGuid, "domain", rep.NewResource(memoryMB, 10, linuxRootFS, nil))
+	}
+
+	BeforeEach(func() {
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		guidCount = 0
+
+		var evacuationReporter evacuation_context.EvacuationReporter
+		evacuatable, evacuationReporter, _ = evacuation_context.New()
+
+		providers := rep.RootFSProviders{"preloaded": rep.NewFixedSetRootFSProvider("cflinuxfs2")}
+		cell = simulation.NewCell(
+			"sim-cell",
+			"z1",
+			rep.NewResources(1024, 1024, 4),
+			providers,
+			evacuatable,
+			evacuationReporter,
+			func() (string, error) {
+				guidCount++
+				return fmt.Sprintf("guid-%d", guidCount), nil
+			},
+			fakeClock,
+			lagertest.NewTestLogger("test"),
+		)
+	})
+
+	It("starts empty with all of its resources available", func() {
+		state, err := cell.State()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(state.Zone).To(Equal("z1"))
+		Expect(state.AvailableResources).To(Equal(rep.NewResources(1024, 1024, 4)))
+		Expect(state.TotalResources).To(Equal(rep.NewResources(1024, 1024, 4)))
+		Expect(state.LRPs).To(BeEmpty())
+		Expect(state.Tasks).To(BeEmpty())
+		Expect(state.Health.State).To(Equal(rep.CellHealthy))
+	})
+
+	Describe("Perform", func() {
+		It("places the work that fits and lists it in the state", func() {
+			failed, err := cell.Perform(rep.Work{
+				LRPs:  []rep.LRP{lrp("pg-1", 0, 256), lrp("pg-1", 1, 2048)},
+				Tasks: []rep.Task{task("tg-1", 512)},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(failed.LRPs).To(Equal([]rep.LRP{lrp("pg-1", 1, 2048)}))
+			Expect(failed.Tasks).To(BeEmpty())
+
+			state, err := cell.State()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.LRPs).To(Equal([]rep.LRP{lrp("pg-1", 0, 256)}))
+			Expect(state.Tasks).To(Equal([]rep.Task{task("tg-1", 512)}))
+			Expect(state.AvailableResources).To(Equal(rep.NewResources(256, 1004, 2)))
+			Expect(state.InstanceCount("pg-1")).To(Equal(1))
+		})
+
+		It("rejects work with an unsupported rootfs", func() {
+			work := rep.Work{Tasks: []rep.Task{rep.NewTask("tg-1", "domain", rep.NewResource(10, 10, "docker:///busybox", nil))}}
+			failed, err := cell.Perform(work)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(failed).To(Equal(work))
+		})
+
+		It("rejects all work while the cell is evacuating", func() {
+			evacuatable.Evacuate()
+
+			work := rep.Work{Tasks: []rep.Task{task("tg-1", 10)}}
+			failed, err := cell.Perform(work)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(failed).To(Equal(work))
+
+			state, err := cell.State()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.Evacuating).To(BeTrue())
+			Expect(state.Health.State).To(Equal(rep.CellDraining))
+		})
+	})
+
+	Describe("holds", func() {
+		It("sets resources aside until the hold is committed", func() {
+			hold, err := cell.Reserve(rep.NewHoldRequest(512, 20, 1, time.Minute))
+			Expect(err).NotTo(HaveOccurred())
+
+			state, err := cell.State()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.AvailableResources).To(Equal(rep.NewResources(512, 1004, 3)))
+
+			failed, err := cell.CommitHold(hold.ID, rep.Work{Tasks: []rep.Task{task("tg-1", 512)}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(failed.Tasks).To(BeEmpty())
+
+			state, err = cell.State()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.Holds).To(BeEmpty())
+			Expect(state.Tasks).To(HaveLen(1))
+		})
+
+		It("releases expired holds", func() {
+			hold, err := cell.Reserve(rep.NewHoldRequest(512, 20, 1, time.Minute))
+			Expect(err).NotTo(HaveOccurred())
+
+			fakeClock.Increment(time.Minute)
+			Expect(cell.ReleaseHold(hold.ID)).To(Equal(rep.ErrorHoldNotFound))
+		})
+
+		It("refuses holds that exceed the available resources", func() {
+			_, err := cell.Reserve(rep.NewHoldRequest(2048, 20, 1, time.Minute))
+			Expect(err).To(Equal(rep.ErrorInsufficientResources))
+		})
+	})
+
+	Describe("removing work", func() {
+		BeforeEach(func() {
+			_, err := cell.Perform(rep.Work{
+				LRPs:  []rep.LRP{lrp("pg-1", 0, 256), lrp("pg-1", 1, 256)},
+				Tasks: []rep.Task{task("tg-1", 256)},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("stops LRP instances by instance guid", func() {
+			err := cell.StopLRPInstance(models.ActualLRPKey{ProcessGuid: "pg-1"}, models.ActualLRPInstanceKey{InstanceGuid: "guid-2"})
+			Expect(err).NotTo(HaveOccurred())
+
+			state, err := cell.State()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.LRPs).To(Equal([]rep.LRP{lrp("pg-1", 0, 256)}))
+		})
+
+		It("cancels tasks", func() {
+			Expect(cell.CancelTask("tg-1")).To(Succeed())
+
+			state, err := cell.State()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.Tasks).To(BeEmpty())
+		})
+
+		It("removes everything on reset", func() {
+			_, err := cell.Reserve(rep.NewHoldRequest(10, 10, 1, time.Minute))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cell.Reset()).To(Succeed())
+
+			state, err := cell.State()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.LRPs).To(BeEmpty())
+			Expect(state.Tasks).To(BeEmpty())
+			Expect(state.Holds).To(BeEmpty())
+			Expect(state.AvailableResources).To(Equal(rep.NewResources(1024, 1024, 4)))
+		})
+
+		It("reports the remaining containers in the evacuation status", func() {
+			evacuatable.Evacuate()
+
+			status, err := cell.EvacuationStatus()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status.Evacuating).To(BeTrue())
+			Expect(status.Complete).To(BeFalse())
+			Expect(status.RemainingContainers[rep.LRPLifecycle]).To(Equal(map[string]int{"running": 2}))
+		})
+	})
+
+	Describe("CancelEvacuation", func() {
+		It("returns ErrNoEvacuationInProgress when the cell is not evacuating", func() {
+			Expect(cell.CancelEvacuation()).To(Equal(rep.ErrNoEvacuationInProgress))
+		})
+
+		It("cancels an evacuation in progress", func() {
+			evacuatable.Evacuate()
+			Expect(cell.CancelEvacuation()).To(Succeed())
+		})
+	})
+})
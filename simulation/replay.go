@@ -0,0 +1,346 @@
+package simulation
+
+import (
+	"fmt"
+	"sort"
+
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/rep"
+)
+
+// LocalityOffset is added to a cell's score for every instance of the same
+// process already placed on it, so that instances spread across cells before
+// they stack up on the best scoring one.
+const LocalityOffset = 1000
+
+// Report summarizes how well a replayed scenario was placed.
+type Report struct {
+	StartingContainerWeight float64           `json:"starting_container_weight"`
+	Placed                  int               `json:"placed"`
+	Failed                  []FailedPlacement `json:"failed"`
+	Cells                   []CellReport      `json:"cells"`
+	Zones                   []ZoneReport      `json:"zones"`
+
+	// Fragmentation is the fraction of free memory that is not on the cell
+	// with the most free memory. It is 0 when all free memory could be used
+	// by a single container and approaches 1 as free memory is spread thinly
+	// across the fleet.
+	Fragmentation float64 `json:"fragmentation"`
+
+	// MaxZoneSpread is the largest difference, for any process, between the
+	// number of its instances in its most and least populated zones.
+	MaxZoneSpread int `json:"max_zone_spread"`
+
+	// UnbalancedProcesses counts the processes whose instances differ by more
+	// than one between any two zones.
+	UnbalancedProcesses int `json:"unbalanced_processes"`
+}
+
+type FailedPlacement struct {
+	Auction     int    `json:"auction"`
+	ProcessGuid string `json:"process_guid,omitempty"`
+	Index       int32  `json:"index,omitempty"`
+	TaskGuid    string `json:"task_guid,omitempty"`
+	Reason      string `json:"reason"`
+}
+
+type CellReport struct {
+	ID                   string  `json:"id"`
+	Zone                 string  `json:"zone"`
+	LRPs                 int     `json:"lrps"`
+	Tasks                int     `json:"tasks"`
+	MemoryUtilization    float64 `json:"memory_utilization"`
+	DiskUtilization      float64 `json:"disk_utilization"`
+	ContainerUtilization float64 `json:"container_utilization"`
+}
+
+type ZoneReport struct {
+	Zone              string  `json:"zone"`
+	Cells             int     `json:"cells"`
+	LRPs              int     `json:"lrps"`
+	Tasks             int     `json:"tasks"`
+	MemoryUtilization float64 `json:"memory_utilization"`
+}
+
+type simulatedCell struct {
+	id    string
+	state *rep.CellState
+}
+
+// Replay auctions the scenario's work against its fleet, one auction at a
+// time, and reports the resulting placement. Each LRP instance goes to the
+// lowest scoring cell in the zones with the fewest instances of its process;
+// each task goes to the lowest scoring cell in the fleet.
+func Replay(scenario Scenario, startingContainerWeight float64) Report {
+	cells := sortedCells(scenario.CellStates())
+
+	report := Report{
+		StartingContainerWeight: startingContainerWeight,
+		Failed:                  []FailedPlacement{},
+	}
+
+	for i, auction := range scenario.Auctions {
+		for _, cell := range cells {
+			cell.state.StartingContainerCount = 0
+		}
+
+		for _, spec := range auction.LRPs {
+			for index := int32(0); index < spec.Instances; index++ {
+				lrp := rep.NewLRP(
+					models.NewActualLRPKey(spec.ProcessGuid, index, spec.Domain),
+					rep.NewResource(spec.MemoryMB, spec.DiskMB, spec.RootFS, spec.VolumeDrivers),
+				)
+
+				err := placeLRP(cells, &lrp, startingContainerWeight)
+				if err != nil {
+					report.Failed = append(report.Failed, FailedPlacement{
+						Auction:     i,
+						ProcessGuid: spec.ProcessGuid,
+						Index:       index,
+						Reason:      err.Error(),
+					})
+					continue
+				}
+				report.Placed++
+			}
+		}
+
+		for _, spec := range auction.Tasks {
+			count := spec.Count
+			if count < 1 {
+				count = 1
+			}
+
+			for n := 0; n < count; n++ {
+				taskGuid := spec.TaskGuid
+				if count > 1 {
+					taskGuid = fmt.Sprintf("%s-%d", spec.TaskGuid, n)
+				}
+
+				task := rep.NewTask(taskGuid, spec.Domain, rep.NewResource(spec.MemoryMB, spec.DiskMB, spec.RootFS, spec.VolumeDrivers))
+
+				err := placeTask(cells, &task, startingContainerWeight)
+				if err != nil {
+					report.Failed = append(report.Failed, FailedPlacement{
+						Auction:  i,
+						TaskGuid: taskGuid,
+						Reason:   err.Error(),
+					})
+					continue
+				}
+				report.Placed++
+			}
+		}
+	}
+
+	report.Cells, report.Zones = utilization(cells)
+	report.Fragmentation = fragmentation(cells)
+	report.MaxZoneSpread, report.UnbalancedProcesses = zoneBalance(cells)
+
+	return report
+}
+
+func sortedCells(states map[string]*rep.CellState) []simulatedCell {
+	cells := make([]simulatedCell, 0, len(states))
+	for id, state := range states {
+		cells = append(cells, simulatedCell{id: id, state: state})
+	}
+	sort.Slice(cells, func(i, j int) bool { return cells[i].id < cells[j].id })
+	return cells
+}
+
+// candidates returns the cells with room for the resource. When none have
+// room, the error explains why.
+func candidates(cells []simulatedCell, res *rep.Resource) ([]simulatedCell, error) {
+	matches := []simulatedCell{}
+	err := rep.ErrorIncompatibleRootfs
+
+	for _, cell := range cells {
+		if !cell.state.MatchVolumeDrivers(res.VolumeDrivers) {
+			continue
+		}
+
+		matchErr := cell.state.ResourceMatch(res)
+		if matchErr == rep.ErrorInsufficientResources {
+			err = matchErr
+		}
+		if matchErr != nil {
+			continue
+		}
+
+		matches = append(matches, cell)
+	}
+
+	if len(matches) == 0 {
+		return nil, err
+	}
+
+	return matches, nil
+}
+
+func placeLRP(cells []simulatedCell, lrp *rep.LRP, startingContainerWeight float64) error {
+	matches, err := candidates(cells, &lrp.Resource)
+	if err != nil {
+		return err
+	}
+
+	zoneInstances := map[string]int{}
+	for _, cell := range cells {
+		zoneInstances[cell.state.Zone] += cell.state.InstanceCount(lrp.ProcessGuid)
+	}
+
+	fewest := -1
+	for _, cell := range matches {
+		count := zoneInstances[cell.state.Zone]
+		if fewest == -1 || count < fewest {
+			fewest = count
+		}
+	}
+
+	var winner *rep.CellState
+	var winningScore float64
+	for _, cell := range matches {
+		if zoneInstances[cell.state.Zone] != fewest {
+			continue
+		}
+
+		score := cell.state.ComputeScore(&lrp.Resource, startingContainerWeight)
+		score += float64(cell.state.InstanceCount(lrp.ProcessGuid) * LocalityOffset)
+		if winner == nil || score < winningScore {
+			winner = cell.state
+			winningScore = score
+		}
+	}
+
+	winner.AddLRP(lrp)
+	return nil
+}
+
+func placeTask(cells []simulatedCell, task *rep.Task, startingContainerWeight float64) error {
+	matches, err := candidates(cells, &task.Resource)
+	if err != nil {
+		return err
+	}
+
+	var winner *rep.CellState
+	var winningScore float64
+	for _, cell := range matches {
+		score := cell.state.ComputeScore(&task.Resource, startingContainerWeight)
+		if winner == nil || score < winningScore {
+			winner = cell.state
+			winningScore = score
+		}
+	}
+
+	winner.AddTask(task)
+	return nil
+}
+
+func utilization(cells []simulatedCell) ([]CellReport, []ZoneReport) {
+	cellReports := make([]CellReport, 0, len(cells))
+	zones := map[string]*ZoneReport{}
+	zoneMemory := map[string][2]int64{}
+
+	for _, cell := range cells {
+		state := cell.state
+		cellReports = append(cellReports, CellReport{
+			ID:                   cell.id,
+			Zone:                 state.Zone,
+			LRPs:                 len(state.LRPs),
+			Tasks:                len(state.Tasks),
+			MemoryUtilization:    usedFraction(int64(state.AvailableResources.MemoryMB), int64(state.TotalResources.MemoryMB)),
+			DiskUtilization:      usedFraction(int64(state.AvailableResources.DiskMB), int64(state.TotalResources.DiskMB)),
+			ContainerUtilization: usedFraction(int64(state.AvailableResources.Containers), int64(state.TotalResources.Containers)),
+		})
+
+		zone, ok := zones[state.Zone]
+		if !ok {
+			zone = &ZoneReport{Zone: state.Zone}
+			zones[state.Zone] = zone
+		}
+		zone.Cells++
+		zone.LRPs += len(state.LRPs)
+		zone.Tasks += len(state.Tasks)
+
+		memory := zoneMemory[state.Zone]
+		memory[0] += int64(state.AvailableResources.MemoryMB)
+		memory[1] += int64(state.TotalResources.MemoryMB)
+		zoneMemory[state.Zone] = memory
+	}
+
+	zoneReports := make([]ZoneReport, 0, len(zones))
+	for name, zone := range zones {
+		memory := zoneMemory[name]
+		zone.MemoryUtilization = usedFraction(memory[0], memory[1])
+		zoneReports = append(zoneReports, *zone)
+	}
+	sort.Slice(zoneReports, func(i, j int) bool { return zoneReports[i].Zone < zoneReports[j].Zone })
+
+	return cellReports, zoneReports
+}
+
+func usedFraction(available, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return 1.0 - float64(available)/float64(total)
+}
+
+func fragmentation(cells []simulatedCell) float64 {
+	var free, largest int64
+	for _, cell := range cells {
+		memory := int64(cell.state.AvailableResources.MemoryMB)
+		if cell.state.AvailableResources.Containers < 1 || memory <= 0 {
+			continue
+		}
+
+		free += memory
+		if memory > largest {
+			largest = memory
+		}
+	}
+
+	if free == 0 {
+		return 0
+	}
+	return 1.0 - float64(largest)/float64(free)
+}
+
+func zoneBalance(cells []simulatedCell) (int, int) {
+	zones := map[string]struct{}{}
+	instances := map[string]map[string]int{}
+
+	for _, cell := range cells {
+		zones[cell.state.Zone] = struct{}{}
+		for processGuid, count := range cell.state.ProcessInstanceCounts {
+			if instances[processGuid] == nil {
+				instances[processGuid] = map[string]int{}
+			}
+			instances[processGuid][cell.state.Zone] += count
+		}
+	}
+
+	maxSpread, unbalanced := 0, 0
+	for _, perZone := range instances {
+		least, most := -1, 0
+		for zone := range zones {
+			count := perZone[zone]
+			if least == -1 || count < least {
+				least = count
+			}
+			if count > most {
+				most = count
+			}
+		}
+
+		spread := most - least
+		if spread > maxSpread {
+			maxSpread = spread
+		}
+		if spread > 1 {
+			unbalanced++
+		}
+	}
+
+	return maxSpread, unbalanced
+}
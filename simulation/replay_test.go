@@ -0,0 +1,107 @@
+package simulation_test
+
+import (
+	"strings"
+
+	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/simulation"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Replay", func() {
+	var scenario simulation.Scenario
+
+	BeforeEach(func() {
+		scenario = simulation.Scenario{
+			Cells: []simulation.CellSpec{
+				{ID: "z1-cell", Zone: "z1", Count: 2, MemoryMB: 1024, DiskMB: 1024, Containers: 10, PreloadedRootFSes: []string{"cflinuxfs2"}},
+				{ID: "z2-cell", Zone: "z2", MemoryMB: 1024, DiskMB: 1024, Containers: 10, PreloadedRootFSes: []string{"cflinuxfs2"}},
+			},
+		}
+	})
+
+	Describe("LoadScenario", func() {
+		It("loads YAML scenarios", func() {
+			loaded, err := simulation.LoadScenario(strings.NewReader(`
+starting_container_weight: 0.25
+cells:
+- id: cell
+  zone: z1
+  count: 3
+  memory_mb: 1024
+  disk_mb: 2048
+  containers: 4
+  preloaded_rootfses: [cflinuxfs2]
+auctions:
+- lrps:
+  - process_guid: pg
+    instances: 2
+    memory_mb: 128
+    disk_mb: 128
+    rootfs: preloaded:cflinuxfs2
+`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(loaded.StartingContainerWeight).To(Equal(0.25))
+			Expect(loaded.CellStates()).To(HaveLen(3))
+			Expect(loaded.Auctions[0].LRPs[0].Instances).To(BeEquivalentTo(2))
+		})
+
+		It("loads JSON scenarios", func() {
+			loaded, err := simulation.LoadScenario(strings.NewReader(`{"cells":[{"id":"cell","zone":"z1","memory_mb":1024,"disk_mb":1024,"containers":4}]}`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(loaded.Cells[0].ID).To(Equal("cell"))
+		})
+
+		It("rejects scenarios without cells", func() {
+			_, err := simulation.LoadScenario(strings.NewReader(`auctions: []`))
+			Expect(err).To(Equal(simulation.ErrEmptyFleet))
+		})
+	})
+
+	It("balances the instances of a process across zones", func() {
+		scenario.Auctions = []simulation.AuctionSpec{
+			{LRPs: []simulation.LRPSpec{{ProcessGuid: "pg", Instances: 4, MemoryMB: 128, DiskMB: 128, RootFS: "preloaded:cflinuxfs2"}}},
+		}
+
+		report := simulation.Replay(scenario, 0.25)
+		Expect(report.Placed).To(Equal(4))
+		Expect(report.Failed).To(BeEmpty())
+		Expect(report.MaxZoneSpread).To(Equal(0))
+		Expect(report.Zones).To(ConsistOf(
+			simulation.ZoneReport{Zone: "z1", Cells: 2, LRPs: 2, MemoryUtilization: 0.125},
+			simulation.ZoneReport{Zone: "z2", Cells: 1, LRPs: 2, MemoryUtilization: 0.25},
+		))
+	})
+
+	It("places tasks on the least used cell", func() {
+		scenario.Auctions = []simulation.AuctionSpec{
+			{Tasks: []simulation.TaskSpec{{TaskGuid: "task", Count: 3, MemoryMB: 256, DiskMB: 256, RootFS: "preloaded:cflinuxfs2"}}},
+		}
+
+		report := simulation.Replay(scenario, 0.25)
+		Expect(report.Placed).To(Equal(3))
+		for _, cell := range report.Cells {
+			Expect(cell.Tasks).To(Equal(1))
+			Expect(cell.MemoryUtilization).To(Equal(0.25))
+		}
+		Expect(report.Fragmentation).To(BeNumerically("~", 2.0/3.0, 0.001))
+	})
+
+	It("reports work that could not be placed", func() {
+		scenario.Auctions = []simulation.AuctionSpec{
+			{
+				LRPs:  []simulation.LRPSpec{{ProcessGuid: "huge", Instances: 1, MemoryMB: 4096, DiskMB: 128, RootFS: "preloaded:cflinuxfs2"}},
+				Tasks: []simulation.TaskSpec{{TaskGuid: "windows", MemoryMB: 128, DiskMB: 128, RootFS: "preloaded:windows2012R2"}},
+			},
+		}
+
+		report := simulation.Replay(scenario, 0.25)
+		Expect(report.Placed).To(Equal(0))
+		Expect(report.Failed).To(ConsistOf(
+			simulation.FailedPlacement{ProcessGuid: "huge", Reason: rep.ErrorInsufficientResources.Error()},
+			simulation.FailedPlacement{TaskGuid: "windows", Reason: rep.ErrorIncompatibleRootfs.Error()},
+		))
+	})
+})
@@ -0,0 +1,160 @@
+package simulation
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"code.cloudfoundry.org/rep"
+	"github.com/ghodss/yaml"
+)
+
+var ErrEmptyFleet = errors.New("scenario has no cells")
+
+// Scenario describes a fleet of cells and the stream of auctions to replay
+// against it. Scenarios may be written in JSON or YAML.
+type Scenario struct {
+	StartingContainerWeight float64       `json:"starting_container_weight"`
+	Cells                   []CellSpec    `json:"cells"`
+	Auctions                []AuctionSpec `json:"auctions"`
+}
+
+// CellSpec describes one or more identical cells. When Count is greater than
+// one the cells are named <id>-0, <id>-1 and so on.
+type CellSpec struct {
+	ID                string   `json:"id"`
+	Zone              string   `json:"zone"`
+	Count             int      `json:"count,omitempty"`
+	MemoryMB          int32    `json:"memory_mb"`
+	DiskMB            int32    `json:"disk_mb"`
+	Containers        int      `json:"containers"`
+	PreloadedRootFSes []string `json:"preloaded_rootfses"`
+	RootFSProviders   []string `json:"rootfs_providers,omitempty"`
+	VolumeDrivers     []string `json:"volume_drivers,omitempty"`
+}
+
+// AuctionSpec is a single batch of work, auctioned together in the order it
+// is listed. Containers started by earlier batches are considered running by
+// the time a batch is auctioned.
+type AuctionSpec struct {
+	LRPs  []LRPSpec  `json:"lrps,omitempty"`
+	Tasks []TaskSpec `json:"tasks,omitempty"`
+}
+
+type LRPSpec struct {
+	ProcessGuid   string   `json:"process_guid"`
+	Domain        string   `json:"domain,omitempty"`
+	Instances     int32    `json:"instances"`
+	MemoryMB      int32    `json:"memory_mb"`
+	DiskMB        int32    `json:"disk_mb"`
+	RootFS        string   `json:"rootfs"`
+	VolumeDrivers []string `json:"volume_drivers,omitempty"`
+}
+
+// TaskSpec describes Count tasks with identical resources. When Count is
+// greater than one the tasks are named <task_guid>-0, <task_guid>-1 and so on.
+type TaskSpec struct {
+	TaskGuid      string   `json:"task_guid"`
+	Domain        string   `json:"domain,omitempty"`
+	Count         int      `json:"count,omitempty"`
+	MemoryMB      int32    `json:"memory_mb"`
+	DiskMB        int32    `json:"disk_mb"`
+	RootFS        string   `json:"rootfs"`
+	VolumeDrivers []string `json:"volume_drivers,omitempty"`
+}
+
+// LoadScenario reads a JSON or YAML scenario.
+func LoadScenario(r io.Reader) (Scenario, error) {
+	payload, err := ioutil.ReadAll(r)
+	if err != nil {
+		return Scenario{}, err
+	}
+
+	var scenario Scenario
+	err = yaml.Unmarshal(payload, &scenario)
+	if err != nil {
+		return Scenario{}, err
+	}
+
+	return scenario, scenario.Validate()
+}
+
+func (s Scenario) Validate() error {
+	if len(s.Cells) == 0 {
+		return ErrEmptyFleet
+	}
+
+	if s.StartingContainerWeight < 0 {
+		return fmt.Errorf("starting_container_weight must not be negative, got %f", s.StartingContainerWeight)
+	}
+
+	for i := range s.Cells {
+		cell := &s.Cells[i]
+		if cell.ID == "" {
+			return fmt.Errorf("cell %d has no id", i)
+		}
+		if cell.MemoryMB <= 0 || cell.DiskMB <= 0 || cell.Containers <= 0 {
+			return fmt.Errorf("cell %s must have positive memory, disk and containers", cell.ID)
+		}
+	}
+
+	for i := range s.Auctions {
+		for _, lrp := range s.Auctions[i].LRPs {
+			if lrp.ProcessGuid == "" {
+				return fmt.Errorf("auction %d has an lrp with no process_guid", i)
+			}
+		}
+		for _, task := range s.Auctions[i].Tasks {
+			if task.TaskGuid == "" {
+				return fmt.Errorf("auction %d has a task with no task_guid", i)
+			}
+		}
+	}
+
+	return nil
+}
+
+// CellStates builds the empty fleet described by the scenario.
+func (s Scenario) CellStates() map[string]*rep.CellState {
+	states := map[string]*rep.CellState{}
+
+	for _, spec := range s.Cells {
+		count := spec.Count
+		if count < 1 {
+			count = 1
+		}
+
+		for i := 0; i < count; i++ {
+			id := spec.ID
+			if count > 1 {
+				id = fmt.Sprintf("%s-%d", spec.ID, i)
+			}
+
+			total := rep.NewResources(spec.MemoryMB, spec.DiskMB, spec.Containers)
+			state := rep.NewCellState(
+				spec.rootFSProviders(),
+				total,
+				total,
+				nil,
+				nil,
+				spec.Zone,
+				0,
+				false,
+				spec.VolumeDrivers,
+			)
+			states[id] = &state
+		}
+	}
+
+	return states
+}
+
+func (s CellSpec) rootFSProviders() rep.RootFSProviders {
+	providers := rep.RootFSProviders{}
+	for _, scheme := range s.RootFSProviders {
+		providers[scheme] = rep.ArbitraryRootFSProvider{}
+	}
+	providers["preloaded"] = rep.NewFixedSetRootFSProvider(s.PreloadedRootFSes...)
+	return providers
+}
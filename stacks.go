@@ -0,0 +1,150 @@
+package rep
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// StackVersionSeparator separates the name and version of a preloaded stack
+// in the keys of a StackPathMap, as in cflinuxfs2@1.2.
+const StackVersionSeparator = "@"
+
+// StackVersionParam is the query parameter that selects a version of a
+// preloaded stack, as in preloaded:cflinuxfs2?version=1.2.
+const StackVersionParam = "version"
+
+// StackKey returns the StackPathMap key of the given version of a stack. An
+// empty version refers to the unversioned stack.
+func StackKey(name, version string) string {
+	if version == "" {
+		return name
+	}
+	return name + StackVersionSeparator + version
+}
+
+// SplitStackKey returns the name and version in a StackPathMap key.
+func SplitStackKey(key string) (string, string) {
+	parts := strings.SplitN(key, StackVersionSeparator, 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// Path returns the path of the given version of a stack. When no version is
+// requested, the unversioned stack is preferred, falling back to its highest
+// version.
+func (m StackPathMap) Path(name, version string) (string, bool) {
+	if version != "" {
+		path, ok := m[StackKey(name, version)]
+		return path, ok
+	}
+
+	if path, ok := m[name]; ok {
+		return path, true
+	}
+
+	versions := m.Versions()[name]
+	if len(versions) == 0 {
+		return "", false
+	}
+	return m[StackKey(name, versions[len(versions)-1])], true
+}
+
+// Versions returns the versions held of every stack, lowest first. Stacks
+// that are only held unversioned have no entry.
+func (m StackPathMap) Versions() map[string][]string {
+	versions := map[string][]string{}
+	for key := range m {
+		name, version := SplitStackKey(key)
+		if version != "" {
+			versions[name] = append(versions[name], version)
+		}
+	}
+
+	for name := range versions {
+		sortVersions(versions[name])
+	}
+	return versions
+}
+
+// Stacks returns the names of the stacks held, in any version.
+func (m StackPathMap) Stacks() []string {
+	seen := map[string]bool{}
+	stacks := []string{}
+	for key := range m {
+		name, _ := SplitStackKey(key)
+		if !seen[name] {
+			seen[name] = true
+			stacks = append(stacks, name)
+		}
+	}
+	sort.Strings(stacks)
+	return stacks
+}
+
+// PreloadedRootFSes returns every stack and stack version held, in the form
+// advertised in the cell presence: cflinuxfs2 and cflinuxfs2?version=1.2.
+func (m StackPathMap) PreloadedRootFSes() []string {
+	versions := m.Versions()
+
+	rootfses := []string{}
+	for _, name := range m.Stacks() {
+		rootfses = append(rootfses, name)
+		for _, version := range versions[name] {
+			rootfses = append(rootfses, fmt.Sprintf("%s?%s=%s", name, StackVersionParam, version))
+		}
+	}
+	return rootfses
+}
+
+// RootFSProvider returns a provider matching every stack held, which honours
+// version constraints on preloaded rootfs URLs.
+func (m StackPathMap) RootFSProvider() FixedSetRootFSProvider {
+	provider := NewFixedSetRootFSProvider(m.Stacks()...)
+
+	versions := m.Versions()
+	if len(versions) > 0 {
+		provider.Versions = map[string]StringSet{}
+		for name, held := range versions {
+			provider.Versions[name] = NewStringSet(held...)
+		}
+	}
+
+	return provider
+}
+
+// sortVersions orders dot-separated versions, comparing numeric components
+// numerically and others lexically.
+func sortVersions(versions []string) {
+	sort.Slice(versions, func(i, j int) bool {
+		return compareVersions(versions[i], versions[j]) < 0
+	})
+}
+
+func compareVersions(a, b string) int {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+
+		switch {
+		case aErr == nil && bErr == nil && an != bn:
+			if an < bn {
+				return -1
+			}
+			return 1
+		case (aErr != nil || bErr != nil) && as[i] != bs[i]:
+			if as[i] < bs[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	return len(as) - len(bs)
+}
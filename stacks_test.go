@@ -0,0 +1,87 @@
+package rep_test
+
+import (
+	"code.cloudfoundry.org/rep"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StackPathMap", func() {
+	var stacks rep.StackPathMap
+
+	BeforeEach(func() {
+		stacks = rep.StackPathMap{
+			"cflinuxfs2":        "/rootfs/cflinuxfs2",
+			"cflinuxfs2@1.10":   "/rootfs/cflinuxfs2-1.10",
+			"cflinuxfs2@1.9":    "/rootfs/cflinuxfs2-1.9",
+			"windows2012R2@2.0": "/rootfs/windows-2.0",
+		}
+	})
+
+	Describe("Path", func() {
+		It("resolves a specific version", func() {
+			path, ok := stacks.Path("cflinuxfs2", "1.9")
+			Expect(ok).To(BeTrue())
+			Expect(path).To(Equal("/rootfs/cflinuxfs2-1.9"))
+		})
+
+		It("prefers the unversioned stack when no version is requested", func() {
+			path, ok := stacks.Path("cflinuxfs2", "")
+			Expect(ok).To(BeTrue())
+			Expect(path).To(Equal("/rootfs/cflinuxfs2"))
+		})
+
+		It("falls back to the highest version", func() {
+			stacks["windows2012R2@10.0"] = "/rootfs/windows-10.0"
+
+			path, ok := stacks.Path("windows2012R2", "")
+			Expect(ok).To(BeTrue())
+			Expect(path).To(Equal("/rootfs/windows-10.0"))
+		})
+
+		It("does not resolve versions that are not held", func() {
+			_, ok := stacks.Path("cflinuxfs2", "2.0")
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	It("advertises every stack and version", func() {
+		Expect(stacks.PreloadedRootFSes()).To(Equal([]string{
+			"cflinuxfs2",
+			"cflinuxfs2?version=1.9",
+			"cflinuxfs2?version=1.10",
+			"windows2012R2",
+			"windows2012R2?version=2.0",
+		}))
+	})
+
+	Describe("the rootfs provider", func() {
+		var state rep.CellState
+
+		BeforeEach(func() {
+			total := rep.NewResources(1024, 1024, 4)
+			state = rep.NewCellState(
+				rep.RootFSProviders{"preloaded": stacks.RootFSProvider()},
+				total,
+				total,
+				nil,
+				nil,
+				"z1",
+				0,
+				false,
+				nil,
+			)
+		})
+
+		It("honors version constraints when matching resources", func() {
+			resource := rep.NewResource(10, 10, "preloaded:cflinuxfs2?version=1.10", nil)
+			Expect(state.ResourceMatch(&resource)).To(Succeed())
+
+			resource = rep.NewResource(10, 10, "preloaded:cflinuxfs2", nil)
+			Expect(state.ResourceMatch(&resource)).To(Succeed())
+
+			resource = rep.NewResource(10, 10, "preloaded:cflinuxfs2?version=2.0", nil)
+			Expect(state.ResourceMatch(&resource)).To(Equal(rep.ErrorIncompatibleRootfs))
+		})
+	})
+})
@@ -0,0 +1,109 @@
+package task_hooks
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"code.cloudfoundry.org/lager"
+)
+
+//go:generate counterfeiter -o fake_task_hooks/fake_dispatcher.go . Dispatcher
+
+// Dispatcher hands task completions to the hooks of the task's domain.
+type Dispatcher interface {
+	// TaskCompleted queues the completion for the hooks of its domain and
+	// returns without waiting for them. Completions that do not fit in the
+	// queue are dropped.
+	TaskCompleted(logger lager.Logger, completion Completion)
+}
+
+type job struct {
+	logger     lager.Logger
+	hook       Hook
+	completion Completion
+}
+
+// HookDispatcher runs hooks on a fixed number of workers, so that slow or
+// failing hooks hold up neither the caller nor each other beyond their
+// timeouts.
+type HookDispatcher struct {
+	logger        lager.Logger
+	hooks         map[string][]Hook
+	maxConcurrent int
+	queue         chan job
+}
+
+func NewDispatcher(logger lager.Logger, hooks map[string][]Hook, maxConcurrent, queueSize int) *HookDispatcher {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+
+	return &HookDispatcher{
+		logger:        logger.Session("task-hooks"),
+		hooks:         hooks,
+		maxConcurrent: maxConcurrent,
+		queue:         make(chan job, queueSize),
+	}
+}
+
+func (d *HookDispatcher) TaskCompleted(logger lager.Logger, completion Completion) {
+	for _, hook := range d.hooks[completion.Domain] {
+		select {
+		case d.queue <- job{logger: logger, hook: hook, completion: completion}:
+		default:
+			logger.Info("dropped-task-completion-hook", lager.Data{"hook": hook.Name(), "task-guid": completion.TaskGuid})
+		}
+	}
+}
+
+func (d *HookDispatcher) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	logger := d.logger
+	logger.Info("starting", lager.Data{"max-concurrent": d.maxConcurrent})
+	defer logger.Info("finished")
+
+	done := make(chan struct{})
+	wg := sync.WaitGroup{}
+	for i := 0; i < d.maxConcurrent; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.work(done)
+		}()
+	}
+
+	close(ready)
+
+	<-signals
+	close(done)
+	wg.Wait()
+	return nil
+}
+
+func (d *HookDispatcher) work(done <-chan struct{}) {
+	for {
+		select {
+		case j := <-d.queue:
+			d.run(j)
+		case <-done:
+			return
+		}
+	}
+}
+
+func (d *HookDispatcher) run(j job) {
+	logger := j.logger.Session("run-hook", lager.Data{"hook": j.hook.Name(), "task-guid": j.completion.TaskGuid})
+
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("hook-panicked", fmt.Errorf("%v", r))
+		}
+	}()
+
+	err := j.hook.Run(logger, j.completion)
+	if err != nil {
+		logger.Error("failed-running-hook", err)
+		return
+	}
+	logger.Info("succeeded-running-hook")
+}
@@ -0,0 +1,124 @@
+package task_hooks_test
+
+import (
+	"errors"
+
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/rep/task_hooks"
+	"code.cloudfoundry.org/rep/task_hooks/fake_task_hooks"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	"github.com/tedsuo/ifrit"
+)
+
+var _ = Describe("HookDispatcher", func() {
+	var (
+		logger      *lagertest.TestLogger
+		hookA       *fake_task_hooks.FakeHook
+		hookB       *fake_task_hooks.FakeHook
+		otherHook   *fake_task_hooks.FakeHook
+		dispatcher  *task_hooks.HookDispatcher
+		process     ifrit.Process
+		completion  task_hooks.Completion
+		maxWorkers  int
+		queueLength int
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		hookA = new(fake_task_hooks.FakeHook)
+		hookA.NameReturns("hook-a")
+		hookB = new(fake_task_hooks.FakeHook)
+		hookB.NameReturns("hook-b")
+		otherHook = new(fake_task_hooks.FakeHook)
+		otherHook.NameReturns("other-hook")
+
+		completion = task_hooks.Completion{TaskGuid: "some-task-guid", Domain: "some-domain"}
+		maxWorkers = 2
+		queueLength = 10
+	})
+
+	JustBeforeEach(func() {
+		dispatcher = task_hooks.NewDispatcher(logger, map[string][]task_hooks.Hook{
+			"some-domain":  {hookA, hookB},
+			"other-domain": {otherHook},
+		}, maxWorkers, queueLength)
+		process = ifrit.Invoke(dispatcher)
+	})
+
+	AfterEach(func() {
+		ifrit.Interrupt(process)
+		Eventually(process.Wait()).Should(Receive())
+	})
+
+	It("runs the hooks of the completed task's domain", func() {
+		dispatcher.TaskCompleted(logger, completion)
+
+		Eventually(hookA.RunCallCount).Should(Equal(1))
+		Eventually(hookB.RunCallCount).Should(Equal(1))
+		Consistently(otherHook.RunCallCount).Should(BeZero())
+
+		_, actual := hookA.RunArgsForCall(0)
+		Expect(actual).To(Equal(completion))
+	})
+
+	Context("when a hook fails", func() {
+		BeforeEach(func() {
+			hookA.RunReturns(errors.New("boom"))
+		})
+
+		It("still runs the other hooks", func() {
+			dispatcher.TaskCompleted(logger, completion)
+
+			Eventually(hookB.RunCallCount).Should(Equal(1))
+			Eventually(logger).Should(Say("failed-running-hook"))
+		})
+	})
+
+	Context("when a hook panics", func() {
+		BeforeEach(func() {
+			hookA.RunStub = func(lager.Logger, task_hooks.Completion) error {
+				panic("boom")
+			}
+		})
+
+		It("keeps running hooks", func() {
+			dispatcher.TaskCompleted(logger, completion)
+			dispatcher.TaskCompleted(logger, completion)
+
+			Eventually(hookA.RunCallCount).Should(Equal(2))
+			Eventually(hookB.RunCallCount).Should(Equal(2))
+			Eventually(logger).Should(Say("hook-panicked"))
+		})
+	})
+
+	Context("when the hooks are slow", func() {
+		var release chan struct{}
+
+		BeforeEach(func() {
+			maxWorkers = 1
+			queueLength = 1
+
+			release = make(chan struct{})
+			otherHook.RunStub = func(lager.Logger, task_hooks.Completion) error {
+				<-release
+				return nil
+			}
+		})
+
+		It("runs no more hooks at once than allowed and drops what does not fit in the queue", func() {
+			dispatcher.TaskCompleted(logger, task_hooks.Completion{TaskGuid: "other-task-guid", Domain: "other-domain"})
+			Eventually(otherHook.RunCallCount).Should(Equal(1))
+
+			dispatcher.TaskCompleted(logger, completion)
+			Expect(logger).To(Say("dropped-task-completion-hook"))
+			Consistently(hookA.RunCallCount).Should(BeZero())
+
+			close(release)
+			Eventually(hookA.RunCallCount).Should(Equal(1))
+			Consistently(hookB.RunCallCount).Should(BeZero())
+		})
+	})
+})
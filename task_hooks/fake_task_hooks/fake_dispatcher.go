@@ -0,0 +1,44 @@
+// This file was generated by counterfeiter
+package fake_task_hooks
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep/task_hooks"
+)
+
+type FakeDispatcher struct {
+	TaskCompletedStub        func(logger lager.Logger, completion task_hooks.Completion)
+	taskCompletedMutex       sync.RWMutex
+	taskCompletedArgsForCall []struct {
+		logger     lager.Logger
+		completion task_hooks.Completion
+	}
+}
+
+func (fake *FakeDispatcher) TaskCompleted(logger lager.Logger, completion task_hooks.Completion) {
+	fake.taskCompletedMutex.Lock()
+	fake.taskCompletedArgsForCall = append(fake.taskCompletedArgsForCall, struct {
+		logger     lager.Logger
+		completion task_hooks.Completion
+	}{logger, completion})
+	fake.taskCompletedMutex.Unlock()
+	if fake.TaskCompletedStub != nil {
+		fake.TaskCompletedStub(logger, completion)
+	}
+}
+
+func (fake *FakeDispatcher) TaskCompletedCallCount() int {
+	fake.taskCompletedMutex.RLock()
+	defer fake.taskCompletedMutex.RUnlock()
+	return len(fake.taskCompletedArgsForCall)
+}
+
+func (fake *FakeDispatcher) TaskCompletedArgsForCall(i int) (lager.Logger, task_hooks.Completion) {
+	fake.taskCompletedMutex.RLock()
+	defer fake.taskCompletedMutex.RUnlock()
+	return fake.taskCompletedArgsForCall[i].logger, fake.taskCompletedArgsForCall[i].completion
+}
+
+var _ task_hooks.Dispatcher = new(FakeDispatcher)
@@ -0,0 +1,86 @@
+// This file was generated by counterfeiter
+package fake_task_hooks
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep/task_hooks"
+)
+
+type FakeHook struct {
+	NameStub        func() string
+	nameMutex       sync.RWMutex
+	nameArgsForCall []struct{}
+	nameReturns     struct {
+		result1 string
+	}
+	RunStub        func(logger lager.Logger, completion task_hooks.Completion) error
+	runMutex       sync.RWMutex
+	runArgsForCall []struct {
+		logger     lager.Logger
+		completion task_hooks.Completion
+	}
+	runReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeHook) Name() string {
+	fake.nameMutex.Lock()
+	fake.nameArgsForCall = append(fake.nameArgsForCall, struct{}{})
+	fake.nameMutex.Unlock()
+	if fake.NameStub != nil {
+		return fake.NameStub()
+	} else {
+		return fake.nameReturns.result1
+	}
+}
+
+func (fake *FakeHook) NameCallCount() int {
+	fake.nameMutex.RLock()
+	defer fake.nameMutex.RUnlock()
+	return len(fake.nameArgsForCall)
+}
+
+func (fake *FakeHook) NameReturns(result1 string) {
+	fake.NameStub = nil
+	fake.nameReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeHook) Run(logger lager.Logger, completion task_hooks.Completion) error {
+	fake.runMutex.Lock()
+	fake.runArgsForCall = append(fake.runArgsForCall, struct {
+		logger     lager.Logger
+		completion task_hooks.Completion
+	}{logger, completion})
+	fake.runMutex.Unlock()
+	if fake.RunStub != nil {
+		return fake.RunStub(logger, completion)
+	} else {
+		return fake.runReturns.result1
+	}
+}
+
+func (fake *FakeHook) RunCallCount() int {
+	fake.runMutex.RLock()
+	defer fake.runMutex.RUnlock()
+	return len(fake.runArgsForCall)
+}
+
+func (fake *FakeHook) RunArgsForCall(i int) (lager.Logger, task_hooks.Completion) {
+	fake.runMutex.RLock()
+	defer fake.runMutex.RUnlock()
+	return fake.runArgsForCall[i].logger, fake.runArgsForCall[i].completion
+}
+
+func (fake *FakeHook) RunReturns(result1 error) {
+	fake.RunStub = nil
+	fake.runReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ task_hooks.Hook = new(FakeHook)
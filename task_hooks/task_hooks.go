@@ -0,0 +1,201 @@
+// task_hooks notifies cell-local hooks of the tasks completed on the cell
+package task_hooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/lager"
+)
+
+const (
+	// ExecScheme prefixes hooks that run an executable on the cell.
+	ExecScheme = "exec"
+	// HTTPScheme and HTTPSScheme prefix hooks that post to a local endpoint.
+	HTTPScheme  = "http"
+	HTTPSScheme = "https"
+)
+
+var (
+	ErrInvalidHook  = errors.New("invalid task completion hook")
+	ErrHookTimedOut = errors.New("task completion hook timed out")
+	ErrHookFailed   = errors.New("task completion hook failed")
+)
+
+// Completion describes a task whose completion has been reported to the BBS.
+// Hooks receive it as JSON.
+type Completion struct {
+	TaskGuid      string `json:"task_guid"`
+	Domain        string `json:"domain"`
+	CellID        string `json:"cell_id"`
+	Failed        bool   `json:"failed"`
+	FailureReason string `json:"failure_reason,omitempty"`
+	Result        string `json:"result,omitempty"`
+}
+
+//go:generate counterfeiter -o fake_task_hooks/fake_hook.go . Hook
+
+type Hook interface {
+	// Name identifies the hook in logs.
+	Name() string
+	// Run notifies the hook of a completion and returns once the hook has
+	// handled it or its timeout has passed.
+	Run(logger lager.Logger, completion Completion) error
+}
+
+// ParseHook parses a hook of the form "domain=exec:/path/to/executable" or
+// "domain=http://localhost:port/path", returning the domain it applies to.
+func ParseHook(value string, timeout time.Duration, clock clock.Clock) (string, Hook, error) {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", nil, fmt.Errorf("%s: not of the form 'domain=hook'", ErrInvalidHook)
+	}
+	domain, spec := parts[0], parts[1]
+
+	if strings.HasPrefix(spec, ExecScheme+":") {
+		path := strings.TrimPrefix(spec, ExecScheme+":")
+		if !filepath.IsAbs(path) {
+			return "", nil, fmt.Errorf("%s: executable path %q is not absolute", ErrInvalidHook, path)
+		}
+		return domain, NewExecHook(path, timeout, clock), nil
+	}
+
+	u, err := url.Parse(spec)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %s", ErrInvalidHook, err)
+	}
+	if u.Scheme != HTTPScheme && u.Scheme != HTTPSScheme {
+		return "", nil, fmt.Errorf("%s: unknown scheme in %q", ErrInvalidHook, spec)
+	}
+	if !isLoopback(u.Hostname()) {
+		return "", nil, fmt.Errorf("%s: %q is not a local endpoint", ErrInvalidHook, spec)
+	}
+	return domain, NewHTTPHook(u.String(), timeout), nil
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+type execHook struct {
+	path    string
+	timeout time.Duration
+	clock   clock.Clock
+}
+
+// NewExecHook returns a hook running the executable at path with the
+// completion on its standard input. The task guid, domain and failure state
+// are also passed in the TASK_GUID, TASK_DOMAIN and TASK_FAILED environment
+// variables. The executable is killed once the timeout passes.
+func NewExecHook(path string, timeout time.Duration, clock clock.Clock) Hook {
+	return &execHook{
+		path:    path,
+		timeout: timeout,
+		clock:   clock,
+	}
+}
+
+func (h *execHook) Name() string {
+	return ExecScheme + ":" + h.path
+}
+
+func (h *execHook) Run(logger lager.Logger, completion Completion) error {
+	payload, err := json.Marshal(completion)
+	if err != nil {
+		return err
+	}
+
+	output := &bytes.Buffer{}
+	cmd := exec.Command(h.path)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	cmd.Env = append(os.Environ(),
+		"TASK_GUID="+completion.TaskGuid,
+		"TASK_DOMAIN="+completion.Domain,
+		"TASK_FAILED="+strconv.FormatBool(completion.Failed),
+	)
+
+	err = cmd.Start()
+	if err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	timer := h.clock.NewTimer(h.timeout)
+	defer timer.Stop()
+
+	select {
+	case err = <-done:
+		if err != nil {
+			logger.Info("hook-output", lager.Data{"output": output.String()})
+			return fmt.Errorf("%s: %s", ErrHookFailed, err)
+		}
+		return nil
+	case <-timer.C():
+		// processes started by the executable may keep its output open, and
+		// waiting on them would block the worker, so only the executable is
+		// waited on: Wait reaps it before it waits for the output
+		cmd.Process.Kill()
+		return ErrHookTimedOut
+	}
+}
+
+type httpHook struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPHook returns a hook posting the completion as JSON to the given URL.
+// Responses other than 2xx are failures.
+func NewHTTPHook(url string, timeout time.Duration) Hook {
+	return &httpHook{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (h *httpHook) Name() string {
+	return h.url
+}
+
+func (h *httpHook) Run(logger lager.Logger, completion Completion) error {
+	payload, err := json.Marshal(completion)
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.client.Post(h.url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		if urlErr, ok := err.(*url.Error); ok && urlErr.Timeout() {
+			return ErrHookTimedOut
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s: status %d", ErrHookFailed, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package task_hooks_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestTaskHooks(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Task Hooks Suite")
+}
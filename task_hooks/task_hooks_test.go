@@ -0,0 +1,167 @@
+package task_hooks_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/rep/task_hooks"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Task completion hooks", func() {
+	var (
+		logger     *lagertest.TestLogger
+		completion task_hooks.Completion
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		completion = task_hooks.Completion{
+			TaskGuid:      "some-task-guid",
+			Domain:        "some-domain",
+			CellID:        "some-cell",
+			Failed:        true,
+			FailureReason: "because",
+		}
+	})
+
+	Describe("ParseHook", func() {
+		It("parses executable hooks", func() {
+			domain, hook, err := task_hooks.ParseHook("some-domain=exec:/usr/bin/hook", time.Second, clock.NewClock())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(domain).To(Equal("some-domain"))
+			Expect(hook.Name()).To(Equal("exec:/usr/bin/hook"))
+		})
+
+		It("parses local HTTP hooks", func() {
+			for _, endpoint := range []string{"http://localhost:8080/done", "https://127.0.0.1/done", "http://[::1]:80/"} {
+				domain, hook, err := task_hooks.ParseHook("some-domain="+endpoint, time.Second, clock.NewClock())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(domain).To(Equal("some-domain"))
+				Expect(hook.Name()).To(Equal(endpoint))
+			}
+		})
+
+		It("rejects invalid hooks", func() {
+			for _, value := range []string{
+				"exec:/usr/bin/hook",
+				"=exec:/usr/bin/hook",
+				"some-domain=exec:hook",
+				"some-domain=ftp://localhost/done",
+				"some-domain=http://example.com/done",
+			} {
+				_, _, err := task_hooks.ParseHook(value, time.Second, clock.NewClock())
+				Expect(err).To(HaveOccurred(), value)
+			}
+		})
+	})
+
+	Describe("exec hooks", func() {
+		var tmpDir string
+
+		BeforeEach(func() {
+			var err error
+			tmpDir, err = ioutil.TempDir("", "task-hooks")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tmpDir)
+		})
+
+		writeHook := func(script string) string {
+			path := filepath.Join(tmpDir, "hook")
+			err := ioutil.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755)
+			Expect(err).NotTo(HaveOccurred())
+			return path
+		}
+
+		It("passes the completion on stdin and in the environment", func() {
+			output := filepath.Join(tmpDir, "output")
+			path := writeHook("cat > " + output + "\necho \"$TASK_GUID $TASK_DOMAIN $TASK_FAILED\" >> " + output + "\n")
+
+			err := task_hooks.NewExecHook(path, time.Second, clock.NewClock()).Run(logger, completion)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := ioutil.ReadFile(output)
+			Expect(err).NotTo(HaveOccurred())
+
+			payload, err := json.Marshal(completion)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal(string(payload) + "some-task-guid some-domain true\n"))
+		})
+
+		It("fails when the executable fails", func() {
+			path := writeHook("exit 1\n")
+
+			err := task_hooks.NewExecHook(path, time.Second, clock.NewClock()).Run(logger, completion)
+			Expect(err).To(MatchError(ContainSubstring(task_hooks.ErrHookFailed.Error())))
+		})
+
+		It("kills the executable once the timeout passes", func() {
+			path := writeHook("exec sleep 10\n")
+
+			err := task_hooks.NewExecHook(path, 100*time.Millisecond, clock.NewClock()).Run(logger, completion)
+			Expect(err).To(Equal(task_hooks.ErrHookTimedOut))
+		})
+
+		It("does not wait for processes that keep the output open after the timeout", func() {
+			path := writeHook("sleep 5 &\nexec sleep 5\n")
+
+			errs := make(chan error, 1)
+			go func() {
+				errs <- task_hooks.NewExecHook(path, 100*time.Millisecond, clock.NewClock()).Run(logger, completion)
+			}()
+
+			Eventually(errs, 2*time.Second).Should(Receive(Equal(task_hooks.ErrHookTimedOut)))
+		})
+	})
+
+	Describe("HTTP hooks", func() {
+		var server *ghttp.Server
+
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("posts the completion", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/done"),
+				ghttp.VerifyJSONRepresenting(completion),
+				ghttp.RespondWith(http.StatusNoContent, nil),
+			))
+
+			err := task_hooks.NewHTTPHook(server.URL()+"/done", time.Second).Run(logger, completion)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("fails on an unsuccessful response", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusInternalServerError, nil))
+
+			err := task_hooks.NewHTTPHook(server.URL()+"/done", time.Second).Run(logger, completion)
+			Expect(err).To(MatchError(ContainSubstring(task_hooks.ErrHookFailed.Error())))
+		})
+
+		It("times out", func() {
+			server.AppendHandlers(func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(time.Second)
+			})
+
+			err := task_hooks.NewHTTPHook(server.URL()+"/done", 100*time.Millisecond).Run(logger, completion)
+			Expect(err).To(Equal(task_hooks.ErrHookTimedOut))
+		})
+	})
+})